@@ -0,0 +1,6 @@
+package agentspec
+
+// agentspec.schema.json 是 spec/agentspec.schema.json 的副本，随 CLI 一起编译
+// 修改规范后运行 go generate ./agentspec 同步
+
+//go:generate cp ../../spec/agentspec.schema.json agentspec.schema.json
@@ -0,0 +1,109 @@
+package agentspec
+
+import (
+	"encoding/json"
+	"path/filepath"
+)
+
+// SARIF 2.1.0 中用到的部分，供 CI 的代码扫描读取
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIF 将校验结果输出为 SARIF 2.1.0，toolVersion 为 CLI 版本
+func SARIF(reports []*Report, toolVersion string) ([]byte, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "agenthub",
+			Version:        toolVersion,
+			InformationURI: "https://agenthub.dev",
+		}},
+		Results: []sarifResult{},
+	}
+	index := make(map[string]int, len(Rules))
+	for i, rule := range Rules {
+		sr := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{Text: rule.Description}}
+		sr.DefaultConfiguration.Level = string(rule.Severity)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sr)
+		index[rule.ID] = i
+	}
+
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			text := issue.Message
+			if issue.Path != "" {
+				text = issue.Path + ": " + text
+			}
+			var loc sarifLocation
+			loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(report.File)
+			if issue.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: issue.Line, StartColumn: issue.Column}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    issue.Rule,
+				RuleIndex: index[issue.Rule],
+				Level:     string(issue.Severity),
+				Message:   sarifMessage{Text: text},
+				Locations: []sarifLocation{loc},
+			})
+		}
+	}
+
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+}
@@ -0,0 +1,77 @@
+package agentspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+// checkGolden 比较输出与 testdata/name，-update 时写入
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the output, run go test -update to regenerate\ngot:\n%s", path, got)
+	}
+}
+
+// invalidReport 校验 testdata/invalid，覆盖 schema、文件引用、工具参数、工作流和 lint 问题
+func invalidReport(t *testing.T) *Report {
+	t.Helper()
+	report, err := Validate(filepath.Join("testdata", "invalid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestReportJSONGolden(t *testing.T) {
+	data, err := json.MarshalIndent(invalidReport(t), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "invalid.json", append(data, '\n'))
+}
+
+func TestSARIFGolden(t *testing.T) {
+	data, err := SARIF([]*Report{invalidReport(t)}, "1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "invalid.sarif", append(data, '\n'))
+}
+
+func TestSARIFRuleIndex(t *testing.T) {
+	data, err := SARIF([]*Report{invalidReport(t)}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(Rules) {
+		t.Fatalf("driver lists %d rules, want %d", len(run.Tool.Driver.Rules), len(Rules))
+	}
+	for _, result := range run.Results {
+		if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+			t.Errorf("result %s points at rule %s", result.RuleID, rule.ID)
+		}
+	}
+}
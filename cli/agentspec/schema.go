@@ -0,0 +1,194 @@
+package agentspec
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed agentspec.schema.json
+var schemaJSON []byte
+
+// schema JSON Schema (draft-07) 中 agentspec.schema.json 用到的部分
+type schema struct {
+	Type       string             `json:"type"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	Enum       []interface{}      `json:"enum"`
+	Pattern    string             `json:"pattern"`
+	Format     string             `json:"format"`
+	MinLength  *int               `json:"minLength"`
+	MaxLength  *int               `json:"maxLength"`
+	Minimum    *float64           `json:"minimum"`
+	Maximum    *float64           `json:"maximum"`
+	MaxItems   *int               `json:"maxItems"`
+
+	pattern *regexp.Regexp
+}
+
+// specSchema 解析内置的 schema，内容随 CLI 编译，解析失败说明副本损坏
+func specSchema() *schema {
+	s := &schema{}
+	if err := json.Unmarshal(schemaJSON, s); err != nil {
+		panic(fmt.Sprintf("agentspec: bundled schema is invalid: %v", err))
+	}
+	s.compile()
+	return s
+}
+
+// compile 预先编译各层的 pattern
+func (s *schema) compile() {
+	if s.Pattern != "" {
+		s.pattern = regexp.MustCompile(s.Pattern)
+	}
+	for _, p := range s.Properties {
+		p.compile()
+	}
+	if s.Items != nil {
+		s.Items.compile()
+	}
+}
+
+// validate 按 schema 校验 YAML 节点，问题追加到 r
+func (s *schema) validate(r *Report, node *yaml.Node, path string) {
+	node = resolve(node)
+	if s.Type != "" && !matchesType(node, s.Type) {
+		r.add(RuleSchema, node, path, "应为 %s，实际为 %s", s.Type, nodeType(node))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for _, key := range s.Required {
+			if child := field(node, key); child == nil || isNull(child) {
+				r.add(RuleSchema, node, join(path, key), "缺少必填字段")
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if p, ok := s.Properties[node.Content[i].Value]; ok {
+				p.validate(r, node.Content[i+1], join(path, node.Content[i].Value))
+			}
+		}
+	case yaml.SequenceNode:
+		if s.MaxItems != nil && len(node.Content) > *s.MaxItems {
+			r.add(RuleSchema, node, path, "最多 %d 项，实际 %d 项", *s.MaxItems, len(node.Content))
+		}
+		if s.Items != nil {
+			for i, item := range node.Content {
+				s.Items.validate(r, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case yaml.ScalarNode:
+		s.validateScalar(r, node, path)
+	}
+}
+
+func (s *schema) validateScalar(r *Report, node *yaml.Node, path string) {
+	if len(s.Enum) > 0 && !s.inEnum(node.Value) {
+		r.add(RuleSchema, node, path, "必须是以下之一: %s", s.enumString())
+	}
+
+	if node.Tag == "!!str" {
+		length := utf8.RuneCountInString(node.Value)
+		if s.MinLength != nil && length < *s.MinLength {
+			r.add(RuleSchema, node, path, "长度不能少于 %d 个字符", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			r.add(RuleSchema, node, path, "长度不能超过 %d 个字符，实际 %d 个", *s.MaxLength, length)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(node.Value) {
+			r.add(RuleSchema, node, path, "格式不正确，应匹配 %s", s.Pattern)
+		}
+		if s.Format == "uri" {
+			if u, err := url.Parse(node.Value); err != nil || u.Scheme == "" || u.Host == "" {
+				r.add(RuleSchema, node, path, "应为完整的 URL")
+			}
+		}
+	}
+
+	if n, err := strconv.ParseFloat(node.Value, 64); err == nil && (node.Tag == "!!int" || node.Tag == "!!float") {
+		if s.Minimum != nil && n < *s.Minimum {
+			r.add(RuleSchema, node, path, "不能小于 %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			r.add(RuleSchema, node, path, "不能大于 %v", *s.Maximum)
+		}
+	}
+}
+
+func (s *schema) inEnum(value string) bool {
+	for _, v := range s.Enum {
+		if fmt.Sprint(v) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schema) enumString() string {
+	values := make([]string, len(s.Enum))
+	for i, v := range s.Enum {
+		values[i] = fmt.Sprint(v)
+	}
+	return strings.Join(values, ", ")
+}
+
+// matchesType 节点是否符合 JSON Schema 的 type
+func matchesType(node *yaml.Node, typ string) bool {
+	switch typ {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!str"
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float")
+	case "integer":
+		if node.Kind != yaml.ScalarNode {
+			return false
+		}
+		if node.Tag == "!!int" {
+			return true
+		}
+		n, err := strconv.ParseFloat(node.Value, 64)
+		return node.Tag == "!!float" && err == nil && n == math.Trunc(n)
+	case "null":
+		return isNull(node)
+	}
+	return true
+}
+
+// nodeType 节点对应的 JSON 类型，用于错误说明
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!str":
+		return "string"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!null":
+		return "null"
+	}
+	return node.Tag
+}
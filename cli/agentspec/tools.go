@@ -0,0 +1,146 @@
+package agentspec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonSchemaTypes JSON Schema 的 type 取值
+var jsonSchemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// toolNamePattern 模型调用工具时对名称的要求
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// checkTools 检查工具名称和参数定义
+func checkTools(r *Report, root *yaml.Node) {
+	tools := field(field(root, "capabilities"), "tools")
+	if tools == nil || tools.Kind != yaml.SequenceNode {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i, tool := range tools.Content {
+		tool = resolve(tool)
+		path := fmt.Sprintf("capabilities.tools[%d]", i)
+
+		if nameNode := field(tool, "name"); nameNode != nil {
+			name := scalar(nameNode)
+			if seen[name] {
+				r.add(RuleDuplicateTool, nameNode, path+".name", "工具 %q 重复定义", name)
+			}
+			seen[name] = true
+			if name != "" && !toolNamePattern.MatchString(name) {
+				r.warn(RuleJSONSchema, nameNode, path+".name", "工具名称只能包含字母、数字、_ 和 -，且不超过 64 个字符")
+			}
+		}
+
+		params := field(tool, "parameters")
+		if params == nil {
+			continue
+		}
+		checkJSONSchema(r, params, path+".parameters")
+		if params.Kind == yaml.MappingNode && scalar(field(params, "type")) != "object" {
+			r.add(RuleJSONSchema, params, path+".parameters", "工具参数的顶层 type 应为 object")
+		}
+	}
+}
+
+// checkInterface 检查输入输出定义中的 JSON Schema
+func checkInterface(r *Report, root *yaml.Node) {
+	iface := field(root, "interface")
+	for _, key := range []string{"input", "output"} {
+		if s := field(field(iface, key), "schema"); s != nil {
+			checkJSONSchema(r, s, "interface."+key+".schema")
+		}
+	}
+}
+
+// checkJSONSchema 检查节点本身是否为合法的 JSON Schema
+// 只检查常用关键字的类型以及 required 与 properties 是否一致，未知关键字忽略
+func checkJSONSchema(r *Report, node *yaml.Node, path string) {
+	node = resolve(node)
+	if node.Kind != yaml.MappingNode {
+		r.add(RuleJSONSchema, node, path, "应为 JSON Schema 对象，实际为 %s", nodeType(node))
+		return
+	}
+
+	if typ := field(node, "type"); typ != nil {
+		types := []*yaml.Node{typ}
+		if typ.Kind == yaml.SequenceNode {
+			types = typ.Content
+		}
+		for _, t := range types {
+			if !containsString(jsonSchemaTypes, scalar(t)) {
+				r.add(RuleJSONSchema, t, path+".type", "未知的类型 %q，可选值: %s", t.Value, strings.Join(jsonSchemaTypes, ", "))
+			}
+		}
+	}
+
+	properties := field(node, "properties")
+	if properties != nil {
+		if properties.Kind != yaml.MappingNode {
+			r.add(RuleJSONSchema, properties, path+".properties", "properties 应为对象")
+			properties = nil
+		} else {
+			for i := 0; i+1 < len(properties.Content); i += 2 {
+				checkJSONSchema(r, properties.Content[i+1], path+".properties."+properties.Content[i].Value)
+			}
+		}
+	}
+
+	if required := field(node, "required"); required != nil {
+		if required.Kind != yaml.SequenceNode {
+			r.add(RuleJSONSchema, required, path+".required", "required 应为字符串数组")
+		} else {
+			for i, name := range required.Content {
+				if properties != nil && field(properties, name.Value) == nil {
+					r.add(RuleJSONSchema, name, fmt.Sprintf("%s.required[%d]", path, i), "必填参数 %q 未在 properties 中定义", name.Value)
+				}
+			}
+		}
+	}
+
+	if items := field(node, "items"); items != nil {
+		if items.Kind == yaml.SequenceNode {
+			for i, item := range items.Content {
+				checkJSONSchema(r, item, fmt.Sprintf("%s.items[%d]", path, i))
+			}
+		} else {
+			checkJSONSchema(r, items, path+".items")
+		}
+	}
+
+	if additional := field(node, "additionalProperties"); additional != nil && additional.Kind != yaml.ScalarNode {
+		checkJSONSchema(r, additional, path+".additionalProperties")
+	} else if additional != nil && additional.Tag != "!!bool" {
+		r.add(RuleJSONSchema, additional, path+".additionalProperties", "additionalProperties 应为布尔值或 JSON Schema")
+	}
+
+	if enum := field(node, "enum"); enum != nil && (enum.Kind != yaml.SequenceNode || len(enum.Content) == 0) {
+		r.add(RuleJSONSchema, enum, path+".enum", "enum 应为非空数组")
+	}
+
+	if pattern := field(node, "pattern"); pattern != nil {
+		if _, err := regexp.Compile(pattern.Value); err != nil {
+			r.add(RuleJSONSchema, pattern, path+".pattern", "正则表达式不合法: %v", err)
+		}
+	}
+
+	for _, key := range []string{"minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems"} {
+		if n := field(node, key); n != nil && !matchesType(n, "number") {
+			r.add(RuleJSONSchema, n, path+"."+key, "%s 应为数字", key)
+		}
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
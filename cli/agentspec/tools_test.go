@@ -0,0 +1,47 @@
+package agentspec
+
+import "testing"
+
+// withTool 在 validSpec 后追加一个带参数定义的工具
+func withTool(parameters string) string {
+	return validSpec + "capabilities:\n  tools:\n    - name: search\n      description: Search the web\n      parameters:\n" + parameters
+}
+
+func TestCheckTools(t *testing.T) {
+	runIssueCases(t, []issueCase{
+		{"parameters not object type", withTool("        type: string\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters", SeverityError},
+		{"unknown type", withTool("        type: object\n        properties:\n          query: {type: text}\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties.query.type", SeverityError},
+		{"unknown type in list", withTool("        type: object\n        properties:\n          query: {type: [string, text]}\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties.query.type", SeverityError},
+		{"required not in properties", withTool("        type: object\n        properties:\n          query: {type: string}\n        required: [query, limit]\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.required[1]", SeverityError},
+		{"required not a list", withTool("        type: object\n        required: query\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.required", SeverityError},
+		{"properties not object", withTool("        type: object\n        properties: [query]\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties", SeverityError},
+		{"invalid pattern", withTool("        type: object\n        properties:\n          query: {type: string, pattern: \"[a-\"}\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties.query.pattern", SeverityError},
+		{"empty enum", withTool("        type: object\n        properties:\n          mode: {type: string, enum: []}\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties.mode.enum", SeverityError},
+		{"non-numeric maxLength", withTool("        type: object\n        properties:\n          query: {type: string, maxLength: long}\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties.query.maxLength", SeverityError},
+		{"invalid items", withTool("        type: object\n        properties:\n          ids: {type: array, items: {type: uuid}}\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.properties.ids.items.type", SeverityError},
+		{"invalid additionalProperties", withTool("        type: object\n        additionalProperties: maybe\n"),
+			RuleJSONSchema, "capabilities.tools[0].parameters.additionalProperties", SeverityError},
+		{"duplicate tool", validSpec + "capabilities:\n  tools:\n    - {name: search, description: a}\n    - {name: search, description: b}\n",
+			RuleDuplicateTool, "capabilities.tools[1].name", SeverityError},
+		{"tool name characters", validSpec + "capabilities:\n  tools:\n    - {name: web search, description: a}\n",
+			RuleJSONSchema, "capabilities.tools[0].name", SeverityWarning},
+		{"invalid input schema", validSpec + "interface:\n  input:\n    schema:\n      type: object\n      required: [text]\n      properties: {}\n",
+			RuleJSONSchema, "interface.input.schema.required[0]", SeverityError},
+	})
+
+	valid := withTool("        type: object\n        properties:\n          query: {type: string, pattern: \"^.+$\", maxLength: 100}\n" +
+		"          tags: {type: array, items: {type: string}}\n        required: [query]\n        additionalProperties: false\n")
+	if report := validateSpec(t, valid); len(report.Issues) != 0 {
+		t.Errorf("valid tool has issues: %+v", report.Issues)
+	}
+}
@@ -0,0 +1,276 @@
+// Package agentspec 校验 agentspec.yaml
+//
+// 校验分为几类：按内置的 agentspec.schema.json 检查字段，检查引用的文件是否存在，
+// 检查工具参数和接口定义中的 JSON Schema、工作流步骤之间的引用，以及不影响发布的规范建议 (lint)。
+// 每个问题带有所在的行列，可以输出为文本、JSON 或 SARIF。
+package agentspec
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName 项目目录中的规范文件名
+const FileName = "agentspec.yaml"
+
+// Severity 问题级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 不符合规范，发布或运行会失败
+	SeverityWarning Severity = "warning" // 建议修改
+)
+
+// Rule 校验规则
+type Rule struct {
+	ID          string
+	Severity    Severity // 默认级别，个别问题可以降级为 warning
+	Description string
+}
+
+// 校验规则，ID 用于输出和 SARIF，发布后不能改变含义
+var (
+	RuleSyntax            = &Rule{"syntax", SeverityError, "agentspec.yaml 不是合法的 YAML"}
+	RuleSchema            = &Rule{"schema", SeverityError, "字段不符合 agentspec.schema.json"}
+	RuleFileReference     = &Rule{"file-reference", SeverityError, "引用的文件不存在或不在项目目录内"}
+	RuleJSONSchema        = &Rule{"json-schema", SeverityError, "工具参数或接口定义中的 JSON Schema 不合法"}
+	RuleDuplicateTool     = &Rule{"duplicate-tool", SeverityError, "工具名称重复"}
+	RuleWorkflow          = &Rule{"workflow", SeverityError, "工作流的智能体或步骤引用不正确"}
+	RuleMissingLicense    = &Rule{"missing-license", SeverityWarning, "未声明开源协议"}
+	RuleDescriptionLength = &Rule{"description-length", SeverityWarning, "简介过长"}
+	RuleDuplicateTag      = &Rule{"duplicate-tag", SeverityWarning, "标签重复"}
+	RuleMissingPrompt     = &Rule{"missing-prompt", SeverityWarning, "prompt 运行时没有提示词"}
+)
+
+// Rules 全部规则
+var Rules = []*Rule{
+	RuleSyntax, RuleSchema, RuleFileReference, RuleJSONSchema, RuleDuplicateTool, RuleWorkflow,
+	RuleMissingLicense, RuleDescriptionLength, RuleDuplicateTag, RuleMissingPrompt,
+}
+
+// maxDescriptionLength 简介超过该长度时提示，schema 的硬性上限为 500
+const maxDescriptionLength = 200
+
+// Issue 一个问题
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path,omitempty"` // 字段路径，例如 capabilities.tools[0].name
+	Message  string   `json:"message"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+}
+
+// Report 一个文件的校验结果
+type Report struct {
+	File   string  `json:"file"`
+	Issues []Issue `json:"issues"`
+}
+
+// Errors 错误数
+func (r *Report) Errors() int {
+	return r.count(SeverityError)
+}
+
+// Warnings 警告数
+func (r *Report) Warnings() int {
+	return r.count(SeverityWarning)
+}
+
+func (r *Report) count(severity Severity) int {
+	n := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) add(rule *Rule, node *yaml.Node, path, format string, args ...interface{}) {
+	r.addIssue(rule, rule.Severity, node, path, format, args...)
+}
+
+func (r *Report) warn(rule *Rule, node *yaml.Node, path, format string, args ...interface{}) {
+	r.addIssue(rule, SeverityWarning, node, path, format, args...)
+}
+
+func (r *Report) addIssue(rule *Rule, severity Severity, node *yaml.Node, path, format string, args ...interface{}) {
+	issue := Issue{Rule: rule.ID, Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// Validate 校验项目目录中的 agentspec.yaml，文件无法读取时返回错误
+func Validate(dir string) (*Report, error) {
+	file := filepath.Join(dir, FileName)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{File: file, Issues: []Issue{}}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		r.Issues = append(r.Issues, Issue{
+			Rule:     RuleSyntax.ID,
+			Severity: SeverityError,
+			Message:  err.Error(),
+			Line:     syntaxErrorLine(err),
+		})
+		return r, nil
+	}
+	if len(doc.Content) == 0 {
+		r.add(RuleSchema, nil, "", "文件为空")
+		return r, nil
+	}
+	root := resolve(doc.Content[0])
+
+	specSchema().validate(r, root, "")
+	if root.Kind == yaml.MappingNode {
+		checkFiles(r, dir, root)
+		checkTools(r, root)
+		checkInterface(r, root)
+		checkWorkflow(r, root)
+		lint(r, root)
+	}
+
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		if r.Issues[i].Line != r.Issues[j].Line {
+			return r.Issues[i].Line < r.Issues[j].Line
+		}
+		return r.Issues[i].Column < r.Issues[j].Column
+	})
+	return r, nil
+}
+
+// checkFiles 检查 spec 中引用的文件
+func checkFiles(r *Report, dir string, root *yaml.Node) {
+	runtime := field(root, "runtime")
+	switch scalar(field(runtime, "type")) {
+	case "prompt", "python", "nodejs":
+		checkFile(r, dir, field(runtime, "entry"), "runtime.entry")
+	}
+	checkFile(r, dir, field(field(runtime, "python"), "requirements"), "runtime.python.requirements")
+	checkFile(r, dir, field(field(runtime, "nodejs"), "package"), "runtime.nodejs.package")
+	checkFile(r, dir, field(field(runtime, "docker"), "dockerfile"), "runtime.docker.dockerfile")
+	checkFile(r, dir, field(field(root, "prompts"), "system_file"), "prompts.system_file")
+}
+
+func checkFile(r *Report, dir string, node *yaml.Node, path string) {
+	name := scalar(node)
+	if name == "" {
+		return
+	}
+	local := filepath.FromSlash(name)
+	if filepath.IsAbs(local) || !filepath.IsLocal(local) {
+		r.add(RuleFileReference, node, path, "%s 必须是项目目录内的相对路径", name)
+		return
+	}
+	info, err := os.Stat(filepath.Join(dir, local))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		r.add(RuleFileReference, node, path, "文件 %s 不存在", name)
+	case err != nil:
+		r.add(RuleFileReference, node, path, "无法读取 %s: %v", name, err)
+	case info.IsDir():
+		r.add(RuleFileReference, node, path, "%s 是目录，应为文件", name)
+	}
+}
+
+// lint 不影响发布的规范建议
+func lint(r *Report, root *yaml.Node) {
+	metadata := field(root, "metadata")
+	if metadata == nil {
+		return
+	}
+
+	if scalar(field(metadata, "license")) == "" {
+		r.add(RuleMissingLicense, metadata, "metadata.license", "未声明 license，使用者无法确认能否使用")
+	}
+
+	if description := field(metadata, "description"); description != nil {
+		length := len([]rune(description.Value))
+		if length > maxDescriptionLength && length <= 500 {
+			r.add(RuleDescriptionLength, description, "metadata.description", "简介有 %d 个字符，建议不超过 %d 个，详细说明放在 README 中", length, maxDescriptionLength)
+		}
+	}
+
+	if tags := field(metadata, "tags"); tags != nil && tags.Kind == yaml.SequenceNode {
+		seen := make(map[string]bool)
+		for i, tag := range tags.Content {
+			key := strings.ToLower(strings.TrimSpace(tag.Value))
+			if seen[key] {
+				r.add(RuleDuplicateTag, tag, fmt.Sprintf("metadata.tags[%d]", i), "标签 %q 重复", tag.Value)
+			}
+			seen[key] = true
+		}
+	}
+
+	runtime := field(root, "runtime")
+	prompts := field(root, "prompts")
+	if scalar(field(runtime, "type")) == "prompt" && scalar(field(runtime, "entry")) == "" &&
+		scalar(field(prompts, "system")) == "" && scalar(field(prompts, "system_file")) == "" {
+		r.add(RuleMissingPrompt, runtime, "runtime", "prompt 运行时需要 runtime.entry、prompts.system 或 prompts.system_file 之一")
+	}
+}
+
+// resolve 展开 YAML 别名
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// field 映射节点中 key 对应的值，不存在或 node 不是映射时返回 nil
+func field(node *yaml.Node, key string) *yaml.Node {
+	node = resolve(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolve(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// scalar 标量节点的值，其他节点返回空字符串
+func scalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode || isNull(node) {
+		return ""
+	}
+	return node.Value
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// join 拼接字段路径
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// syntaxErrorLine 从 yaml 的错误信息中取出行号，例如 "yaml: line 3: ..."
+func syntaxErrorLine(err error) int {
+	var line int
+	if _, scanErr := fmt.Sscanf(err.Error(), "yaml: line %d:", &line); scanErr != nil {
+		return 0
+	}
+	return line
+}
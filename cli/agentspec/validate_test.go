@@ -0,0 +1,197 @@
+package agentspec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validSpec 没有任何问题的最小规范，用例在此基础上修改
+const validSpec = `version: "1.0.0"
+metadata:
+  name: helper
+  description: A helpful agent
+  author: alice
+  license: MIT
+  tags: [coding, review]
+runtime:
+  type: prompt
+  entry: prompts/system.md
+`
+
+// withMetadata 在 validSpec 的 metadata 末尾追加字段
+func withMetadata(lines string) string {
+	return strings.Replace(validSpec, "  tags: [coding, review]\n", "  tags: [coding, review]\n"+lines, 1)
+}
+
+// validateSpec 在临时目录中写入 spec 和 prompts/system.md 后校验
+func validateSpec(t *testing.T, spec string) *Report {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "prompts", "examples"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prompts", "system.md"), []byte("You are helpful."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := Validate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// findIssue 按规则和字段路径查找问题
+func findIssue(report *Report, rule *Rule, path string) *Issue {
+	for i, issue := range report.Issues {
+		if issue.Rule == rule.ID && issue.Path == path {
+			return &report.Issues[i]
+		}
+	}
+	return nil
+}
+
+// issueCase spec 应产生的一个问题
+type issueCase struct {
+	name     string
+	spec     string
+	rule     *Rule
+	path     string
+	severity Severity
+}
+
+func runIssueCases(t *testing.T, tests []issueCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := validateSpec(t, tt.spec)
+			issue := findIssue(report, tt.rule, tt.path)
+			if issue == nil {
+				t.Fatalf("no %s issue at %q, got %+v", tt.rule.ID, tt.path, report.Issues)
+			}
+			if issue.Severity != tt.severity {
+				t.Errorf("severity = %s, want %s", issue.Severity, tt.severity)
+			}
+		})
+	}
+}
+
+func TestValidateValidSpec(t *testing.T) {
+	report := validateSpec(t, validSpec)
+	if len(report.Issues) != 0 {
+		t.Errorf("valid spec has issues: %+v", report.Issues)
+	}
+}
+
+func TestValidateWithoutSpec(t *testing.T) {
+	if _, err := Validate(t.TempDir()); err == nil {
+		t.Error("directory without agentspec.yaml was accepted")
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	runIssueCases(t, []issueCase{
+		{"syntax error", "version: [1.0.0\n", RuleSyntax, "", SeverityError},
+		{"empty file", "", RuleSchema, "", SeverityError},
+		{"root not object", "- a\n", RuleSchema, "", SeverityError},
+		{"missing runtime", strings.Replace(validSpec, "runtime:\n  type: prompt\n  entry: prompts/system.md\n", "", 1),
+			RuleSchema, "runtime", SeverityError},
+		{"missing author", strings.Replace(validSpec, "  author: alice\n", "", 1), RuleSchema, "metadata.author", SeverityError},
+		{"null required field", strings.Replace(validSpec, "author: alice", "author:", 1), RuleSchema, "metadata.author", SeverityError},
+		{"unknown version", strings.Replace(validSpec, `"1.0.0"`, `"2.0.0"`, 1), RuleSchema, "version", SeverityError},
+		{"version as number", strings.Replace(validSpec, `"1.0.0"`, "1.0", 1), RuleSchema, "version", SeverityError},
+		{"name pattern", strings.Replace(validSpec, "name: helper", "name: Helper_Bot", 1), RuleSchema, "metadata.name", SeverityError},
+		{"name too short", strings.Replace(validSpec, "name: helper", "name: ab", 1), RuleSchema, "metadata.name", SeverityError},
+		{"description too long", strings.Replace(validSpec, "A helpful agent", strings.Repeat("x", 501), 1),
+			RuleSchema, "metadata.description", SeverityError},
+		{"too many tags", strings.Replace(validSpec, "[coding, review]", "[a, b, c, d, e, f, g, h, i, j, k]", 1),
+			RuleSchema, "metadata.tags", SeverityError},
+		{"tag not string", strings.Replace(validSpec, "[coding, review]", "[coding, 42]", 1), RuleSchema, "metadata.tags[1]", SeverityError},
+		{"homepage not uri", withMetadata("  homepage: example.com\n"), RuleSchema, "metadata.homepage", SeverityError},
+		{"unknown runtime", strings.Replace(validSpec, "type: prompt", "type: java", 1), RuleSchema, "runtime.type", SeverityError},
+		{"temperature above maximum", validSpec + "model:\n  parameters:\n    temperature: 3\n",
+			RuleSchema, "model.parameters.temperature", SeverityError},
+		{"max_tokens not integer", validSpec + "model:\n  parameters:\n    max_tokens: 1.5\n",
+			RuleSchema, "model.parameters.max_tokens", SeverityError},
+		{"streaming not boolean", validSpec + "capabilities:\n  streaming: \"yes\"\n", RuleSchema, "capabilities.streaming", SeverityError},
+		{"tool without description", validSpec + "capabilities:\n  tools:\n    - name: search\n",
+			RuleSchema, "capabilities.tools[0].description", SeverityError},
+	})
+}
+
+func TestValidateFileReferences(t *testing.T) {
+	runIssueCases(t, []issueCase{
+		{"missing runtime.entry", strings.Replace(validSpec, "prompts/system.md", "prompts/missing.md", 1),
+			RuleFileReference, "runtime.entry", SeverityError},
+		{"runtime.entry outside project", strings.Replace(validSpec, "prompts/system.md", "../system.md", 1),
+			RuleFileReference, "runtime.entry", SeverityError},
+		{"absolute runtime.entry", strings.Replace(validSpec, "prompts/system.md", "/etc/passwd", 1),
+			RuleFileReference, "runtime.entry", SeverityError},
+		{"runtime.entry is a directory", strings.Replace(validSpec, "prompts/system.md", "prompts/examples", 1),
+			RuleFileReference, "runtime.entry", SeverityError},
+		{"missing prompts.system_file", validSpec + "prompts:\n  system_file: prompts/other.md\n",
+			RuleFileReference, "prompts.system_file", SeverityError},
+		{"missing requirements", strings.Replace(validSpec, "type: prompt\n  entry: prompts/system.md\n",
+			"type: python\n  entry: prompts/system.md\n  python:\n    requirements: requirements.txt\n", 1),
+			RuleFileReference, "runtime.python.requirements", SeverityError},
+	})
+
+	// remote 运行时的 entry 是端点，不检查文件
+	remote := strings.Replace(validSpec, "type: prompt\n  entry: prompts/system.md\n", "type: remote\n  entry: https://example.com/agent\n", 1)
+	if issue := findIssue(validateSpec(t, remote), RuleFileReference, "runtime.entry"); issue != nil {
+		t.Errorf("remote entry reported as a file: %+v", issue)
+	}
+}
+
+func TestLint(t *testing.T) {
+	runIssueCases(t, []issueCase{
+		{"missing license", strings.Replace(validSpec, "  license: MIT\n", "", 1), RuleMissingLicense, "metadata.license", SeverityWarning},
+		{"description over 200 characters", strings.Replace(validSpec, "A helpful agent", strings.Repeat("智", 201), 1),
+			RuleDescriptionLength, "metadata.description", SeverityWarning},
+		{"duplicate tag", strings.Replace(validSpec, "[coding, review]", "[coding, review, coding]", 1),
+			RuleDuplicateTag, "metadata.tags[2]", SeverityWarning},
+		{"duplicate tag ignoring case", strings.Replace(validSpec, "[coding, review]", "[coding, ' Coding']", 1),
+			RuleDuplicateTag, "metadata.tags[1]", SeverityWarning},
+		{"prompt runtime without prompt", strings.Replace(validSpec, "  entry: prompts/system.md\n", "", 1),
+			RuleMissingPrompt, "runtime", SeverityWarning},
+	})
+
+	tests := []struct {
+		name string
+		spec string
+		rule *Rule
+	}{
+		// 超过 500 个字符由 schema 报错，不再重复提示
+		{"description over schema limit", strings.Replace(validSpec, "A helpful agent", strings.Repeat("x", 501), 1), RuleDescriptionLength},
+		{"description at 200 characters", strings.Replace(validSpec, "A helpful agent", strings.Repeat("智", 200), 1), RuleDescriptionLength},
+		{"inline system prompt", strings.Replace(validSpec, "  entry: prompts/system.md\n", "", 1) + "prompts:\n  system: You are helpful.\n", RuleMissingPrompt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, issue := range validateSpec(t, tt.spec).Issues {
+				if issue.Rule == tt.rule.ID {
+					t.Errorf("unexpected %s issue: %+v", tt.rule.ID, issue)
+				}
+			}
+		})
+	}
+}
+
+func TestIssuePositions(t *testing.T) {
+	spec := strings.Replace(validSpec, "[coding, review]", "[coding, review, coding]", 1)
+	report := validateSpec(t, spec)
+	issue := findIssue(report, RuleDuplicateTag, "metadata.tags[2]")
+	if issue == nil {
+		t.Fatalf("no duplicate-tag issue, got %+v", report.Issues)
+	}
+	if issue.Line != 7 || issue.Column != 26 {
+		t.Errorf("duplicate tag reported at %d:%d, want 7:26", issue.Line, issue.Column)
+	}
+	if report.Errors() != 0 || report.Warnings() != 1 {
+		t.Errorf("got %d errors and %d warnings, want 0 and 1", report.Errors(), report.Warnings())
+	}
+}
@@ -0,0 +1,236 @@
+package agentspec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// agentRefPattern 工作流中引用的智能体: namespace/name[@version]
+var agentRefPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*/[a-z0-9][a-z0-9-]*(@[^@\s]+)?$`)
+
+// stepRefPattern 步骤输入中对其他步骤输出的引用，例如 {{steps.research.output}}
+var stepRefPattern = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)\.`)
+
+// workflowStep 步骤及其在文件中的位置
+type workflowStep struct {
+	name      string
+	node      *yaml.Node
+	path      string
+	dependsOn []string
+}
+
+// checkWorkflow 检查工作流的智能体定义和步骤图
+// 步骤引用的智能体、depends_on 和 on_failure.goto 必须存在，depends_on 不能形成环，
+// 输入中引用的步骤输出应当是该步骤的 (间接) 依赖
+func checkWorkflow(r *Report, root *yaml.Node) {
+	workflow := field(root, "workflow")
+	if workflow == nil {
+		return
+	}
+	if workflow.Kind != yaml.MappingNode {
+		r.add(RuleWorkflow, workflow, "workflow", "workflow 应为对象")
+		return
+	}
+
+	agents := checkWorkflowAgents(r, field(workflow, "agents"))
+
+	stepsNode := field(workflow, "steps")
+	if stepsNode == nil {
+		return
+	}
+	if stepsNode.Kind != yaml.SequenceNode {
+		r.add(RuleWorkflow, stepsNode, "workflow.steps", "steps 应为数组")
+		return
+	}
+
+	// 先收集全部步骤名，depends_on 和 goto 可以引用后面的步骤
+	steps := make(map[string]*workflowStep)
+	var ordered []*workflowStep
+	for i, node := range stepsNode.Content {
+		node = resolve(node)
+		step := &workflowStep{node: node, path: fmt.Sprintf("workflow.steps[%d]", i)}
+		step.name = scalar(field(node, "name"))
+		switch {
+		case step.name == "":
+			r.add(RuleWorkflow, node, step.path+".name", "步骤缺少 name")
+		case steps[step.name] != nil:
+			r.add(RuleWorkflow, field(node, "name"), step.path+".name", "步骤 %q 重复定义", step.name)
+		default:
+			steps[step.name] = step
+		}
+		ordered = append(ordered, step)
+	}
+
+	for _, step := range ordered {
+		node := step.node
+
+		agent := field(node, "agent")
+		switch {
+		case scalar(agent) == "":
+			r.add(RuleWorkflow, node, step.path+".agent", "步骤缺少 agent")
+		case agents != nil && !agents[scalar(agent)]:
+			r.add(RuleWorkflow, agent, step.path+".agent", "智能体 %q 未在 workflow.agents 中定义", agent.Value)
+		}
+
+		if deps := field(node, "depends_on"); deps != nil && deps.Kind == yaml.SequenceNode {
+			for i, dep := range deps.Content {
+				depPath := fmt.Sprintf("%s.depends_on[%d]", step.path, i)
+				switch {
+				case dep.Value == step.name:
+					r.add(RuleWorkflow, dep, depPath, "步骤不能依赖自身")
+				case steps[dep.Value] == nil:
+					r.add(RuleWorkflow, dep, depPath, "依赖的步骤 %q 不存在", dep.Value)
+				default:
+					step.dependsOn = append(step.dependsOn, dep.Value)
+				}
+			}
+		} else if deps != nil {
+			r.add(RuleWorkflow, deps, step.path+".depends_on", "depends_on 应为步骤名数组")
+		}
+
+		onFailure := field(node, "on_failure")
+		if target := field(onFailure, "goto"); target != nil && steps[scalar(target)] == nil {
+			r.add(RuleWorkflow, target, step.path+".on_failure.goto", "跳转的步骤 %q 不存在", target.Value)
+		}
+		if retries := field(onFailure, "max_retries"); retries != nil && (!matchesType(retries, "integer") || strings.HasPrefix(retries.Value, "-")) {
+			r.add(RuleWorkflow, retries, step.path+".on_failure.max_retries", "max_retries 应为非负整数")
+		}
+	}
+
+	if cycle := findCycle(ordered, steps); cycle != nil {
+		r.add(RuleWorkflow, stepsNode, "workflow.steps", "步骤之间存在循环依赖: %s", strings.Join(cycle, " → "))
+		return
+	}
+
+	for _, step := range ordered {
+		checkStepInputs(r, step, steps)
+	}
+}
+
+// checkWorkflowAgents 检查 agents 并返回已定义的 ID，没有 agents 时返回 nil
+func checkWorkflowAgents(r *Report, node *yaml.Node) map[string]bool {
+	if node == nil {
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		r.add(RuleWorkflow, node, "workflow.agents", "agents 应为数组")
+		return nil
+	}
+
+	ids := make(map[string]bool)
+	for i, agent := range node.Content {
+		agent = resolve(agent)
+		path := fmt.Sprintf("workflow.agents[%d]", i)
+
+		id := field(agent, "id")
+		switch {
+		case scalar(id) == "":
+			r.add(RuleWorkflow, agent, path+".id", "智能体缺少 id")
+		case ids[id.Value]:
+			r.add(RuleWorkflow, id, path+".id", "智能体 %q 重复定义", id.Value)
+		default:
+			ids[id.Value] = true
+		}
+
+		ref := field(agent, "ref")
+		switch {
+		case scalar(ref) == "":
+			r.add(RuleWorkflow, agent, path+".ref", "智能体缺少 ref")
+		case !agentRefPattern.MatchString(ref.Value):
+			r.warn(RuleWorkflow, ref, path+".ref", "ref 应为 namespace/name 或 namespace/name@version")
+		}
+	}
+	return ids
+}
+
+// findCycle 按 depends_on 查找环，返回环上的步骤名 (首尾相同)
+func findCycle(ordered []*workflowStep, steps map[string]*workflowStep) []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range steps[name].dependsOn {
+			switch state[dep] {
+			case visiting:
+				for i, s := range stack {
+					if s == dep {
+						return append(append([]string{}, stack[i:]...), dep)
+					}
+				}
+			case 0:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, step := range ordered {
+		if steps[step.name] == step && state[step.name] == 0 {
+			if cycle := visit(step.name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// checkStepInputs 检查输入中引用的步骤输出，只能在依赖的步骤完成后使用
+func checkStepInputs(r *Report, step *workflowStep, steps map[string]*workflowStep) {
+	input := field(step.node, "input")
+	if input == nil {
+		return
+	}
+	ancestors := make(map[string]bool)
+	var collect func(name string)
+	collect = func(name string) {
+		for _, dep := range steps[name].dependsOn {
+			if !ancestors[dep] {
+				ancestors[dep] = true
+				collect(dep)
+			}
+		}
+	}
+	if steps[step.name] == step {
+		collect(step.name)
+	}
+
+	walkScalars(input, func(node *yaml.Node) {
+		for _, match := range stepRefPattern.FindAllStringSubmatch(node.Value, -1) {
+			ref := match[1]
+			switch {
+			case steps[ref] == nil:
+				r.add(RuleWorkflow, node, step.path+".input", "引用的步骤 %q 不存在", ref)
+			case ref == step.name:
+				r.add(RuleWorkflow, node, step.path+".input", "步骤不能引用自身的输出")
+			case !ancestors[ref]:
+				r.warn(RuleWorkflow, node, step.path+".input", "引用了步骤 %q 的输出，但未在 depends_on 中声明", ref)
+			}
+		}
+	})
+}
+
+// walkScalars 遍历节点下的全部标量
+func walkScalars(node *yaml.Node, fn func(*yaml.Node)) {
+	node = resolve(node)
+	if node.Kind == yaml.ScalarNode {
+		fn(node)
+		return
+	}
+	for _, child := range node.Content {
+		walkScalars(child, fn)
+	}
+}
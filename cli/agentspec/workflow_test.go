@@ -0,0 +1,77 @@
+package agentspec
+
+import "testing"
+
+// withWorkflow 在 validSpec 后追加工作流，agents 定义 researcher 和 writer
+func withWorkflow(steps string) string {
+	return validSpec + `workflow:
+  agents:
+    - id: researcher
+      ref: alice/researcher@1.0.0
+    - id: writer
+      ref: alice/writer
+  steps:
+` + steps
+}
+
+func TestCheckWorkflow(t *testing.T) {
+	runIssueCases(t, []issueCase{
+		{"cycle", withWorkflow(`    - {name: a, agent: researcher, depends_on: [c]}
+    - {name: b, agent: writer, depends_on: [a]}
+    - {name: c, agent: writer, depends_on: [b]}
+`), RuleWorkflow, "workflow.steps", SeverityError},
+		{"self dependency", withWorkflow("    - {name: a, agent: researcher, depends_on: [a]}\n"),
+			RuleWorkflow, "workflow.steps[0].depends_on[0]", SeverityError},
+		{"unknown dependency", withWorkflow("    - {name: a, agent: researcher, depends_on: [missing]}\n"),
+			RuleWorkflow, "workflow.steps[0].depends_on[0]", SeverityError},
+		{"depends_on not a list", withWorkflow("    - {name: a, agent: researcher, depends_on: b}\n"),
+			RuleWorkflow, "workflow.steps[0].depends_on", SeverityError},
+		{"unknown goto", withWorkflow("    - {name: a, agent: researcher, on_failure: {goto: missing}}\n"),
+			RuleWorkflow, "workflow.steps[0].on_failure.goto", SeverityError},
+		{"negative max_retries", withWorkflow("    - {name: a, agent: researcher, on_failure: {max_retries: -1}}\n"),
+			RuleWorkflow, "workflow.steps[0].on_failure.max_retries", SeverityError},
+		{"unknown agent", withWorkflow("    - {name: a, agent: editor}\n"),
+			RuleWorkflow, "workflow.steps[0].agent", SeverityError},
+		{"missing agent", withWorkflow("    - {name: a}\n"),
+			RuleWorkflow, "workflow.steps[0].agent", SeverityError},
+		{"missing name", withWorkflow("    - {agent: researcher}\n"),
+			RuleWorkflow, "workflow.steps[0].name", SeverityError},
+		{"duplicate step", withWorkflow("    - {name: a, agent: researcher}\n    - {name: a, agent: writer}\n"),
+			RuleWorkflow, "workflow.steps[1].name", SeverityError},
+		{"unknown step in input", withWorkflow("    - {name: a, agent: researcher, input: {topic: \"{{steps.missing.output}}\"}}\n"),
+			RuleWorkflow, "workflow.steps[0].input", SeverityError},
+		{"own output in input", withWorkflow("    - {name: a, agent: researcher, input: \"{{steps.a.output}}\"}\n"),
+			RuleWorkflow, "workflow.steps[0].input", SeverityError},
+		{"undeclared dependency in input", withWorkflow(`    - {name: a, agent: researcher}
+    - {name: b, agent: writer, input: {draft: "{{ steps.a.output }}"}}
+`), RuleWorkflow, "workflow.steps[1].input", SeverityWarning},
+		{"duplicate agent id", validSpec + "workflow:\n  agents:\n    - {id: a, ref: alice/a}\n    - {id: a, ref: alice/b}\n",
+			RuleWorkflow, "workflow.agents[1].id", SeverityError},
+		{"malformed ref", validSpec + "workflow:\n  agents:\n    - {id: a, ref: Alice}\n",
+			RuleWorkflow, "workflow.agents[0].ref", SeverityWarning},
+		{"steps not a list", validSpec + "workflow:\n  steps: {a: b}\n", RuleWorkflow, "workflow.steps", SeverityError},
+	})
+
+	// 依赖可以引用后面的步骤，输入可以引用间接依赖的输出
+	valid := withWorkflow(`    - {name: write, agent: writer, depends_on: [review], input: {notes: "{{steps.research.output}}"}}
+    - {name: research, agent: researcher, on_failure: {goto: research, max_retries: 2}}
+    - {name: review, agent: writer, depends_on: [research]}
+`)
+	if report := validateSpec(t, valid); len(report.Issues) != 0 {
+		t.Errorf("valid workflow has issues: %+v", report.Issues)
+	}
+}
+
+func TestFindCycleReportsPath(t *testing.T) {
+	report := validateSpec(t, withWorkflow(`    - {name: a, agent: researcher}
+    - {name: b, agent: writer, depends_on: [c]}
+    - {name: c, agent: writer, depends_on: [b]}
+`))
+	issue := findIssue(report, RuleWorkflow, "workflow.steps")
+	if issue == nil {
+		t.Fatalf("cycle not reported, got %+v", report.Issues)
+	}
+	if want := "步骤之间存在循环依赖: b → c → b"; issue.Message != want {
+		t.Errorf("message = %q, want %q", issue.Message, want)
+	}
+}
@@ -0,0 +1,376 @@
+// Package cache 本地内容寻址缓存，pull 和 run 共享
+//
+// 目录结构:
+//
+//	blobs/sha256/<digest>                 内容，文件名为内容的 SHA-256 十六进制摘要
+//	refs/<namespace>/<name>/<tag>.json    版本号或 latest 指向的摘要
+//
+// 带项目包的版本在 blobs 中另存一份 tar.gz，映射中记录包的摘要和包内各文件的摘要
+//
+// 写入都先写临时文件再重命名，中断的写入不会留下不完整的内容
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotCached 缓存中没有该版本
+var ErrNotCached = errors.New("not in cache")
+
+// IntegrityError 内容与摘要不一致
+type IntegrityError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("digest mismatch: expected %s, got %s", e.Expected, e.Actual)
+}
+
+// Ref 版本号或标签到内容摘要的映射
+type Ref struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Tag       string    `json:"tag"`     // 拉取时使用的版本号或 latest
+	Version   string    `json:"version"` // 实际版本号
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	FetchedAt time.Time `json:"fetched_at"`
+	UsedAt    time.Time `json:"used_at"` // 最近一次使用，prune 据此清理
+
+	// Package 项目包的摘要，只发布了 spec 的版本为空
+	Package string `json:"package,omitempty"`
+	// Files 包内各文件路径到摘要的映射，解压时校验
+	Files map[string]string `json:"files,omitempty"`
+}
+
+// FullName namespace/name@tag
+func (r *Ref) FullName() string {
+	return r.Namespace + "/" + r.Name + "@" + r.Tag
+}
+
+// Blob 缓存中的一份内容
+type Blob struct {
+	Digest  string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store 缓存目录
+type Store struct {
+	dir string
+}
+
+// New 使用 dir 作为缓存目录，目录在第一次写入时创建
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir 缓存目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Digest 内容的 SHA-256 十六进制摘要，与注册表中版本的 digest 一致
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put 写入内容并返回摘要；expected 不为空时先校验，不一致返回 *IntegrityError
+func (s *Store) Put(data []byte, expected string) (string, error) {
+	digest := Digest(data)
+	if expected != "" && digest != expected {
+		return "", &IntegrityError{Expected: expected, Actual: digest}
+	}
+	if err := writeFileAtomic(s.blobPath(digest), data); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// Has 内容是否存在，不校验摘要
+func (s *Store) Has(digest string) bool {
+	if !validDigest(digest) {
+		return false
+	}
+	_, err := os.Stat(s.blobPath(digest))
+	return err == nil
+}
+
+// Get 读取内容并校验摘要，不存在时返回 ErrNotCached，内容损坏时返回 *IntegrityError
+func (s *Store) Get(digest string) ([]byte, error) {
+	if !validDigest(digest) {
+		return nil, ErrNotCached
+	}
+	data, err := os.ReadFile(s.blobPath(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotCached
+	}
+	if err != nil {
+		return nil, err
+	}
+	if actual := Digest(data); actual != digest {
+		return nil, &IntegrityError{Expected: digest, Actual: actual}
+	}
+	return data, nil
+}
+
+// Resolve 查找 namespace/name@tag 的映射，不存在时返回 ErrNotCached
+func (s *Store) Resolve(namespace, name, tag string) (*Ref, error) {
+	path, err := s.refPath(namespace, name, tag)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := readRef(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotCached
+	}
+	return ref, err
+}
+
+// Tag 写入映射，覆盖同名的旧映射
+func (s *Store) Tag(ref *Ref) error {
+	path, err := s.refPath(ref.Namespace, ref.Name, ref.Tag)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(ref, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Touch 记录映射被使用，失败时忽略，只影响 prune 的判断
+func (s *Store) Touch(ref *Ref) {
+	ref.UsedAt = time.Now()
+	s.Tag(ref)
+}
+
+// Refs 列出全部映射，按名称和标签排序
+func (s *Store) Refs() ([]*Ref, error) {
+	var refs []*Ref
+	root := filepath.Join(s.dir, "refs")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		ref, err := readRef(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		refs = append(refs, ref)
+		return nil
+	})
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].FullName() < refs[j].FullName()
+	})
+	return refs, err
+}
+
+// Blobs 列出全部内容
+func (s *Store) Blobs() ([]Blob, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "blobs", "sha256"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var blobs []Blob
+	for _, entry := range entries {
+		if !validDigest(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, Blob{Digest: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return blobs, nil
+}
+
+// Verify 重新计算全部内容的摘要，返回损坏的内容和指向缺失内容的映射
+func (s *Store) Verify() (corrupt []Blob, dangling []*Ref, err error) {
+	blobs, err := s.Blobs()
+	if err != nil {
+		return nil, nil, err
+	}
+	present := make(map[string]bool, len(blobs))
+	for _, blob := range blobs {
+		_, err := s.Get(blob.Digest)
+		var integrity *IntegrityError
+		switch {
+		case errors.As(err, &integrity):
+			corrupt = append(corrupt, blob)
+		case err != nil:
+			return nil, nil, err
+		default:
+			present[blob.Digest] = true
+		}
+	}
+
+	refs, err := s.Refs()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, ref := range refs {
+		missing := func(digest string) bool {
+			return !present[digest] && !containsBlob(corrupt, digest)
+		}
+		if missing(ref.Digest) || (ref.Package != "" && missing(ref.Package)) {
+			dangling = append(dangling, ref)
+		}
+	}
+	return corrupt, dangling, nil
+}
+
+// Prune 删除 before 之前未使用的映射，再删除没有映射指向的内容，返回删除的映射数和释放的字节数
+func (s *Store) Prune(before time.Time) (refs int, freed int64, err error) {
+	all, err := s.Refs()
+	if err != nil {
+		return 0, 0, err
+	}
+	live := make(map[string]bool)
+	for _, ref := range all {
+		if lastUsed(ref).Before(before) {
+			path, err := s.refPath(ref.Namespace, ref.Name, ref.Tag)
+			if err != nil {
+				return refs, freed, err
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return refs, freed, err
+			}
+			refs++
+			continue
+		}
+		live[ref.Digest] = true
+		if ref.Package != "" {
+			live[ref.Package] = true
+		}
+	}
+
+	blobs, err := s.Blobs()
+	if err != nil {
+		return refs, freed, err
+	}
+	for _, blob := range blobs {
+		if live[blob.Digest] {
+			continue
+		}
+		if err := os.Remove(s.blobPath(blob.Digest)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return refs, freed, err
+		}
+		freed += blob.Size
+	}
+	return refs, freed, nil
+}
+
+// Clean 删除整个缓存目录
+func (s *Store) Clean() error {
+	return os.RemoveAll(s.dir)
+}
+
+func (s *Store) blobPath(digest string) string {
+	return filepath.Join(s.dir, "blobs", "sha256", digest)
+}
+
+// refPath 映射文件路径，各部分来自用户输入，不能包含路径分隔符
+func (s *Store) refPath(namespace, name, tag string) (string, error) {
+	for _, part := range []string{namespace, name, tag} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("invalid agent reference %s/%s@%s", namespace, name, tag)
+		}
+	}
+	return filepath.Join(s.dir, "refs", namespace, name, tag+".json"), nil
+}
+
+func readRef(path string) (*Ref, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ref := &Ref{}
+	if err := json.Unmarshal(data, ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// lastUsed 最近一次使用的时间，从未使用过时为拉取时间
+func lastUsed(ref *Ref) time.Time {
+	if ref.UsedAt.After(ref.FetchedAt) {
+		return ref.UsedAt
+	}
+	return ref.FetchedAt
+}
+
+func containsBlob(blobs []Blob, digest string) bool {
+	for _, blob := range blobs {
+		if blob.Digest == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// validDigest 64 位小写十六进制
+func validDigest(digest string) bool {
+	if len(digest) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range digest {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFileAtomic 在同一目录写临时文件后重命名
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// putRef 写入 spec 和可选的项目包，并建立 helper@tag 的映射
+func putRef(t *testing.T, s *Store, tag, spec, pkg string, fetched time.Time) *Ref {
+	t.Helper()
+	digest, err := s.Put([]byte(spec), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := &Ref{Namespace: "alice", Name: "helper", Tag: tag, Version: tag, Digest: digest, Size: int64(len(spec)), FetchedAt: fetched}
+	if pkg != "" {
+		if ref.Package, err = s.Put([]byte(pkg), ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Tag(ref); err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func TestPutAndGet(t *testing.T) {
+	s := New(t.TempDir())
+	data := []byte("version: 1.0.0\n")
+
+	var integrity *IntegrityError
+	if _, err := s.Put(data, Digest([]byte("other"))); !errors.As(err, &integrity) {
+		t.Fatalf("Put with a wrong digest returned %v, want *IntegrityError", err)
+	}
+	if s.Has(Digest(data)) {
+		t.Error("rejected content was written")
+	}
+
+	digest, err := s.Put(data, Digest(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(digest)
+	if err != nil || string(got) != string(data) {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+
+	for _, digest := range []string{Digest([]byte("missing")), "not-a-digest", "../../etc/passwd"} {
+		if _, err := s.Get(digest); !errors.Is(err, ErrNotCached) {
+			t.Errorf("Get(%q) returned %v, want ErrNotCached", digest, err)
+		}
+	}
+}
+
+func TestGetDetectsCorruption(t *testing.T) {
+	s := New(t.TempDir())
+	digest, err := s.Put([]byte("original"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.blobPath(digest), []byte("tampered"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var integrity *IntegrityError
+	if _, err := s.Get(digest); !errors.As(err, &integrity) {
+		t.Fatalf("Get of a corrupted blob returned %v, want *IntegrityError", err)
+	}
+	if integrity.Expected != digest || integrity.Actual != Digest([]byte("tampered")) {
+		t.Errorf("unexpected integrity error %+v", integrity)
+	}
+
+	corrupt, _, err := s.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 || corrupt[0].Digest != digest {
+		t.Errorf("Verify reported corrupt blobs %+v", corrupt)
+	}
+}
+
+func TestRefPathRejectsTraversal(t *testing.T) {
+	s := New(t.TempDir())
+	tests := []struct {
+		namespace, name, tag string
+	}{
+		{"..", "helper", "latest"},
+		{"alice", "..", "latest"},
+		{"alice", "helper", ".."},
+		{".", "helper", "latest"},
+		{"alice/../..", "helper", "latest"},
+		{"alice", "a/b", "latest"},
+		{"alice", "helper", `..\..\evil`},
+		{"", "helper", "latest"},
+		{"alice", "helper", ""},
+	}
+	for _, tt := range tests {
+		name := tt.namespace + "/" + tt.name + "@" + tt.tag
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.refPath(tt.namespace, tt.name, tt.tag); err == nil {
+				t.Error("refPath accepted the reference")
+			}
+			if _, err := s.Resolve(tt.namespace, tt.name, tt.tag); err == nil || errors.Is(err, ErrNotCached) {
+				t.Errorf("Resolve returned %v, want an invalid reference error", err)
+			}
+			err := s.Tag(&Ref{Namespace: tt.namespace, Name: tt.name, Tag: tt.tag, Digest: Digest(nil)})
+			if err == nil {
+				t.Error("Tag accepted the reference")
+			}
+		})
+	}
+
+	path, err := s.refPath("alice", "helper", "1.0.0")
+	if err != nil || !strings.HasPrefix(path, s.Dir()) {
+		t.Errorf("refPath for a valid reference = %q, %v", path, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	s := New(t.TempDir())
+	if _, err := s.Resolve("alice", "helper", "latest"); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Resolve on an empty cache returned %v, want ErrNotCached", err)
+	}
+
+	ref := putRef(t, s, "latest", "spec", "", time.Now())
+	got, err := s.Resolve("alice", "helper", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Digest != ref.Digest || got.FullName() != "alice/helper@latest" {
+		t.Errorf("Resolve = %+v", got)
+	}
+}
+
+func TestPruneKeepsLiveBlobs(t *testing.T) {
+	s := New(t.TempDir())
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	// 旧映射与新映射共享 spec，项目包只被旧映射引用
+	stale := putRef(t, s, "1.0.0", "shared spec", "old package", old)
+	live := putRef(t, s, "1.1.0", "shared spec", "new package", now)
+	orphan, err := s.Put([]byte("orphan"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 最近使用过的旧映射不会被清理
+	used := putRef(t, s, "0.9.0", "used spec", "used package", old)
+	s.Touch(used)
+
+	refs, freed, err := s.Prune(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refs != 1 {
+		t.Errorf("pruned %d refs, want 1", refs)
+	}
+	if want := int64(len("old package") + len("orphan")); freed != want {
+		t.Errorf("freed %d bytes, want %d", freed, want)
+	}
+
+	if _, err := s.Resolve("alice", "helper", stale.Tag); !errors.Is(err, ErrNotCached) {
+		t.Errorf("stale ref still resolves: %v", err)
+	}
+	for _, digest := range []string{live.Digest, live.Package, used.Digest, used.Package} {
+		if !s.Has(digest) {
+			t.Errorf("blob %s of a live ref was pruned", digest)
+		}
+	}
+	for _, digest := range []string{stale.Package, orphan} {
+		if s.Has(digest) {
+			t.Errorf("unreferenced blob %s was kept", digest)
+		}
+	}
+}
+
+func TestVerifyReportsDanglingRefs(t *testing.T) {
+	s := New(t.TempDir())
+	intact := putRef(t, s, "1.0.0", "spec 1", "package 1", time.Now())
+	missingSpec := putRef(t, s, "1.1.0", "spec 2", "", time.Now())
+	missingPackage := putRef(t, s, "1.2.0", "spec 3", "package 3", time.Now())
+
+	if err := os.Remove(s.blobPath(missingSpec.Digest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(s.blobPath(missingPackage.Package)); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt, dangling, err := s.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 0 {
+		t.Errorf("Verify reported corrupt blobs %+v", corrupt)
+	}
+	var names []string
+	for _, ref := range dangling {
+		names = append(names, ref.FullName())
+	}
+	if got, want := strings.Join(names, ","), "alice/helper@1.1.0,alice/helper@1.2.0"; got != want {
+		t.Errorf("dangling refs = %s, want %s", got, want)
+	}
+	if !s.Has(intact.Package) {
+		t.Error("intact package blob is missing")
+	}
+}
@@ -0,0 +1,5 @@
+package client
+
+// 客户端由服务端接口文档生成，修改 server/internal/api/openapi.yaml 后运行 go generate ./client 重新生成
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.0 -config oapi-codegen.yaml ../../server/internal/api/openapi.yaml
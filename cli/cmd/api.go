@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/agenthub/cli/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// apiTimeout 单次请求的超时时间
+const apiTimeout = 30 * time.Second
+
+// transferTimeout 上传或下载项目包的超时时间
+const transferTimeout = 10 * time.Minute
+
+// newAPIClient 创建注册表客户端，凭证按请求通过 withToken 或 withCredentials 附加
+func newAPIClient() *client.ClientWithResponses {
+	c, err := client.NewClientWithResponses(viper.GetString("api_url"),
+		client.WithHTTPClient(&http.Client{Timeout: apiTimeout}))
+	cobra.CheckErr(err)
+	return c
+}
+
+// newTransferClient 与 newAPIClient 相同，但超时时间按传输项目包设置
+func newTransferClient() *client.ClientWithResponses {
+	c, err := client.NewClientWithResponses(viper.GetString("api_url"),
+		client.WithHTTPClient(&http.Client{Timeout: transferTimeout}))
+	cobra.CheckErr(err)
+	return c
+}
+
+// withToken 携带登录令牌，未登录时不附加
+func withToken(ctx context.Context, req *http.Request) error {
+	if token := viper.GetString("token"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// withCredentials 优先携带 API Key，未配置时使用登录令牌
+// 用于读取私有智能体和调用，这两类接口同时接受两种凭证
+func withCredentials(ctx context.Context, req *http.Request) error {
+	if apiKey := viper.GetString("api_key"); apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+		return nil
+	}
+	return withToken(ctx, req)
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// apiError 服务端返回的错误 (RFC 7807 problem+json)
+type apiError struct {
+	Type       string           `json:"type"`
+	Status     int              `json:"status"`
+	Code       string           `json:"code"`
+	Detail     string           `json:"detail"`
+	Details    []apiErrorDetail `json:"details"`
+	RequestID  string           `json:"request_id"`
+	RetryAfter int              `json:"retry_after"`
+
+	// Legacy 旧版服务端只返回 error 字段
+	Legacy string `json:"error"`
+}
+
+// apiErrorDetail 错误明细，例如单个字段的校验失败
+type apiErrorDetail struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+// checkResponse 检查响应状态码，不在 expected 中时从响应体解析错误
+// resp 和 body 为生成客户端返回的 HTTPResponse 和 Body，expected 为空时接受所有 2xx
+func checkResponse(resp *http.Response, body []byte, expected ...int) error {
+	if len(expected) == 0 && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+
+	apiErr := &apiError{Status: resp.StatusCode}
+	if json.Unmarshal(body, apiErr) != nil || (apiErr.Code == "" && apiErr.Legacy == "") {
+		// 非 JSON 的错误响应 (例如网关返回的 HTML) 只保留开头部分
+		if len(body) > 64<<10 {
+			body = body[:64<<10]
+		}
+		apiErr.Detail = strings.TrimSpace(string(body))
+	}
+	apiErr.Status = resp.StatusCode
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Request-ID")
+	}
+	return apiErr
+}
+
+// Error 按用户语言输出错误说明、字段明细和请求 ID
+func (e *apiError) Error() string {
+	lang := userLang()
+
+	var b strings.Builder
+	b.WriteString(e.message(lang))
+	for _, d := range e.Details {
+		b.WriteString("\n  - ")
+		if d.Field != "" {
+			b.WriteString(d.Field + ": ")
+		}
+		b.WriteString(detailMessage(d, lang))
+	}
+	if e.RetryAfter > 0 {
+		b.WriteString("\n  " + fmt.Sprintf(localized(lang, "请在 %d 秒后重试", "retry in %d seconds"), e.RetryAfter))
+	}
+	if e.RequestID != "" {
+		b.WriteString("\n  " + localized(lang, "请求 ID: ", "request id: ") + e.RequestID)
+	}
+	return b.String()
+}
+
+// message 错误码有中文译文时使用译文，英文优先使用服务端更具体的说明
+func (e *apiError) message(lang string) string {
+	if texts, ok := errorMessages[e.Code]; ok {
+		if lang == "en" && e.Detail != "" {
+			return e.Detail
+		}
+		return localized(lang, texts[0], texts[1])
+	}
+	switch {
+	case e.Detail != "":
+		return e.Detail
+	case e.Legacy != "":
+		return e.Legacy
+	}
+	return fmt.Sprintf("HTTP %d %s", e.Status, http.StatusText(e.Status))
+}
+
+// errorMessages 错误码的中英文说明，与服务端 internal/api/errors.go 中的错误码对应
+var errorMessages = map[string][2]string{
+	"invalid_request":     {"请求格式错误", "malformed request"},
+	"validation_failed":   {"参数校验失败", "request validation failed"},
+	"invalid_spec":        {"agentspec.yaml 格式错误", "invalid agent spec"},
+	"invalid_package":     {"项目包无效或超过大小限制", "invalid or oversized package"},
+	"unauthorized":        {"未登录或登录已过期，请运行 'agenthub login'", "not logged in or session expired, run 'agenthub login'"},
+	"invalid_credentials": {"用户名或密码错误", "invalid username or password"},
+	"invalid_api_key":     {"API Key 无效或已过期", "invalid or expired API key"},
+	"forbidden":           {"没有权限执行此操作", "permission denied"},
+	"not_found":           {"资源不存在", "resource not found"},
+	"agent_not_found":     {"智能体不存在或无权访问", "agent not found"},
+	"version_not_found":   {"版本不存在", "version not found"},
+	"user_not_found":      {"用户不存在", "user not found"},
+	"conflict":            {"资源已存在", "resource already exists"},
+	"agent_exists":        {"智能体已存在", "agent already exists"},
+	"version_exists":      {"该版本已发布，请修改 agentspec.yaml 中的版本号", "version already published, bump the version in agentspec.yaml"},
+	"username_taken":      {"用户名已被占用", "username already taken"},
+	"email_taken":         {"邮箱已被注册", "email already registered"},
+	"rate_limited":        {"请求过于频繁", "rate limit exceeded"},
+	"login_locked":        {"登录失败次数过多，账号已暂时锁定", "too many failed login attempts, account temporarily locked"},
+	"request_cancelled":   {"请求已取消", "request cancelled"},
+	"timeout":             {"服务端处理超时", "request timed out"},
+	"service_unavailable": {"服务暂不可用，请稍后重试", "service unavailable, try again later"},
+	"internal_error":      {"服务端内部错误", "internal server error"},
+}
+
+// detailMessage 字段校验失败的说明
+func detailMessage(d apiErrorDetail, lang string) string {
+	if lang == "en" || d.Rule == "" {
+		return d.Message
+	}
+	switch d.Rule {
+	case "required":
+		return "必填"
+	case "email":
+		return "邮箱格式不正确"
+	case "url":
+		return "URL 格式不正确"
+	case "min":
+		return "不能少于 " + d.Param
+	case "max":
+		return "不能超过 " + d.Param
+	case "oneof":
+		return "必须是以下之一: " + d.Param
+	case "type":
+		return "类型错误，应为 " + d.Param
+	case "semver":
+		return "必须是语义化版本号，例如 1.2.0"
+	case "pattern":
+		return "格式不正确，应匹配 " + d.Param
+	}
+	return d.Message
+}
+
+// userLang 用户语言 (zh/en)
+// 优先使用配置项 lang (或 AGENTHUB_LANG)，其次是 LC_ALL、LC_MESSAGES、LANG，默认中文
+func userLang() string {
+	candidates := []string{viper.GetString("lang"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")}
+	for _, value := range candidates {
+		value = strings.ToLower(value)
+		switch {
+		case value == "" || value == "c" || value == "posix" || strings.HasPrefix(value, "c."):
+			continue
+		case strings.HasPrefix(value, "en"):
+			return "en"
+		default:
+			return "zh"
+		}
+	}
+	return "zh"
+}
+
+func localized(lang, zh, en string) string {
+	if lang == "en" {
+		return en
+	}
+	return zh
+}
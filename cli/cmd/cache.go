@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agenthub/cli/cache"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cacheOlderThan string
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "管理本地缓存",
+	Long: `管理 pull 和 run 共享的本地缓存。
+
+缓存按内容摘要保存已下载的版本，默认位于 ~/.agenthub/cache，
+可通过配置项 cache_dir 或环境变量 AGENTHUB_CACHE_DIR 修改。
+
+示例:
+  agenthub cache ls                       # 列出缓存的版本
+  agenthub cache verify                   # 校验缓存内容
+  agenthub cache prune --older-than 30d   # 清理 30 天未使用的版本
+  agenthub cache clean                    # 清空缓存`,
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "列出缓存的版本",
+	Args:  cobra.NoArgs,
+	Run:   runCacheLs,
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "校验缓存内容的摘要",
+	Args:  cobra.NoArgs,
+	Run:   runCacheVerify,
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "清空缓存",
+	Args:  cobra.NoArgs,
+	Run:   runCacheClean,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "清理长时间未使用的版本",
+	Args:  cobra.NoArgs,
+	Run:   runCachePrune,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheLsCmd, cacheVerifyCmd, cacheCleanCmd, cachePruneCmd)
+	cachePruneCmd.Flags().StringVar(&cacheOlderThan, "older-than", "30d", "清理超过该时长未使用的版本，例如 72h、30d")
+}
+
+// openCache 打开本地缓存
+func openCache() *cache.Store {
+	dir := viper.GetString("cache_dir")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+		dir = filepath.Join(home, ".agenthub", "cache")
+	}
+	return cache.New(dir)
+}
+
+func runCacheLs(cmd *cobra.Command, args []string) {
+	refs, err := openCache().Refs()
+	if err != nil {
+		fmt.Printf("读取缓存失败: %v\n", err)
+		os.Exit(1)
+	}
+	if len(refs) == 0 {
+		fmt.Println("缓存为空")
+		return
+	}
+
+	fmt.Printf("%-40s %-10s %-14s %10s  %s\n", "NAME", "VERSION", "DIGEST", "SIZE", "LAST USED")
+	for _, ref := range refs {
+		lastUsed := ref.UsedAt
+		if lastUsed.IsZero() {
+			lastUsed = ref.FetchedAt
+		}
+		fmt.Printf("%-40s %-10s %-14s %10s  %s\n",
+			ref.FullName(), ref.Version, shortDigest(ref.Digest), formatSize(ref.Size), lastUsed.Local().Format("2006-01-02 15:04"))
+	}
+}
+
+func runCacheVerify(cmd *cobra.Command, args []string) {
+	store := openCache()
+	corrupt, dangling, err := store.Verify()
+	if err != nil {
+		fmt.Printf("校验失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, blob := range corrupt {
+		fmt.Printf("✗ %s 内容已损坏\n", blob.Digest)
+	}
+	for _, ref := range dangling {
+		fmt.Printf("✗ %s 指向的内容不存在\n", ref.FullName())
+	}
+	if len(corrupt) > 0 || len(dangling) > 0 {
+		fmt.Println("\n重新 pull 对应的版本会覆盖损坏的内容，或运行 'agenthub cache clean' 清空缓存")
+		os.Exit(1)
+	}
+
+	blobs, _ := store.Blobs()
+	fmt.Printf("✓ %d 份内容校验通过\n", len(blobs))
+}
+
+func runCacheClean(cmd *cobra.Command, args []string) {
+	store := openCache()
+	if err := store.Clean(); err != nil {
+		fmt.Printf("清空缓存失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ 已清空 %s\n", store.Dir())
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) {
+	age, err := parseAge(cacheOlderThan)
+	if err != nil {
+		fmt.Printf("--older-than 格式错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	refs, freed, err := openCache().Prune(time.Now().Add(-age))
+	if err != nil {
+		fmt.Printf("清理失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ 已清理 %d 个版本，释放 %s\n", refs, formatSize(freed))
+}
+
+// parseAge 解析时长，在 time.ParseDuration 的基础上支持以天为单位，例如 30d
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// shortDigest 摘要的前 12 位，用于显示
+func shortDigest(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// formatSize 以 B、KB、MB 显示字节数
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
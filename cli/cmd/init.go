@@ -49,12 +49,7 @@ func runInit(cmd *cobra.Command, args []string) {
 	description, _ = reader.ReadString('\n')
 	description = strings.TrimSpace(description)
 
-	fmt.Print("分类 (assistant/coding/writing/analysis/creative/education/business/research/tooling/other) [assistant]: ")
-	category, _ = reader.ReadString('\n')
-	category = strings.TrimSpace(category)
-	if category == "" {
-		category = "assistant"
-	}
+	category = promptCategory(reader)
 
 	fmt.Print("运行时类型 (prompt/python/nodejs/docker/remote) [prompt]: ")
 	runtimeType, _ = reader.ReadString('\n')
@@ -93,6 +88,45 @@ func runInit(cmd *cobra.Command, args []string) {
 	fmt.Println("  3. 运行 'agenthub push' 发布")
 }
 
+// promptCategory 交互式选择分类，可选值来自服务端分类注册表
+func promptCategory(reader *bufio.Reader) string {
+	categories, err := fetchCategories()
+	if err != nil {
+		fmt.Printf("(无法获取分类列表: %v)\n", err)
+	}
+
+	ids := make([]string, 0, len(categories))
+	for _, cat := range categories {
+		ids = append(ids, cat.ID)
+	}
+
+	for {
+		if len(ids) > 0 {
+			fmt.Printf("分类 (%s) [assistant]: ", strings.Join(ids, "/"))
+		} else {
+			fmt.Print("分类 [assistant]: ")
+		}
+		category, _ := reader.ReadString('\n')
+		category = strings.TrimSpace(category)
+		if category == "" {
+			return "assistant"
+		}
+		if len(ids) == 0 || containsString(ids, category) {
+			return category
+		}
+		fmt.Printf("未知分类: %s\n", category)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func generateSpec(name, description, category, runtimeType, author string) string {
 	var runtimeSection, promptSection string
 
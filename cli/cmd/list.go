@@ -1,11 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/agenthub/cli/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -18,7 +20,8 @@ var listCmd = &cobra.Command{
 示例:
   agenthub list                    # 列出热门智能体
   agenthub list --category coding  # 列出编程类智能体
-  agenthub list --mine             # 列出我的智能体`,
+  agenthub list --mine             # 列出我的智能体
+  agenthub list --page 2           # 查看第 2 页`,
 	Run: runList,
 }
 
@@ -28,50 +31,51 @@ var (
 	listPage     int
 )
 
+// listPageSize 每页数量
+const listPageSize = 20
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 	listCmd.Flags().StringVarP(&listCategory, "category", "c", "", "按分类筛选")
 	listCmd.Flags().BoolVarP(&listMine, "mine", "m", false, "只显示我的智能体")
-	listCmd.Flags().IntVarP(&listPage, "page", "p", 1, "页码")
+	listCmd.Flags().IntVarP(&listPage, "page", "p", 1, "页码，每页 20 个")
 }
 
 func runList(cmd *cobra.Command, args []string) {
-	apiURL := viper.GetString("api_url")
-
-	var reqURL string
-
-	if listMine {
-		username := viper.GetString("username")
-		if username == "" {
-			fmt.Println("请先登录: agenthub login")
-			os.Exit(1)
-		}
-		reqURL = fmt.Sprintf("%s/api/v1/users/%s/agents", apiURL, username)
-	} else {
-		reqURL = fmt.Sprintf("%s/api/v1/agents?page=%d&page_size=20", apiURL, listPage)
-		if listCategory != "" {
-			reqURL += "&category=" + listCategory
-		}
-	}
-
-	resp, err := http.Get(reqURL)
-	if err != nil {
-		fmt.Printf("请求失败: %v\n", err)
+	if listPage < 1 {
+		fmt.Println("页码必须大于 0")
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
 
-	var result struct {
-		Agents []AgentInfo `json:"agents"`
-		Total  int64       `json:"total"`
+	username := viper.GetString("username")
+	if listMine && username == "" {
+		fmt.Println("请先登录: agenthub login")
+		os.Exit(1)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Printf("解析响应失败: %v\n", err)
-		os.Exit(1)
+	// 服务端使用游标分页，第 N 页需要沿 next_cursor 依次翻到
+	api := newAPIClient()
+	var result *client.AgentPage
+	var cursor *string
+	for page := 1; page <= listPage; page++ {
+		var err error
+		if listMine {
+			result, err = fetchUserAgents(api, username, cursor)
+		} else {
+			result, err = fetchAgents(api, listCategory, cursor)
+		}
+		if err != nil {
+			fmt.Printf("请求失败: %v\n", err)
+			os.Exit(1)
+		}
+		if !result.HasMore && page < listPage {
+			fmt.Printf("没有第 %d 页，共 %d 页\n", listPage, page)
+			return
+		}
+		cursor = result.NextCursor
 	}
 
-	if len(result.Agents) == 0 {
+	if len(result.Items) == 0 {
 		if listMine {
 			fmt.Println("你还没有发布任何智能体")
 			fmt.Println("使用 'agenthub init' 创建，'agenthub push' 发布")
@@ -90,13 +94,16 @@ func runList(cmd *cobra.Command, args []string) {
 	}
 	fmt.Println()
 
-	for _, agent := range result.Agents {
-		fullName := agent.Namespace + "/" + agent.Name
-		if agent.FullName != "" {
-			fullName = agent.FullName
+	for _, agent := range result.Items {
+		fullName := agentFullName(agent)
+		switch agent.Visibility {
+		case client.AgentVisibilityPrivate:
+			fmt.Printf("📦 %s 🔒 私有\n", fullName)
+		case client.AgentVisibilityUnlisted:
+			fmt.Printf("📦 %s 🔗 不公开\n", fullName)
+		default:
+			fmt.Printf("📦 %s\n", fullName)
 		}
-
-		fmt.Printf("📦 %s\n", fullName)
 		if agent.Description != "" {
 			desc := agent.Description
 			if len(desc) > 70 {
@@ -108,39 +115,91 @@ func runList(cmd *cobra.Command, args []string) {
 		fmt.Println()
 	}
 
-	if !listMine && result.Total > 20 {
-		fmt.Printf("共 %d 个智能体，使用 --page 查看更多\n", result.Total)
+	if result.HasMore {
+		fmt.Printf("还有更多，使用 --page %d 查看下一页\n", listPage+1)
 	}
 }
 
+// fetchAgents 获取一页公开智能体，cursor 为 nil 时获取第一页
+func fetchAgents(api *client.ClientWithResponses, category string, cursor *string) (*client.AgentPage, error) {
+	limit := listPageSize
+	params := &client.ListAgentsParams{Limit: &limit, Cursor: cursor}
+	if category != "" {
+		params.Category = &category
+	}
+
+	resp, err := api.ListAgentsWithResponse(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.JSON200, nil
+}
+
+// fetchUserAgents 获取一页用户的智能体
+// 携带登录凭证，服务端会为本人返回私有和不公开的智能体
+func fetchUserAgents(api *client.ClientWithResponses, username string, cursor *string) (*client.AgentPage, error) {
+	limit := listPageSize
+	params := &client.ListUserAgentsParams{Limit: &limit, Cursor: cursor}
+
+	resp, err := api.ListUserAgentsWithResponse(context.Background(), username, params, withToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.JSON200, nil
+}
+
+// agentFullName 智能体全名 namespace/name
+func agentFullName(agent client.Agent) string {
+	if agent.FullName != "" {
+		return agent.FullName
+	}
+	return agent.Namespace + "/" + agent.Name
+}
+
+// fetchCategories 从注册表获取分类列表
+func fetchCategories() ([]client.Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := newAPIClient().ListCategoriesWithResponse(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.JSON200.Items, nil
+}
+
 // categoriesCmd 列出所有分类
 var categoriesCmd = &cobra.Command{
 	Use:   "categories",
 	Short: "列出所有分类",
 	Run: func(cmd *cobra.Command, args []string) {
-		apiURL := viper.GetString("api_url")
-
-		resp, err := http.Get(apiURL + "/api/v1/categories")
+		categories, err := fetchCategories()
 		if err != nil {
 			fmt.Printf("请求失败: %v\n", err)
 			os.Exit(1)
 		}
-		defer resp.Body.Close()
-
-		var result struct {
-			Categories []struct {
-				ID    string `json:"id"`
-				Name  string `json:"name"`
-				Count int    `json:"count"`
-			} `json:"categories"`
-		}
-
-		json.NewDecoder(resp.Body).Decode(&result)
 
 		fmt.Println("可用分类:")
 		fmt.Println()
-		for _, cat := range result.Categories {
-			fmt.Printf("  %-15s %s\n", cat.ID, cat.Name)
+		for _, cat := range categories {
+			if cat.ParentID != nil {
+				continue
+			}
+			fmt.Printf("  %-15s %s (%d)\n", cat.ID, cat.Name, cat.Count)
+			for _, sub := range categories {
+				if sub.ParentID != nil && *sub.ParentID == cat.ID {
+					fmt.Printf("    %-13s %s (%d)\n", sub.ID, sub.Name, sub.Count)
+				}
+			}
 		}
 	},
 }
@@ -2,14 +2,14 @@ package cmd
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"syscall"
 
+	"github.com/agenthub/cli/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/term"
@@ -43,33 +43,21 @@ func runLogin(cmd *cobra.Command, args []string) {
 	fmt.Println()
 
 	// 发送登录请求
-	apiURL := viper.GetString("api_url")
-	reqBody, _ := json.Marshal(map[string]string{
-		"login":    login,
-		"password": password,
+	resp, err := newAPIClient().LoginWithResponse(context.Background(), client.LoginRequest{
+		Login:    login,
+		Password: password,
 	})
-
-	resp, err := http.Post(apiURL+"/api/v1/auth/login", "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
 		fmt.Printf("登录失败: %v\n", err)
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Println("登录失败: 用户名或密码错误")
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		fmt.Printf("登录失败: %v\n", err)
 		os.Exit(1)
 	}
-
-	var result struct {
-		User struct {
-			Username string `json:"username"`
-		} `json:"user"`
-		Token string `json:"token"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Printf("解析响应失败: %v\n", err)
+	result := resp.JSON200
+	if result.User == nil {
+		fmt.Println("解析响应失败: 缺少用户信息")
 		os.Exit(1)
 	}
 
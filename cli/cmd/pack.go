@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	packOutput  string
+	packVersion string
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack [path]",
+	Short: "在本地打包智能体",
+	Long: `将项目目录打包为 tar.gz，内容与 push 上传的项目包完全一致。
+
+可以用来在发布前检查包中的文件，相同的内容总是得到相同的摘要。
+默认输出到当前目录的 <name>-<version>.tar.gz，项目根目录下的这类文件不会被打包。
+
+示例:
+  agenthub pack                        # 打包当前目录
+  agenthub pack ./my-agent             # 打包指定目录
+  agenthub pack -o dist/agent.tar.gz   # 指定输出文件`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPack,
+}
+
+func init() {
+	rootCmd.AddCommand(packCmd)
+	packCmd.Flags().StringVarP(&packOutput, "output", "o", "", "输出文件")
+	packCmd.Flags().StringVarP(&packVersion, "version", "v", "", "版本号，用于默认文件名 (覆盖 spec 中的版本)")
+}
+
+func runPack(cmd *cobra.Command, args []string) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	_, spec := loadProject(path)
+	pkg := packProject(path, spec)
+
+	output := packOutput
+	if output == "" {
+		output = fmt.Sprintf("%s-%s.tar.gz", spec.Metadata.Name, spec.publishVersion(packVersion))
+	}
+	if err := os.WriteFile(output, pkg.Data, 0644); err != nil {
+		fmt.Printf("写入 %s 失败: %v\n", output, err)
+		os.Exit(1)
+	}
+
+	printPackage(pkg)
+	fmt.Printf("\n✓ 已写入 %s\n", output)
+}
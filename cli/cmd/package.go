@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agenthub/cli/cache"
+	"golang.org/x/term"
+)
+
+// 项目包限制，与服务端一致
+const (
+	maxPackageSize     = 50 << 20 // 压缩后的字节数
+	largeFileThreshold = 10 << 20 // 超过时提示
+)
+
+// defaultIgnores 总是忽略的路径，在 .gitignore 和 .agenthubignore 之前生效
+var defaultIgnores = []string{".git/", ".hg/", ".svn/", ".DS_Store"}
+
+// secretPatterns 疑似包含密钥的文件名
+var secretPatterns = []string{
+	".env", ".env.*", "*.pem", "*.key", "*.p12", "*.pfx", "id_rsa*", "id_ed25519*",
+	".npmrc", ".pypirc", ".netrc", "credentials*.json", "*secret*",
+}
+
+// packageFile 打包的文件
+type packageFile struct {
+	Path string // 相对路径，以 / 分隔
+	Size int64
+	Exec bool
+}
+
+// projectPackage 打包结果
+type projectPackage struct {
+	Data     []byte
+	Digest   string
+	Files    []packageFile
+	Warnings []string
+}
+
+// buildProjectPackage 打包项目目录
+// 文件按路径排序，修改时间、属主和权限都是固定值，同样的内容总是得到同样的摘要
+// 超过大小限制时返回的结果仍包含文件列表和提示，便于找出较大的文件
+// excludes 为额外忽略的规则，优先级低于 .gitignore 和 .agenthubignore
+func buildProjectPackage(root string, excludes ...string) (*projectPackage, error) {
+	files, warnings, err := collectPackageFiles(root, excludes)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &projectPackage{Files: files, Warnings: warnings}
+
+	var buf bytes.Buffer
+	if err := writePackage(&buf, root, files); err != nil {
+		return nil, err
+	}
+	if buf.Len() > maxPackageSize {
+		return pkg, fmt.Errorf("package is %s, larger than the %s limit", formatSize(int64(buf.Len())), formatSize(maxPackageSize))
+	}
+
+	pkg.Data = buf.Bytes()
+	pkg.Digest = cache.Digest(pkg.Data)
+	return pkg, nil
+}
+
+// publishForm 构造发布版本的 multipart 请求体，pkg 为空时只包含表单字段
+func publishForm(version, spec, changelog string, pkg *projectPackage) (contentType string, body []byte, err error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fields := [][2]string{{"version", version}, {"spec", spec}, {"changelog", changelog}}
+	for _, field := range fields {
+		if err := mw.WriteField(field[0], field[1]); err != nil {
+			return "", nil, err
+		}
+	}
+	if pkg != nil {
+		part, err := mw.CreateFormFile("package", "package.tar.gz")
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := part.Write(pkg.Data); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return "", nil, err
+	}
+	return mw.FormDataContentType(), buf.Bytes(), nil
+}
+
+// progressReader 读取时在终端显示进度条，输出不是终端时不显示
+type progressReader struct {
+	r       io.Reader
+	total   int64
+	read    int64
+	enabled bool
+}
+
+func newProgressReader(r io.Reader, total int64) *progressReader {
+	return &progressReader{r: r, total: total, enabled: term.IsTerminal(int(os.Stdout.Fd()))}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.enabled && p.total > 0 {
+		const width = 30
+		filled := int(p.read * width / p.total)
+		fmt.Printf("\r  [%s%s] %3d%% %s/%s", strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+			p.read*100/p.total, formatSize(p.read), formatSize(p.total))
+		if p.read >= p.total {
+			fmt.Println()
+			p.enabled = false
+		}
+	}
+	return n, err
+}
+
+// collectPackageFiles 列出需要打包的文件，跳过忽略的路径和符号链接
+func collectPackageFiles(root string, excludes []string) ([]packageFile, []string, error) {
+	rules := parseIgnoreRules(append(append([]string{}, defaultIgnores...), excludes...))
+	for _, name := range []string{".gitignore", ".agenthubignore"} {
+		lines, err := readLines(filepath.Join(root, name))
+		if err != nil {
+			return nil, nil, err
+		}
+		rules = append(rules, parseIgnoreRules(lines)...)
+	}
+
+	var files []packageFile
+	var warnings []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		// agentspec.yaml 必须打包
+		if rel != "agentspec.yaml" && rules.ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case d.IsDir():
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			warnings = append(warnings, fmt.Sprintf("%s 是符号链接，已跳过", rel))
+			return nil
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > largeFileThreshold {
+			warnings = append(warnings, fmt.Sprintf("%s 较大 (%s)，确认是否需要发布", rel, formatSize(info.Size())))
+		}
+		if looksSecret(path.Base(rel)) {
+			warnings = append(warnings, fmt.Sprintf("%s 可能包含密钥，不需要发布时请加入 .agenthubignore", rel))
+		}
+		files = append(files, packageFile{Path: rel, Size: info.Size(), Exec: info.Mode()&0111 != 0})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, warnings, nil
+}
+
+// writePackage 写入 tar.gz，只包含普通文件，目录由解压时按路径创建
+func writePackage(w io.Writer, root string, files []packageFile) error {
+	gzw := gzip.NewWriter(w) // 头部不含文件名和修改时间
+	tw := tar.NewWriter(gzw)
+
+	for _, f := range files {
+		mode := int64(0644)
+		if f.Exec {
+			mode = 0755
+		}
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.Path,
+			Size:     f.Size,
+			Mode:     mode,
+			ModTime:  time.Unix(0, 0),
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyFile(tw, filepath.Join(root, filepath.FromSlash(f.Path)), f.Size); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// copyFile 写入文件内容，打包过程中文件大小变化时报错
+func copyFile(w io.Writer, name string, size int64) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(w, io.LimitReader(f, size))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("%s changed while packing", name)
+	}
+	return nil
+}
+
+// looksSecret 文件名是否像密钥文件，示例文件除外
+func looksSecret(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".example") || strings.HasSuffix(lower, ".sample") {
+		return false
+	}
+	for _, pattern := range secretPatterns {
+		if ok, _ := path.Match(pattern, lower); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule .gitignore 格式的一条规则
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+type ignoreRules []ignoreRule
+
+// parseIgnoreRules 解析 .gitignore 格式的规则
+// 支持注释、! 取反、结尾 / 只匹配目录、包含 / 时相对项目根目录匹配，以及 *、?、[...] 和 **
+func parseIgnoreRules(lines []string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// 不含 / 的规则匹配任意层级的名称
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignored 判断路径是否被忽略，后出现的规则优先
+func (rules ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp 将 gitignore 通配符转换为正则表达式
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// readLines 读取文件的各行，文件不存在时返回空
+func readLines(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
@@ -2,22 +2,31 @@ package cmd
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/agenthub/cli/cache"
+	"github.com/agenthub/cli/client"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var (
 	pullVersion string
 	pullOutput  string
+	pullOffline bool
 )
 
 var pullCmd = &cobra.Command{
@@ -28,7 +37,10 @@ var pullCmd = &cobra.Command{
 示例:
   agenthub pull agenthub/code-reviewer
   agenthub pull user/my-agent@1.0.0
-  agenthub pull user/my-agent -o ./my-agents/`,
+  agenthub pull user/my-agent -o ./my-agents/
+  agenthub pull user/my-agent --offline      # 只从本地缓存安装
+
+版本带有项目包时下载整个项目目录，并按服务端给出的摘要逐个校验文件。`,
 	Args: cobra.ExactArgs(1),
 	Run:  runPull,
 }
@@ -37,11 +49,11 @@ func init() {
 	rootCmd.AddCommand(pullCmd)
 	pullCmd.Flags().StringVarP(&pullVersion, "version", "v", "latest", "指定版本")
 	pullCmd.Flags().StringVarP(&pullOutput, "output", "o", "", "输出目录")
+	pullCmd.Flags().BoolVar(&pullOffline, "offline", false, "只从本地缓存获取，不访问网络")
 }
 
 func runPull(cmd *cobra.Command, args []string) {
 	agentRef := args[0]
-	apiURL := viper.GetString("api_url")
 
 	// 解析 agent 引用
 	namespace, name, version := parseAgentRef(agentRef)
@@ -49,57 +61,238 @@ func runPull(cmd *cobra.Command, args []string) {
 		version = pullVersion
 	}
 
+	store := openCache()
+
+	if pullOffline {
+		ref, spec, err := resolveCached(store, namespace, name, version)
+		if err != nil {
+			fmt.Printf("离线模式下无法获取 %s/%s@%s: %v\n", namespace, name, version, err)
+			os.Exit(1)
+		}
+		installPulled(store, ref, spec, true)
+		return
+	}
+
 	fmt.Printf("📥 正在下载 %s/%s@%s ...\n", namespace, name, version)
 
-	// 获取版本信息
-	versionURL := fmt.Sprintf("%s/api/v1/agents/%s/%s/versions/%s", apiURL, namespace, name, version)
-	resp, err := http.Get(versionURL)
+	// 已缓存时带上摘要，版本未变化时服务端返回 304，不再传输内容
+	ref, spec, err := resolveCached(store, namespace, name, version)
+	params := &client.GetVersionParams{}
+	if err == nil {
+		etag := `"` + ref.Digest + `"`
+		params.IfNoneMatch = &etag
+	}
+
+	// 获取版本信息，私有智能体需要凭证
+	resp, err := newAPIClient().GetVersionWithResponse(context.Background(), namespace, name, version, params, withCredentials)
 	if err != nil {
 		fmt.Printf("下载失败: %v\n", err)
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Printf("智能体 %s/%s@%s 不存在\n", namespace, name, version)
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK, http.StatusNotModified); err != nil {
+		fmt.Printf("下载 %s/%s@%s 失败: %v\n", namespace, name, version, err)
 		os.Exit(1)
 	}
 
-	var versionInfo struct {
-		Version string `json:"version"`
-		Spec    string `json:"spec"`
-		Size    int64  `json:"size"`
+	// 智能体已被转移或重命名
+	if newNamespace, newName, ok := resolvedRef(resp.HTTPResponse.Header.Get("Location")); ok {
+		warnMoved(namespace, name, newNamespace, newName)
+		namespace, name = newNamespace, newName
+	}
+
+	if resp.StatusCode() == http.StatusNotModified {
+		installPulled(store, ref, spec, true)
+		return
+	}
+
+	versionInfo := resp.JSON200
+	spec = []byte(versionInfo.Spec)
+
+	// 校验内容与服务端给出的摘要一致后写入缓存
+	digest, err := store.Put(spec, versionInfo.Digest)
+	if err != nil {
+		fmt.Printf("校验 %s/%s@%s 失败: %v\n", namespace, name, versionInfo.Version, err)
+		os.Exit(1)
+	}
+	if err := fetchPackage(store, namespace, name, versionInfo); err != nil {
+		fmt.Printf("下载 %s/%s@%s 的项目包失败: %v\n", namespace, name, versionInfo.Version, err)
+		os.Exit(1)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&versionInfo); err != nil {
-		fmt.Printf("解析响应失败: %v\n", err)
+	now := time.Now()
+	ref = &cache.Ref{
+		Namespace: namespace,
+		Name:      name,
+		Tag:       versionInfo.Version,
+		Version:   versionInfo.Version,
+		Digest:    digest,
+		Size:      int64(len(spec)),
+		FetchedAt: now,
+		UsedAt:    now,
+	}
+	if versionInfo.PackageDigest != nil {
+		ref.Package = *versionInfo.PackageDigest
+		ref.Files = make(map[string]string)
+		if versionInfo.Files != nil {
+			for _, f := range *versionInfo.Files {
+				ref.Files[f.Path] = f.Digest
+			}
+		}
+	}
+	if err := store.Tag(ref); err != nil {
+		fmt.Printf("写入缓存失败: %v\n", err)
 		os.Exit(1)
 	}
+	// 通过 latest 拉取时同时记录 latest 指向的版本，供离线模式使用
+	if version != versionInfo.Version {
+		latest := *ref
+		latest.Tag = version
+		if err := store.Tag(&latest); err != nil {
+			fmt.Printf("写入缓存失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	installPulled(store, ref, spec, false)
+}
+
+// resolveCached 从缓存中查找版本并读取内容，内容或项目包缺失、内容损坏时视为未缓存
+func resolveCached(store *cache.Store, namespace, name, tag string) (*cache.Ref, []byte, error) {
+	ref, err := store.Resolve(namespace, name, tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec, err := store.Get(ref.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ref.Package != "" && !store.Has(ref.Package) {
+		return nil, nil, cache.ErrNotCached
+	}
+	return ref, spec, nil
+}
 
+// fetchPackage 下载版本的项目包并写入缓存，没有项目包或已缓存时跳过
+func fetchPackage(store *cache.Store, namespace, name string, versionInfo *client.AgentVersion) error {
+	if versionInfo.PackageDigest == nil || store.Has(*versionInfo.PackageDigest) {
+		return nil
+	}
+	resp, err := newTransferClient().GetPackageWithResponse(context.Background(), namespace, name, versionInfo.Version, nil, withCredentials)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		return err
+	}
+	_, err = store.Put(resp.Body, *versionInfo.PackageDigest)
+	return err
+}
+
+// installPulled 将版本安装到输出目录并输出结果
+func installPulled(store *cache.Store, ref *cache.Ref, spec []byte, cached bool) {
 	// 确定输出目录
 	outputDir := pullOutput
 	if outputDir == "" {
-		home, _ := os.UserHomeDir()
-		outputDir = filepath.Join(home, ".agenthub", "agents", namespace, name, versionInfo.Version)
+		outputDir = agentInstallDir(ref.Namespace, ref.Name, ref.Version)
 	}
 
-	// 创建目录
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		fmt.Printf("创建目录失败: %v\n", err)
+	var pkg []byte
+	if ref.Package != "" {
+		var err error
+		if pkg, err = store.Get(ref.Package); err != nil {
+			fmt.Printf("读取项目包失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := installVersion(spec, pkg, ref.Files, outputDir); err != nil {
+		fmt.Printf("安装失败: %v\n", err)
 		os.Exit(1)
 	}
+	if cached {
+		store.Touch(ref)
+		fmt.Printf("✓ 使用缓存 (%s)\n", shortDigest(ref.Digest))
+	} else {
+		fmt.Printf("✓ 下载完成！\n")
+	}
+	fmt.Printf("  位置: %s\n", outputDir)
+	fmt.Printf("  版本: %s\n", ref.Version)
+	fmt.Printf("\n使用 'agenthub run %s/%s' 运行智能体\n", ref.Namespace, ref.Name)
+}
 
-	// 保存 spec 文件
-	specPath := filepath.Join(outputDir, "agentspec.yaml")
-	if err := os.WriteFile(specPath, []byte(versionInfo.Spec), 0644); err != nil {
-		fmt.Printf("保存 spec 失败: %v\n", err)
-		os.Exit(1)
+// agentInstallDir 智能体版本的默认安装目录
+func agentInstallDir(namespace, name, version string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".agenthub", "agents", namespace, name, version)
+}
+
+// installVersion 先写入同级的临时目录再移动到目标位置，中断时不会留下不完整的安装
+// pkg 不为空时解压项目包并按 files 校验，否则只写入 agentspec.yaml
+// 目标目录不存在时整体重命名；已存在时 (例如 -o 指定的目录) 逐个文件合并：
+// 包中的文件覆盖同名文件，目录内原有的其他文件和子目录保持不变
+func installVersion(spec, pkg []byte, files map[string]string, destDir string) error {
+	parent := filepath.Dir(destDir)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
 	}
+	staging, err := os.MkdirTemp(parent, "."+filepath.Base(destDir)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
 
-	fmt.Printf("✓ 下载完成！\n")
-	fmt.Printf("  位置: %s\n", outputDir)
-	fmt.Printf("  版本: %s\n", versionInfo.Version)
-	fmt.Printf("\n使用 'agenthub run %s/%s' 运行智能体\n", namespace, name)
+	if pkg != nil {
+		if err := extractTarGz(bytes.NewReader(pkg), staging, files); err != nil {
+			return err
+		}
+	} else if err := os.WriteFile(filepath.Join(staging, "agentspec.yaml"), spec, 0644); err != nil {
+		return err
+	}
+	if err := os.Chmod(staging, 0755); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(destDir); os.IsNotExist(err) {
+		return os.Rename(staging, destDir)
+	}
+	return mergeInto(staging, destDir)
+}
+
+// mergeInto 将 src 中的文件逐个移动到 dst 的对应位置
+// 已存在的目录沿用，不会删除；目标位置类型不同 (文件与目录) 时报错，不覆盖用户的内容
+func mergeInto(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := os.Lstat(target)
+		exists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if d.IsDir() {
+			if !exists {
+				return os.Mkdir(target, 0755)
+			}
+			if !info.IsDir() {
+				return fmt.Errorf("%s 已存在且不是目录", target)
+			}
+			return nil
+		}
+		if exists && !info.Mode().IsRegular() {
+			return fmt.Errorf("%s 已存在且不是普通文件", target)
+		}
+		return os.Rename(p, target)
+	})
 }
 
 // parseAgentRef 解析智能体引用
@@ -124,14 +317,52 @@ func parseAgentRef(ref string) (namespace, name, version string) {
 	return
 }
 
-// extractTarGz 解压 tar.gz 文件
-func extractTarGz(reader io.Reader, destDir string) error {
+// resolvedRef 从服务端返回的 Location 头解析智能体的当前名称
+func resolvedRef(location string) (namespace, name string, ok bool) {
+	for _, prefix := range []string{"/api/v1/agents/", "/invoke/"} {
+		idx := strings.Index(location, prefix)
+		if idx == -1 {
+			continue
+		}
+		parts := strings.SplitN(location[idx+len(prefix):], "/", 3)
+		if len(parts) >= 2 && parts[0] != "" && parts[1] != "" {
+			return parts[0], parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+// warnMoved 提示用户更新已转移或重命名的智能体引用
+func warnMoved(oldNamespace, oldName, newNamespace, newName string) {
+	fmt.Printf("⚠️  %s/%s 已迁移到 %s/%s，请更新你的引用\n", oldNamespace, oldName, newNamespace, newName)
+}
+
+// 解压限制，防止恶意或损坏的包耗尽磁盘
+const (
+	maxExtractSize  = 100 << 20 // 解压后的总字节数
+	maxExtractFiles = 1000      // 文件和目录总数
+)
+
+// errUnsafeArchive 包中含有不安全或不符合预期的条目
+var errUnsafeArchive = errors.New("unsafe archive")
+
+// extractTarGz 解压 tar.gz 文件到 destDir，destDir 应为新建的临时目录
+//
+// 只接受普通文件和目录：路径必须是相对路径且不能包含 ..，拒绝符号链接、硬链接和设备文件，
+// 同一路径不能出现两次。目录权限统一为 0755，文件为 0644，原本可执行的文件为 0755。
+// digests 为服务端给出的各文件 (AgentFile) 路径到 SHA-256 摘要的映射，不为 nil 时
+// 包中的文件必须与之一一对应且内容一致。
+func extractTarGz(reader io.Reader, destDir string, digests map[string]string) error {
 	gzr, err := gzip.NewReader(reader)
 	if err != nil {
 		return err
 	}
 	defer gzr.Close()
 
+	var total int64
+	entries := 0
+	seen := make(map[string]bool)
+
 	tr := tar.NewReader(gzr)
 	for {
 		header, err := tr.Next()
@@ -142,7 +373,25 @@ func extractTarGz(reader io.Reader, destDir string) error {
 			return err
 		}
 
-		target := filepath.Join(destDir, header.Name)
+		// PAX 全局扩展头只包含元数据
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name, err := archivePath(header.Name)
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			continue
+		}
+
+		entries++
+		if entries > maxExtractFiles {
+			return fmt.Errorf("%w: more than %d entries", errUnsafeArchive, maxExtractFiles)
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(name))
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -150,22 +399,77 @@ func extractTarGz(reader io.Reader, destDir string) error {
 				return err
 			}
 		case tar.TypeReg:
-			dir := filepath.Dir(target)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return err
+			if seen[name] {
+				return fmt.Errorf("%w: duplicate entry %q", errUnsafeArchive, name)
 			}
+			seen[name] = true
 
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			if header.Size < 0 || total+header.Size > maxExtractSize {
+				return fmt.Errorf("%w: extracted size exceeds %d bytes", errUnsafeArchive, maxExtractSize)
+			}
+			total += header.Size
+
+			mode := os.FileMode(0644)
+			if header.Mode&0111 != 0 {
+				mode = 0755
+			}
+			digest, err := extractFile(tr, target, mode)
 			if err != nil {
 				return err
 			}
 
-			if _, err := io.Copy(f, tr); err != nil {
-				f.Close()
-				return err
+			if digests != nil {
+				expected, ok := digests[name]
+				if !ok {
+					return fmt.Errorf("%w: unexpected file %q", errUnsafeArchive, name)
+				}
+				if digest != expected {
+					return fmt.Errorf("%s: %w", name, &cache.IntegrityError{Expected: expected, Actual: digest})
+				}
 			}
-			f.Close()
+		default:
+			return fmt.Errorf("%w: %q is not a regular file or directory", errUnsafeArchive, name)
+		}
+	}
+
+	for name := range digests {
+		if !seen[name] {
+			return fmt.Errorf("%w: missing file %q", errUnsafeArchive, name)
 		}
 	}
 	return nil
 }
+
+// archivePath 校验并规范化包中的路径，结果使用 / 分隔
+func archivePath(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if cleaned == "." {
+		return cleaned, nil
+	}
+	if path.IsAbs(cleaned) || !filepath.IsLocal(filepath.FromSlash(cleaned)) {
+		return "", fmt.Errorf("%w: path %q escapes the target directory", errUnsafeArchive, name)
+	}
+	return cleaned, nil
+}
+
+// extractFile 写入单个文件并返回内容的 SHA-256 摘要，文件已存在时报错
+func extractFile(r io.Reader, target string, mode os.FileMode) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return "", err
+	}
+	// 不受 umask 影响
+	if err := f.Chmod(mode); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), f.Close()
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agenthub/cli/cache"
+)
+
+// testEntry 测试用的包内条目
+type testEntry struct {
+	name     string
+	body     string
+	typeflag byte
+	mode     int64
+	link     string
+}
+
+func buildTarGz(t *testing.T, entries ...testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: e.mode, Typeflag: e.typeflag, Linkname: e.link}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Mode == 0 {
+			header.Mode = 0o644
+		}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExtractTarGz(t *testing.T) {
+	dest := t.TempDir()
+	pkg := buildTarGz(t,
+		testEntry{name: "prompts/", typeflag: tar.TypeDir, mode: 0o777},
+		testEntry{name: "prompts/system.md", body: "hello", mode: 0o666},
+		testEntry{name: "./run.sh", body: "#!/bin/sh", mode: 0o4777},
+	)
+	digests := map[string]string{
+		"prompts/system.md": sha256Hex("hello"),
+		"run.sh":            sha256Hex("#!/bin/sh"),
+	}
+	if err := extractTarGz(bytes.NewReader(pkg), dest, digests); err != nil {
+		t.Fatal(err)
+	}
+
+	modes := map[string]os.FileMode{"prompts": 0o755, "prompts/system.md": 0o644, "run.sh": 0o755}
+	for name, want := range modes {
+		info, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %o, want %o", name, got, want)
+		}
+	}
+}
+
+func TestExtractTarGzRejectsUnsafeArchives(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+	}{
+		{"parent traversal", []testEntry{{name: "../evil", body: "x"}}},
+		{"nested traversal", []testEntry{{name: "a/../../evil", body: "x"}}},
+		{"absolute path", []testEntry{{name: "/tmp/evil", body: "x"}}},
+		{"backslash traversal", []testEntry{{name: "..\\evil", body: "x"}}},
+		{"symlink", []testEntry{{name: "link", typeflag: tar.TypeSymlink, link: "/etc/passwd"}}},
+		{"hardlink", []testEntry{{name: "link", typeflag: tar.TypeLink, link: "/etc/passwd"}}},
+		{"device", []testEntry{{name: "dev", typeflag: tar.TypeChar}}},
+		{"duplicate entry", []testEntry{{name: "a", body: "x"}, {name: "./a", body: "y"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "out")
+			if err := os.Mkdir(dest, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			err := extractTarGz(bytes.NewReader(buildTarGz(t, tt.entries...)), dest, nil)
+			if !errors.Is(err, errUnsafeArchive) {
+				t.Errorf("err = %v, want errUnsafeArchive", err)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil")); err == nil {
+				t.Error("file was written outside the target directory")
+			}
+		})
+	}
+}
+
+func TestExtractTarGzVerifiesDigests(t *testing.T) {
+	pkg := buildTarGz(t, testEntry{name: "a.txt", body: "tampered"})
+
+	err := extractTarGz(bytes.NewReader(pkg), t.TempDir(), map[string]string{"a.txt": sha256Hex("original")})
+	var integrity *cache.IntegrityError
+	if !errors.As(err, &integrity) {
+		t.Errorf("digest mismatch: err = %v", err)
+	}
+
+	err = extractTarGz(bytes.NewReader(pkg), t.TempDir(), map[string]string{"a.txt": sha256Hex("tampered"), "b.txt": sha256Hex("")})
+	if !errors.Is(err, errUnsafeArchive) {
+		t.Errorf("missing file: err = %v", err)
+	}
+
+	err = extractTarGz(bytes.NewReader(pkg), t.TempDir(), map[string]string{})
+	if !errors.Is(err, errUnsafeArchive) {
+		t.Errorf("unexpected file: err = %v", err)
+	}
+}
+
+func TestInstallVersionMergesIntoExistingDir(t *testing.T) {
+	dest := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		p := filepath.Join(dest, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("prompts/system.md", "old prompt")
+	write("prompts/notes.md", "my notes")
+	write(".git/HEAD", "ref: refs/heads/main")
+	write("main.go", "package main")
+
+	spec := "version: 1.0.0\n"
+	pkg := buildTarGz(t,
+		testEntry{name: "agentspec.yaml", body: spec},
+		testEntry{name: "prompts/system.md", body: "new prompt"},
+	)
+	files := map[string]string{"agentspec.yaml": sha256Hex(spec), "prompts/system.md": sha256Hex("new prompt")}
+	if err := installVersion([]byte(spec), pkg, files, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"agentspec.yaml":    spec,
+		"prompts/system.md": "new prompt",
+		"prompts/notes.md":  "my notes",
+		".git/HEAD":         "ref: refs/heads/main",
+		"main.go":           "package main",
+	}
+	for name, body := range want {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(data) != body {
+			t.Errorf("%s = %q, want %q", name, data, body)
+		}
+	}
+}
+
+func TestInstallVersionRefusesTypeConflicts(t *testing.T) {
+	dest := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dest, "agentspec.yaml", "keep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := installVersion([]byte("version: 1.0.0\n"), nil, nil, dest); err == nil {
+		t.Fatal("a directory was replaced by a file")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "agentspec.yaml", "keep")); err != nil {
+		t.Errorf("existing directory was removed: %v", err)
+	}
+}
+
+func TestInstallVersionNewDir(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "agents", "helper", "1.0.0")
+	if err := installVersion([]byte("version: 1.0.0\n"), nil, nil, dest); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "agentspec.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("mode = %o", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(dest))
+	if len(entries) != 1 {
+		t.Errorf("staging directory left behind: %d entries", len(entries))
+	}
+}
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/agenthub/cli/agentspec"
+	"github.com/agenthub/cli/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -16,6 +19,8 @@ import (
 var (
 	pushVersion   string
 	pushChangelog string
+	pushNamespace string
+	pushDryRun    bool
 )
 
 var pushCmd = &cobra.Command{
@@ -23,13 +28,24 @@ var pushCmd = &cobra.Command{
 	Short: "发布智能体",
 	Long: `将智能体发布到 AgentHub。
 
-会读取目录中的 agentspec.yaml 文件并发布。
+会读取目录中的 agentspec.yaml 文件，将整个项目目录打包后一起发布。
+
+打包时文件按路径排序，修改时间和权限固定，相同的内容总是得到相同的摘要。
+.gitignore 和 .agenthubignore 中的路径不会打包，.git 等版本控制目录总是跳过。
+发现较大的文件或 .env 等疑似包含密钥的文件时会给出提示。
+压缩后的项目包不能超过 50 MB。
+
+--dry-run 只打包和校验，不发布：先按 agenthub validate 校验 agentspec.yaml，
+再列出将要上传的文件和包摘要，并请服务端执行发布前的全部检查
+(命名空间权限、版本号冲突、spec 和项目包格式)；智能体尚未创建时同样由服务端校验，不会创建智能体。
 
 示例:
   agenthub push                        # 发布当前目录
   agenthub push ./my-agent             # 发布指定目录
   agenthub push -v 1.0.0               # 指定版本号
-  agenthub push -m "修复了一些问题"      # 添加更新日志`,
+  agenthub push -m "修复了一些问题"      # 添加更新日志
+  agenthub push -n my-org              # 发布到组织或受邀协作的命名空间
+  agenthub push --dry-run              # 只校验，不发布`,
 	Run: runPush,
 }
 
@@ -37,135 +53,261 @@ func init() {
 	rootCmd.AddCommand(pushCmd)
 	pushCmd.Flags().StringVarP(&pushVersion, "version", "v", "", "版本号 (覆盖 spec 中的版本)")
 	pushCmd.Flags().StringVarP(&pushChangelog, "message", "m", "", "更新日志")
+	pushCmd.Flags().StringVarP(&pushNamespace, "namespace", "n", "", "目标命名空间 (默认为当前用户)")
+	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "只打包和校验，不发布")
 }
 
-func runPush(cmd *cobra.Command, args []string) {
-	// 检查登录状态
-	token := viper.GetString("token")
-	if token == "" {
-		fmt.Println("请先登录: agenthub login")
-		os.Exit(1)
-	}
+// projectSpec push 和 pack 使用的 agentspec.yaml 字段
+type projectSpec struct {
+	Version  string `yaml:"version"`
+	Metadata struct {
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description"`
+		Author      string   `yaml:"author"`
+		Category    string   `yaml:"category"`
+		Tags        []string `yaml:"tags"`
+		License     string   `yaml:"license"`
+	} `yaml:"metadata"`
+}
 
-	// 确定路径
-	path := "."
-	if len(args) > 0 {
-		path = args[0]
+// publishVersion 发布的版本号，依次使用 override、spec 中的 version，默认 0.1.0
+func (s *projectSpec) publishVersion(override string) string {
+	switch {
+	case override != "":
+		return override
+	case s.Version != "":
+		return s.Version
 	}
+	return "0.1.0"
+}
 
-	// 读取 agentspec.yaml
-	specPath := filepath.Join(path, "agentspec.yaml")
-	specData, err := os.ReadFile(specPath)
+// loadProject 读取并解析项目目录中的 agentspec.yaml，失败时退出
+func loadProject(path string) ([]byte, *projectSpec) {
+	specData, err := os.ReadFile(filepath.Join(path, "agentspec.yaml"))
 	if err != nil {
 		fmt.Printf("读取 agentspec.yaml 失败: %v\n", err)
 		fmt.Println("确保当前目录包含 agentspec.yaml 文件")
 		os.Exit(1)
 	}
 
-	// 解析 spec
-	var spec struct {
-		Version  string `yaml:"version"`
-		Metadata struct {
-			Name        string `yaml:"name"`
-			Description string `yaml:"description"`
-			Author      string `yaml:"author"`
-			Category    string `yaml:"category"`
-			Tags        []string `yaml:"tags"`
-			License     string `yaml:"license"`
-		} `yaml:"metadata"`
+	spec := &projectSpec{}
+	if err := yaml.Unmarshal(specData, spec); err != nil {
+		fmt.Printf("解析 agentspec.yaml 失败: %v\n", err)
+		os.Exit(1)
 	}
+	if spec.Metadata.Name == "" {
+		fmt.Println("agentspec.yaml 缺少 metadata.name")
+		os.Exit(1)
+	}
+	return specData, spec
+}
 
-	if err := yaml.Unmarshal(specData, &spec); err != nil {
-		fmt.Printf("解析 agentspec.yaml 失败: %v\n", err)
+// checkProject 本地校验 agentspec.yaml
+// dry run 时输出全部问题，有错误时退出；正常发布时只提示问题数，由服务端决定能否发布
+func checkProject(path string) {
+	report, err := agentspec.Validate(path)
+	if err != nil {
+		fmt.Printf("校验 agentspec.yaml 失败: %v\n", err)
 		os.Exit(1)
 	}
+	if !pushDryRun {
+		if n := len(report.Issues); n > 0 {
+			fmt.Printf("⚠️  agentspec.yaml 有 %d 个问题，运行 'agenthub validate' 查看\n", n)
+		}
+		return
+	}
+	if len(report.Issues) == 0 {
+		return
+	}
+	printReport(report)
+	if report.Errors() > 0 {
+		os.Exit(1)
+	}
+	fmt.Println()
+}
+
+// packProject 打包项目目录并输出提示，失败时退出
+// 项目根目录下 pack 生成的 <name>-*.tar.gz 不会打包
+func packProject(path string, spec *projectSpec) *projectPackage {
+	pkg, err := buildProjectPackage(path, "/"+spec.Metadata.Name+"-*.tar.gz")
+	if pkg != nil {
+		for _, warning := range pkg.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+	}
+	if err != nil {
+		fmt.Printf("打包失败: %v\n", err)
+		os.Exit(1)
+	}
+	return pkg
+}
 
-	// 确定版本号
-	version := pushVersion
-	if version == "" {
-		version = spec.Version
+// printPackage 列出包中的文件和大小以及包摘要
+func printPackage(pkg *projectPackage) {
+	var total int64
+	for _, f := range pkg.Files {
+		fmt.Printf("  %10s  %s\n", formatSize(f.Size), f.Path)
+		total += f.Size
 	}
-	if version == "" {
-		version = "0.1.0"
+	fmt.Printf("\n  %d 个文件，共 %s，压缩后 %s\n", len(pkg.Files), formatSize(total), formatSize(int64(len(pkg.Data))))
+	fmt.Printf("  摘要: sha256:%s\n", pkg.Digest)
+}
+
+func runPush(cmd *cobra.Command, args []string) {
+	// 检查登录状态
+	token := viper.GetString("token")
+	if token == "" {
+		fmt.Println("请先登录: agenthub login")
+		os.Exit(1)
 	}
 
+	// 确定路径
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	// 读取并校验 agentspec.yaml，然后打包项目目录
+	specData, spec := loadProject(path)
+	checkProject(path)
+	pkg := packProject(path, spec)
+	version := spec.publishVersion(pushVersion)
+
 	username := viper.GetString("username")
+	if pushNamespace != "" {
+		username = pushNamespace
+	}
 	agentName := spec.Metadata.Name
 
-	fmt.Printf("📤 正在发布 %s/%s@%s ...\n", username, agentName, version)
+	if pushDryRun {
+		fmt.Printf("🔍 校验 %s/%s@%s (dry run)\n\n", username, agentName, version)
+		printPackage(pkg)
+		fmt.Println()
+	} else {
+		fmt.Printf("📤 正在发布 %s/%s@%s ...\n", username, agentName, version)
+	}
 
-	apiURL := viper.GetString("api_url")
+	api := newAPIClient()
+	ctx := context.Background()
 
 	// 1. 先检查或创建智能体
-	checkURL := fmt.Sprintf("%s/api/v1/agents/%s/%s", apiURL, username, agentName)
-	checkResp, err := http.Get(checkURL)
+	checkResp, err := api.GetAgentWithResponse(ctx, username, agentName, nil, withToken)
 	if err != nil {
 		fmt.Printf("检查智能体失败: %v\n", err)
 		os.Exit(1)
 	}
 
-	if checkResp.StatusCode == http.StatusNotFound {
+	if checkResp.JSON200 != nil {
+		// 旧名称会重定向到转移或重命名后的智能体
+		if resolved := checkResp.JSON200.ResolvedName; resolved != nil {
+			if parts := strings.SplitN(*resolved, "/", 2); len(parts) == 2 {
+				warnMoved(username, agentName, parts[0], parts[1])
+				fmt.Println("请更新 agentspec.yaml 中的名称或使用 --namespace 指定新的命名空间后重试")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if checkResp.StatusCode() != http.StatusNotFound {
+		if err := checkResponse(checkResp.HTTPResponse, checkResp.Body, http.StatusOK); err != nil {
+			fmt.Printf("检查智能体失败: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		// 只能在自己的命名空间下创建新智能体
+		if username != viper.GetString("username") {
+			fmt.Printf("智能体 %s/%s 不存在或无权访问\n", username, agentName)
+			os.Exit(1)
+		}
+
+		// 智能体还不存在时，dry run 由服务端按将要创建的智能体校验
+		if pushDryRun {
+			fmt.Printf("  发布时将创建新智能体 %s/%s\n", username, agentName)
+			checkPublish(ctx, username, agentName, version, specData, pkg)
+			return
+		}
+
 		// 创建智能体
 		fmt.Println("  创建智能体...")
-		createBody, _ := json.Marshal(map[string]interface{}{
-			"name":        agentName,
-			"description": spec.Metadata.Description,
-			"category":    spec.Metadata.Category,
-			"tags":        spec.Metadata.Tags,
-			"license":     spec.Metadata.License,
-			"visibility":  "public",
-		})
-
-		req, _ := http.NewRequest("POST", apiURL+"/api/v1/agents", bytes.NewBuffer(createBody))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+token)
-
-		client := &http.Client{}
-		createResp, err := client.Do(req)
+		visibility := client.CreateAgentRequestVisibilityPublic
+		createResp, err := api.CreateAgentWithResponse(ctx, client.CreateAgentRequest{
+			Name:        agentName,
+			Description: spec.Metadata.Description,
+			Category:    &spec.Metadata.Category,
+			Tags:        &spec.Metadata.Tags,
+			License:     &spec.Metadata.License,
+			Visibility:  &visibility,
+		}, withToken)
 		if err != nil {
 			fmt.Printf("创建智能体失败: %v\n", err)
 			os.Exit(1)
 		}
 
-		if createResp.StatusCode != http.StatusCreated {
-			fmt.Printf("创建智能体失败: HTTP %d\n", createResp.StatusCode)
+		if err := checkResponse(createResp.HTTPResponse, createResp.Body, http.StatusCreated); err != nil {
+			fmt.Printf("创建智能体失败: %v\n", err)
 			os.Exit(1)
 		}
 	}
-	checkResp.Body.Close()
-
-	// 2. 发布版本
-	fmt.Println("  发布版本...")
-	publishBody, _ := json.Marshal(map[string]interface{}{
-		"version":   version,
-		"spec":      string(specData),
-		"changelog": pushChangelog,
-	})
-
-	publishURL := fmt.Sprintf("%s/api/v1/agents/%s/%s/versions", apiURL, username, agentName)
-	req, _ := http.NewRequest("POST", publishURL, bytes.NewBuffer(publishBody))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
 
-	client := &http.Client{}
-	publishResp, err := client.Do(req)
+	// 2. 上传项目包并发布版本
+	if pushDryRun {
+		checkPublish(ctx, username, agentName, version, specData, pkg)
+		return
+	}
+	fmt.Printf("  上传 %d 个文件 (%s)...\n", len(pkg.Files), formatSize(int64(len(pkg.Data))))
+	contentType, body, err := publishForm(version, string(specData), pushChangelog, pkg)
+	if err != nil {
+		fmt.Printf("发布版本失败: %v\n", err)
+		os.Exit(1)
+	}
+	progress := newProgressReader(bytes.NewReader(body), int64(len(body)))
+	publishResp, err := newTransferClient().PublishVersionWithBodyWithResponse(ctx, username, agentName, nil, contentType, progress, withToken)
 	if err != nil {
 		fmt.Printf("发布版本失败: %v\n", err)
 		os.Exit(1)
 	}
-	defer publishResp.Body.Close()
 
-	if publishResp.StatusCode != http.StatusCreated {
-		var errResp struct {
-			Error string `json:"error"`
-		}
-		json.NewDecoder(publishResp.Body).Decode(&errResp)
-		fmt.Printf("发布失败: %s\n", errResp.Error)
+	if err := checkResponse(publishResp.HTTPResponse, publishResp.Body, http.StatusCreated); err != nil {
+		fmt.Printf("发布失败: %v\n", err)
 		os.Exit(1)
 	}
 
+	// 服务端记录的摘要应与本地打包的一致
+	if published := publishResp.JSON201; published != nil {
+		switch {
+		case published.PackageDigest == nil:
+			fmt.Println("⚠️  服务端未保存项目包，只发布了 agentspec.yaml")
+		case *published.PackageDigest != pkg.Digest:
+			fmt.Printf("服务端收到的项目包摘要 %s 与本地 %s 不一致\n", *published.PackageDigest, pkg.Digest)
+			os.Exit(1)
+		}
+	}
+
 	fmt.Println()
 	fmt.Printf("✓ 发布成功！\n")
 	fmt.Printf("  %s/%s@%s\n", username, agentName, version)
+	fmt.Printf("  包摘要: sha256:%s\n", pkg.Digest)
 	fmt.Printf("\n查看: https://agenthub.dev/%s/%s\n", username, agentName)
 }
+
+// checkPublish 请服务端以 dry_run 方式执行发布前的校验，不通过时退出
+func checkPublish(ctx context.Context, namespace, name, version string, specData []byte, pkg *projectPackage) {
+	contentType, body, err := publishForm(version, string(specData), pushChangelog, pkg)
+	if err != nil {
+		fmt.Printf("校验失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	dryRun := true
+	resp, err := newTransferClient().PublishVersionWithBodyWithResponse(ctx, namespace, name,
+		&client.PublishVersionParams{DryRun: &dryRun}, contentType, bytes.NewReader(body), withToken)
+	if err != nil {
+		fmt.Printf("校验失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		fmt.Printf("✗ 服务端校验未通过: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ 服务端校验通过，可以发布 %s/%s@%s\n", namespace, name, version)
+}
@@ -30,6 +30,7 @@ var rootCmd = &cobra.Command{
   agenthub search "code review"     搜索智能体
   agenthub pull user/agent          下载智能体
   agenthub run user/agent           运行智能体
+  agenthub validate                 校验 agentspec.yaml
   agenthub push                     发布智能体
   agenthub login                    登录账户`,
 }
@@ -2,8 +2,7 @@ package cmd
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,15 +10,19 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/agenthub/cli/client"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
 var (
 	runLocal   bool
+	runOffline bool
 	runVersion string
 	runInput   string
+
+	// movedWarned 交互模式下只提示一次迁移警告
+	movedWarned bool
 )
 
 var runCmd = &cobra.Command{
@@ -30,6 +33,7 @@ var runCmd = &cobra.Command{
 示例:
   agenthub run agenthub/simple-assistant     # 交互模式
   agenthub run user/my-agent --local         # 运行本地智能体
+  agenthub run user/my-agent --offline       # 从本地缓存运行，不访问网络
   agenthub run user/my-agent -i "你好"        # 单次输入
   agenthub run user/my-agent@1.0.0           # 指定版本`,
 	Args: cobra.ExactArgs(1),
@@ -39,15 +43,13 @@ var runCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().BoolVarP(&runLocal, "local", "l", false, "运行本地智能体")
+	runCmd.Flags().BoolVar(&runOffline, "offline", false, "从本地缓存运行，不访问网络")
 	runCmd.Flags().StringVarP(&runVersion, "version", "v", "latest", "指定版本")
 	runCmd.Flags().StringVarP(&runInput, "input", "i", "", "直接输入 (非交互模式)")
 }
 
 func runAgent(cmd *cobra.Command, args []string) {
 	agentRef := args[0]
-	apiURL := viper.GetString("api_url")
-	apiKey := viper.GetString("api_key")
-	token := viper.GetString("token")
 
 	namespace, name, version := parseAgentRef(agentRef)
 	if version == "" {
@@ -59,13 +61,29 @@ func runAgent(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// 离线时只能在本地运行缓存中的版本
+	if runOffline {
+		store := openCache()
+		ref, spec, err := resolveCached(store, namespace, name, version)
+		if err != nil {
+			fmt.Printf("离线模式下无法获取 %s/%s@%s: %v\n", namespace, name, version, err)
+			fmt.Println("联网时先运行 'agenthub pull' 下载智能体")
+			os.Exit(1)
+		}
+		store.Touch(ref)
+		runLocalSpec(namespace, name, ref.Version, spec)
+		return
+	}
+
 	fmt.Printf("🤖 启动 %s/%s@%s\n", namespace, name, version)
 	fmt.Println("(输入 /exit 退出, /help 查看帮助)")
 	fmt.Println()
 
+	api := newAPIClient()
+
 	// 如果有直接输入，单次运行
 	if runInput != "" {
-		response := invokeAgent(apiURL, apiKey, token, namespace, name, runInput)
+		response := invokeAgent(api, namespace, name, runInput)
 		fmt.Println(response)
 		return
 	}
@@ -107,45 +125,31 @@ func runAgent(cmd *cobra.Command, args []string) {
 
 		// 调用智能体
 		fmt.Print("Agent: ")
-		response := invokeAgent(apiURL, apiKey, token, namespace, name, input)
+		response := invokeAgent(api, namespace, name, input)
 		fmt.Println(response)
 		fmt.Println()
 	}
 }
 
-func invokeAgent(apiURL, apiKey, token, namespace, name, input string) string {
-	url := fmt.Sprintf("%s/invoke/%s/%s", apiURL, namespace, name)
-
-	body, _ := json.Marshal(map[string]interface{}{
+func invokeAgent(api *client.ClientWithResponses, namespace, name, input string) string {
+	resp, err := api.InvokeAgentWithResponse(context.Background(), namespace, name, client.InvokeRequest{
 		"message": input,
-	})
-
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-
-	if apiKey != "" {
-		req.Header.Set("X-API-Key", apiKey)
-	} else if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	}, withCredentials)
 	if err != nil {
 		return fmt.Sprintf("调用失败: %v", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Sprintf("调用失败: HTTP %d", resp.StatusCode)
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		return fmt.Sprintf("调用失败: %v", err)
 	}
 
-	var result struct {
-		Output string `json:"output"`
+	if newNamespace, newName, ok := resolvedRef(resp.HTTPResponse.Header.Get("Location")); ok && !movedWarned {
+		warnMoved(namespace, name, newNamespace, newName)
+		movedWarned = true
 	}
-	json.NewDecoder(resp.Body).Decode(&result)
 
-	return result.Output
+	// 输出结构由智能体定义，文本输出直接显示
+	output, _ := resp.JSON200.Output.(string)
+	return output
 }
 
 func runLocalAgent(namespace, name string) {
@@ -175,6 +179,11 @@ func runLocalAgent(namespace, name string) {
 		os.Exit(1)
 	}
 
+	runLocalSpec(namespace, name, latestVersion, specData)
+}
+
+// runLocalSpec 在本地运行智能体
+func runLocalSpec(namespace, name, version string, specData []byte) {
 	var spec struct {
 		Runtime struct {
 			Type string `yaml:"type"`
@@ -189,7 +198,7 @@ func runLocalAgent(namespace, name string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("🤖 本地运行 %s/%s@%s\n", namespace, name, latestVersion)
+	fmt.Printf("🤖 本地运行 %s/%s@%s\n", namespace, name, version)
 	fmt.Printf("类型: %s\n", spec.Runtime.Type)
 	fmt.Println()
 
@@ -210,6 +219,5 @@ func printHelp() {
 可用命令:
   /exit, /quit, /q  退出
   /help, /h         显示帮助
-  /clear            清屏
-`)
+  /clear            清屏`)
 }
@@ -1,15 +1,14 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
 	"strings"
 
+	"github.com/agenthub/cli/client"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var (
@@ -36,61 +35,38 @@ func init() {
 	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 10, "结果数量限制")
 }
 
-type SearchResult struct {
-	Agents []AgentInfo `json:"agents"`
-	Total  int64       `json:"total"`
-}
-
-type AgentInfo struct {
-	FullName    string   `json:"full_name"`
-	Name        string   `json:"name"`
-	Namespace   string   `json:"namespace"`
-	Description string   `json:"description"`
-	Category    string   `json:"category"`
-	Tags        []string `json:"tags"`
-	Downloads   int64    `json:"downloads"`
-	Likes       int64    `json:"likes"`
-}
-
 func runSearch(cmd *cobra.Command, args []string) {
 	query := strings.Join(args, " ")
-	apiURL := viper.GetString("api_url")
-
-	// 构建请求 URL
-	reqURL := fmt.Sprintf("%s/api/v1/search?q=%s&page_size=%d",
-		apiURL, url.QueryEscape(query), searchLimit)
 
+	params := &client.SearchParams{Q: query, Limit: &searchLimit}
 	if searchCategory != "" {
-		reqURL += "&category=" + url.QueryEscape(searchCategory)
+		params.Category = &searchCategory
 	}
 
-	resp, err := http.Get(reqURL)
+	resp, err := newAPIClient().SearchWithResponse(context.Background(), params)
 	if err != nil {
 		fmt.Printf("搜索失败: %v\n", err)
 		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	var result SearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Printf("解析响应失败: %v\n", err)
+	if err := checkResponse(resp.HTTPResponse, resp.Body, http.StatusOK); err != nil {
+		fmt.Printf("搜索失败: %v\n", err)
 		os.Exit(1)
 	}
+	result := resp.JSON200
 
-	if len(result.Agents) == 0 {
+	if len(result.Items) == 0 {
 		fmt.Printf("未找到匹配 \"%s\" 的智能体\n", query)
 		return
 	}
 
-	fmt.Printf("找到 %d 个智能体:\n\n", result.Total)
-
-	for _, agent := range result.Agents {
-		fullName := agent.Namespace + "/" + agent.Name
-		if agent.FullName != "" {
-			fullName = agent.FullName
-		}
+	if result.HasMore {
+		fmt.Printf("前 %d 个匹配的智能体:\n\n", len(result.Items))
+	} else {
+		fmt.Printf("找到 %d 个智能体:\n\n", len(result.Items))
+	}
 
-		fmt.Printf("📦 %s\n", fullName)
+	for _, agent := range result.Items {
+		fmt.Printf("📦 %s\n", agentFullName(agent))
 		if agent.Description != "" {
 			desc := agent.Description
 			if len(desc) > 80 {
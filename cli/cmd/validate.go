@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/agenthub/cli/agentspec"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateFormat string
+	validateStrict bool
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "校验 agentspec.yaml",
+	Long: `按内置的 agentspec.schema.json 校验项目目录中的 agentspec.yaml。
+
+除字段格式外还会检查：
+  - runtime.entry、prompts.system_file、依赖文件等引用的文件是否存在
+  - 工具参数和接口定义中的 JSON Schema 是否合法
+  - 工作流步骤引用的智能体和步骤是否存在，depends_on 是否有环
+  - 规范建议：缺少 license、简介过长、重复的标签等
+
+有错误时退出码为 1，使用 --strict 时警告也视为失败。
+
+示例:
+  agenthub validate                    # 校验当前目录
+  agenthub validate ./my-agent         # 校验指定目录
+  agenthub validate -f sarif > agentspec.sarif   # 输出 SARIF，供 CI 代码扫描使用
+  agenthub validate -f json --strict   # JSON 输出，警告也视为失败`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringVarP(&validateFormat, "format", "f", "text", "输出格式: text、json 或 sarif")
+	validateCmd.Flags().BoolVar(&validateStrict, "strict", false, "警告也视为失败")
+}
+
+// validateResult JSON 输出
+type validateResult struct {
+	*agentspec.Report
+	Valid    bool `json:"valid"`
+	Errors   int  `json:"errors"`
+	Warnings int  `json:"warnings"`
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	switch validateFormat {
+	case "text", "json", "sarif":
+	default:
+		fmt.Printf("不支持的输出格式: %s (可选 text、json、sarif)\n", validateFormat)
+		os.Exit(1)
+	}
+
+	report, err := agentspec.Validate(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取 agentspec.yaml 失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := report.Errors() > 0 || (validateStrict && report.Warnings() > 0)
+
+	switch validateFormat {
+	case "json":
+		data, err := json.MarshalIndent(validateResult{
+			Report:   report,
+			Valid:    !failed,
+			Errors:   report.Errors(),
+			Warnings: report.Warnings(),
+		}, "", "  ")
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	case "sarif":
+		data, err := agentspec.SARIF([]*agentspec.Report{report}, Version)
+		cobra.CheckErr(err)
+		fmt.Println(string(data))
+	default:
+		printReport(report)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printReport 以 文件:行:列 的格式输出问题和汇总
+func printReport(report *agentspec.Report) {
+	for _, issue := range report.Issues {
+		location := report.File
+		switch {
+		case issue.Line > 0 && issue.Column > 0:
+			location = fmt.Sprintf("%s:%d:%d", report.File, issue.Line, issue.Column)
+		case issue.Line > 0:
+			location = fmt.Sprintf("%s:%d", report.File, issue.Line)
+		}
+		message := issue.Message
+		if issue.Path != "" {
+			message = issue.Path + ": " + message
+		}
+		fmt.Printf("%s: %s [%s] %s\n", location, issue.Severity, issue.Rule, message)
+	}
+
+	errors, warnings := report.Errors(), report.Warnings()
+	if len(report.Issues) > 0 {
+		fmt.Println()
+	}
+	switch {
+	case errors > 0:
+		fmt.Printf("✗ %d 个错误，%d 个警告\n", errors, warnings)
+	case warnings > 0:
+		fmt.Printf("✓ 校验通过，%d 个警告\n", warnings)
+	default:
+		fmt.Printf("✓ %s 校验通过\n", report.File)
+	}
+}
@@ -0,0 +1,54 @@
+// openapi-check 比对路由、处理器的请求响应结构与 internal/api/openapi.yaml，不一致时以非零状态退出
+// 同样的比对由 internal/api 的 TestOpenAPIContract 在 go test 中执行，这里便于单独查看结果
+//
+//	go run ./cmd/openapi-check          # 比对
+//	go run ./cmd/openapi-check -dump    # 输出 JSON 文档，与 /api/v1/openapi.json 相同
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/agenthub/server/internal/api"
+	"github.com/agenthub/server/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	dump := flag.Bool("dump", false, "输出 JSON 格式的接口文档")
+	flag.Parse()
+	log.SetFlags(0)
+
+	if *dump {
+		data, err := api.OpenAPISpec()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+
+	// 只需要路由表，不连接任何依赖
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+	cfg, err := config.Load(nil)
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	routes := api.NewRouter(cfg, nil, nil, nil).Routes()
+
+	problems, err := api.VerifyOpenAPI(routes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if len(problems) > 0 {
+		log.Fatalf("openapi.yaml is out of date: %d problem(s)", len(problems))
+	}
+	fmt.Printf("openapi.yaml matches %d routes\n", len(routes))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/agenthub/server/internal/config"
+)
+
+const configUsage = `用法: agenthub-server config <command>
+
+命令:
+  print   输出合并了配置文件、环境变量和命令行参数后的最终配置，敏感字段已隐藏
+`
+
+// runConfig 执行 config 子命令，返回进程退出码
+func runConfig(cfg *config.Config, args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(out)
+	return 0
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,55 +14,168 @@ import (
 
 	"github.com/agenthub/server/internal/api"
 	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/health"
+	"github.com/agenthub/server/internal/logging"
+	"github.com/agenthub/server/internal/metrics"
 	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/storage/memory"
+	"github.com/agenthub/server/internal/tracing"
+	"github.com/agenthub/server/internal/version"
+	"github.com/agenthub/server/internal/webhook"
 )
 
 func main() {
-	// 加载配置
-	cfg, err := config.Load()
+	dev := flag.Bool("dev", false, "开发模式：使用进程内存储，无需 PostgreSQL 和 Redis")
+	var flags config.Flags
+	flags.Register(flag.CommandLine)
+	flag.Parse()
+
+	// 加载配置：配置文件 → 环境变量 → 命令行参数
+	cfg, err := config.Load(&flags)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// 初始化存储
-	store, err := storage.New(cfg)
+	// 结构化日志，标准库 log 的输出也会经过该 logger
+	slog.SetDefault(logging.New(cfg.Log))
+
+	// 子命令
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			os.Exit(runMigrate(cfg, args[1:]))
+		case "config":
+			os.Exit(runConfig(cfg, args[1:]))
+		default:
+			fatal("unknown command", fmt.Errorf("%q", args[0]))
+		}
+	}
+
+	// 链路追踪
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
+		fatal("failed to initialize tracing", err)
+	}
+
+	// 初始化存储，并注册 readiness 依赖检查
+	checker := health.NewChecker(2 * time.Second)
+	var store storage.Store
+	if *dev {
+		slog.Warn("running in dev mode with in-memory storage, data will be lost on exit")
+		cfg.Server.Mode = "debug"
+		cfg.Server.DrainDelay = 0
+		store = memory.New()
+	} else {
+		pg, err := storage.New(cfg)
+		if err != nil {
+			fatal("failed to initialize storage", err)
+		}
+		// debug 模式下自动迁移，生产环境需显式执行 migrate up
+		if cfg.Server.Mode == "debug" {
+			if err := autoMigrate(pg.DB()); err != nil {
+				fatal("failed to run migrations", err)
+			}
+		}
+		metrics.RegisterDB(pg.DB(), "agenthub")
+		checker.Add("postgres", pg.DB().PingContext)
+		checker.Add("redis", func(ctx context.Context) error {
+			return pg.Redis().Ping(ctx).Err()
+		})
+		store = pg
 	}
 	defer store.Close()
 
+	if cfg.Storage.Type == "local" {
+		if err := os.MkdirAll(cfg.Storage.LocalPath, 0o755); err != nil {
+			fatal("failed to create storage directory", err)
+		}
+		checker.Add("blob_store", health.DirWritable(cfg.Storage.LocalPath))
+	}
+
+	// 启动 Webhook 投递 worker
+	hooks := webhook.NewDispatcher(store)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		hooks.Run(workerCtx)
+		close(workersDone)
+	}()
+
 	// 创建路由
-	router := api.NewRouter(cfg, store)
+	router := api.NewRouter(cfg, store, hooks, checker)
 
 	// 创建服务器
 	srv := &http.Server{
 		Addr:         cfg.Server.Address,
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: 15 * time.Second, // 默认值，带处理时限的路由由 BudgetMiddleware 按时限重新设置
 		IdleTimeout:  60 * time.Second,
 	}
 
 	// 启动服务器
 	go func() {
-		log.Printf("AgentHub server starting on %s", cfg.Server.Address)
+		slog.Info("AgentHub server starting",
+			"address", cfg.Server.Address,
+			"environment", cfg.Server.Environment,
+			"version", version.Version,
+			"commit", version.Commit,
+		)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+			fatal("server error", err)
 		}
 	}()
 
+	// 指标服务器使用独立端口，不经过业务路由和中间件
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled {
+		metricsSrv = metrics.NewServer(cfg.Metrics.Port)
+		go func() {
+			slog.Info("metrics server starting", "address", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("metrics server error", "error", err)
+			}
+		}()
+	}
+
 	// 优雅关闭
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	// 先让 readiness 失败，等待负载均衡摘除实例后再停止接收新连接
+	checker.Drain()
+	slog.Info("draining before shutdown", "delay", cfg.Server.DrainDelay)
+	time.Sleep(cfg.Server.DrainDelay)
+
+	slog.Info("shutting down server")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("server forced to shutdown", err)
+	}
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(ctx)
 	}
 
-	log.Println("Server exited")
+	// 等待进行中的投递结束，未处理的任务保留在 Redis 队列中
+	stopWorkers()
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+	}
+
+	// 导出剩余的 span
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+
+	slog.Info("server exited")
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
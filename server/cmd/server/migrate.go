@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/migrate"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/migrations"
+)
+
+const migrateUsage = `用法: agenthub-server migrate <command>
+
+命令:
+  up            应用全部未执行的迁移
+  down [N]      回滚最近 N 个迁移 (默认 1)
+  status        查看迁移状态
+  force VERSION 将 VERSION 及之前的迁移标记为已应用但不执行，用于接管已有数据库
+`
+
+// runMigrate 执行 migrate 子命令，返回进程退出码
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	db, err := storage.OpenDB(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintf(os.Stderr, "Error: invalid step count %q\n", args[1])
+				return 2
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No migrations to revert")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				state += " (script missing)"
+			}
+			fmt.Printf("%03d  %-24s %s\n", s.Version, s.Name, state)
+		}
+	case "force":
+		if len(args) < 2 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid version %q\n", args[1])
+			return 2
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
+
+// autoMigrate 开发模式下启动时自动应用迁移
+func autoMigrate(db *sql.DB) error {
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	_, err = migrator.Up(ctx)
+	return err
+}
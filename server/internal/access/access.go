@@ -0,0 +1,68 @@
+// Package access 智能体和命名空间的权限判断，API 处理器和 Webhook 分发器共用同一套规则
+package access
+
+import (
+	"context"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// Store 权限判断依赖的存储
+type Store interface {
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	GetOrgRole(ctx context.Context, orgName, userID string) (string, error)
+	GetCollaborator(ctx context.Context, agentID, userID string) (*models.AgentCollaborator, error)
+}
+
+// Subject 被判断权限的用户，Username 为空时按 UserID 查询
+type Subject struct {
+	UserID   string
+	Username string
+}
+
+// NamespacePermission 返回用户在命名空间下智能体上的权限
+// 个人命名空间的所有者为 admin，组织成员按角色取权限，其他用户返回空字符串
+func NamespacePermission(ctx context.Context, store Store, subject Subject, namespace string) string {
+	if subject.UserID == "" {
+		return ""
+	}
+	if subject.Username != "" && subject.Username == namespace {
+		return models.PermissionAdmin
+	}
+	if user, err := store.GetUserByID(ctx, subject.UserID); err == nil && user.Username == namespace {
+		return models.PermissionAdmin
+	}
+	role, err := store.GetOrgRole(ctx, namespace, subject.UserID)
+	if err != nil {
+		return ""
+	}
+	return models.OrgRolePermission(role)
+}
+
+// AgentPermission 返回用户在智能体上的权限
+// 命名空间所有者和组织 owner/admin 拥有 admin 权限，组织普通成员拥有 publish 权限，
+// 协作授权更高时取协作授权，无权限时返回空字符串
+// 权限只取决于智能体当前所在的命名空间，作者本身不带权限，转移后原作者不再保留管理权限
+func AgentPermission(ctx context.Context, store Store, subject Subject, agent *models.Agent) string {
+	if subject.UserID == "" {
+		return ""
+	}
+	permission := NamespacePermission(ctx, store, subject, agent.Namespace)
+	if permission == models.PermissionAdmin {
+		return permission
+	}
+	collab, err := store.GetCollaborator(ctx, agent.ID, subject.UserID)
+	if err == nil && collab.Status == models.CollaboratorAccepted &&
+		models.PermissionLevel(collab.Permission) > models.PermissionLevel(permission) {
+		return collab.Permission
+	}
+	return permission
+}
+
+// CanRead 用户是否可以读取智能体，公开和不公开 (unlisted) 的智能体所有人可读
+func CanRead(ctx context.Context, store Store, subject Subject, agent *models.Agent) bool {
+	if agent.Visibility != models.VisibilityPrivate {
+		return true
+	}
+	return models.PermissionLevel(AgentPermission(ctx, store, subject, agent)) >= models.PermissionLevel(models.PermissionRead)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/agenthub/server/internal/access"
+	"github.com/agenthub/server/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyPrefix API Key 前缀
+const apiKeyPrefix = "ak_"
+
+// generateAPIKey 生成新的 API Key 明文
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// hashAPIKey 计算 API Key 的存储哈希
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// currentAPIKey 获取当前请求使用的 API Key，JWT 认证时返回 nil
+func currentAPIKey(c *gin.Context) *models.APIKey {
+	if v, ok := c.Get("api_key"); ok {
+		if key, ok := v.(*models.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// currentAPIKeyAllowsPrivate 判断当前认证方式是否允许读取私有智能体
+// JWT 认证始终允许，API Key 需具备 read:private 权限
+func currentAPIKeyAllowsPrivate(c *gin.Context) bool {
+	key := currentAPIKey(c)
+	return key == nil || key.HasScope(models.ScopeReadPrivate)
+}
+
+// hasNamespaceAccess 判断当前用户是否为命名空间所有者或组织成员
+func (h *Handler) hasNamespaceAccess(ctx context.Context, c *gin.Context, namespace string) bool {
+	return h.namespacePermission(ctx, c, namespace) != ""
+}
+
+// currentSubject 当前请求的用户
+func currentSubject(c *gin.Context) access.Subject {
+	return access.Subject{UserID: c.GetString("user_id"), Username: c.GetString("username")}
+}
+
+// namespacePermission 返回当前用户在命名空间下智能体上的权限，规则见 access.NamespacePermission
+func (h *Handler) namespacePermission(ctx context.Context, c *gin.Context, namespace string) string {
+	return access.NamespacePermission(ctx, h.store, currentSubject(c), namespace)
+}
+
+// agentPermission 返回当前用户在智能体上的权限，规则见 access.AgentPermission
+func (h *Handler) agentPermission(ctx context.Context, c *gin.Context, agent *models.Agent) string {
+	return access.AgentPermission(ctx, h.store, currentSubject(c), agent)
+}
+
+// hasAgentPermission 判断当前用户是否拥有不低于 required 的权限
+func (h *Handler) hasAgentPermission(ctx context.Context, c *gin.Context, agent *models.Agent, required string) bool {
+	return models.PermissionLevel(h.agentPermission(ctx, c, agent)) >= models.PermissionLevel(required)
+}
+
+// canReadAgent 判断当前请求是否可以读取智能体
+// 公开和不公开 (unlisted) 的智能体可直接访问；
+// 私有智能体仅对所有者、组织成员和协作者可见，API Key 还需具备 read:private 权限
+func (h *Handler) canReadAgent(ctx context.Context, c *gin.Context, agent *models.Agent) bool {
+	if agent.Visibility == models.VisibilityPrivate && !currentAPIKeyAllowsPrivate(c) {
+		return false
+	}
+	return access.CanRead(ctx, h.store, currentSubject(c), agent)
+}
+
+// getReadableAgent 获取当前请求可读取的智能体，会跟随转移和重命名留下的重定向
+// 无权限时与不存在一样返回错误，避免泄露私有智能体是否存在
+func (h *Handler) getReadableAgent(ctx context.Context, c *gin.Context, namespace, name string) (*models.Agent, bool) {
+	agent, err := h.store.GetAgent(ctx, namespace, name)
+	if err != nil {
+		if agent, err = h.store.GetAgentByRedirect(ctx, namespace, name); err != nil {
+			return nil, false
+		}
+	}
+	if !h.canReadAgent(ctx, c, agent) {
+		return nil, false
+	}
+	return agent, true
+}
+
+// getWritableAgent 获取写操作的目标智能体，失败时直接写入响应
+// 写操作不跟随重定向，必须使用智能体的当前名称
+func (h *Handler) getWritableAgent(ctx context.Context, c *gin.Context, namespace, name string) (*models.Agent, bool) {
+	agent, err := h.store.GetAgent(ctx, namespace, name)
+	if err != nil {
+		abortWithError(c, notFound(err, errAgentNotFound))
+		return nil, false
+	}
+	if !h.canReadAgent(ctx, c, agent) {
+		abortWithError(c, errAgentNotFound)
+		return nil, false
+	}
+	return agent, true
+}
+
+// requireAgentPermission 获取写操作的目标智能体并校验当前用户权限，失败时直接写入响应
+func (h *Handler) requireAgentPermission(ctx context.Context, c *gin.Context, namespace, name, required string) (*models.Agent, bool) {
+	agent, ok := h.getWritableAgent(ctx, c, namespace, name)
+	if !ok {
+		return nil, false
+	}
+	if !h.hasAgentPermission(ctx, c, agent, required) {
+		abortWithError(c, errPermissionDenied)
+		return nil, false
+	}
+	return agent, true
+}
+
+// setRedirectHeaders 请求使用旧名称时，通过 Location 头返回智能体的当前地址
+// 返回请求是否经过了重定向
+func setRedirectHeaders(c *gin.Context, agent *models.Agent, namespace, name string) bool {
+	if agent.Namespace == namespace && agent.Name == name {
+		return false
+	}
+	oldRef := "/" + namespace + "/" + name
+	newRef := "/" + agent.Namespace + "/" + agent.Name
+	c.Header("Location", strings.Replace(c.Request.URL.Path, oldRef, newRef, 1))
+	return true
+}
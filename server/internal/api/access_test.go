@@ -0,0 +1,247 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// addCollaborator 邀请协作者并由被邀请者接受
+func (s *testServer) addCollaborator(ownerToken, fullName, inviteeToken, username, permission string) {
+	s.t.Helper()
+	w := s.request(http.MethodPost, "/api/v1/agents/"+fullName+"/collaborators", ownerToken, InviteCollaboratorRequest{
+		Username:   username,
+		Permission: permission,
+	})
+	expectStatus(s.t, w, http.StatusCreated)
+	collab := decode[*models.AgentCollaborator](s.t, w)
+
+	w = s.request(http.MethodPost, "/api/v1/invitations/"+collab.ID+"/accept", inviteeToken, nil)
+	expectStatus(s.t, w, http.StatusOK)
+}
+
+func TestVisibilityMatrix(t *testing.T) {
+	s := newTestServer(t)
+	owner, _ := s.register("owner")
+	reader, _ := s.register("reader")
+	pending, _ := s.register("pending")
+	stranger, _ := s.register("stranger")
+
+	s.createAgent(owner, "public-agent", models.VisibilityPublic)
+	s.createAgent(owner, "unlisted-agent", models.VisibilityUnlisted)
+	s.createAgent(owner, "private-agent", models.VisibilityPrivate)
+	s.addCollaborator(owner, "owner/private-agent", reader, "reader", models.PermissionRead)
+
+	// 未接受的邀请不授予任何权限
+	w := s.request(http.MethodPost, "/api/v1/agents/owner/private-agent/collaborators", owner, InviteCollaboratorRequest{
+		Username:   "pending",
+		Permission: models.PermissionAdmin,
+	})
+	expectStatus(t, w, http.StatusCreated)
+
+	tests := []struct {
+		caller string
+		token  string
+		agent  string
+		want   int
+	}{
+		{"anonymous", "", "public-agent", http.StatusOK},
+		{"anonymous", "", "unlisted-agent", http.StatusOK},
+		{"anonymous", "", "private-agent", http.StatusNotFound},
+		{"stranger", stranger, "public-agent", http.StatusOK},
+		{"stranger", stranger, "private-agent", http.StatusNotFound},
+		{"pending", pending, "private-agent", http.StatusNotFound},
+		{"reader", reader, "private-agent", http.StatusOK},
+		{"owner", owner, "private-agent", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller+"/"+tt.agent, func(t *testing.T) {
+			w := s.request(http.MethodGet, "/api/v1/agents/owner/"+tt.agent, tt.token, nil)
+			expectStatus(t, w, tt.want)
+		})
+	}
+
+	// 列表只返回公开智能体
+	w = s.request(http.MethodGet, "/api/v1/agents", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	list := decode[Page[*models.Agent]](t, w)
+	if len(list.Items) != 1 || list.Items[0].Name != "public-agent" {
+		t.Errorf("anonymous listing returned %d agents", len(list.Items))
+	}
+}
+
+func TestPermissionMatrix(t *testing.T) {
+	s := newTestServer(t)
+	owner, _ := s.register("owner")
+	reader, _ := s.register("reader")
+	publisher, _ := s.register("publisher")
+	admin, _ := s.register("admin")
+	stranger, _ := s.register("stranger")
+
+	s.createAgent(owner, "helper", models.VisibilityPublic)
+	s.addCollaborator(owner, "owner/helper", reader, "reader", models.PermissionRead)
+	s.addCollaborator(owner, "owner/helper", publisher, "publisher", models.PermissionPublish)
+	s.addCollaborator(owner, "owner/helper", admin, "admin", models.PermissionAdmin)
+
+	tests := []struct {
+		caller      string
+		token       string
+		wantPublish int
+		wantInvite  int
+	}{
+		{"stranger", stranger, http.StatusForbidden, http.StatusForbidden},
+		{"reader", reader, http.StatusForbidden, http.StatusForbidden},
+		{"publisher", publisher, http.StatusCreated, http.StatusForbidden},
+		{"admin", admin, http.StatusCreated, http.StatusCreated},
+		{"owner", owner, http.StatusCreated, http.StatusCreated},
+	}
+	for i, tt := range tests {
+		t.Run(tt.caller, func(t *testing.T) {
+			w := s.request(http.MethodPost, "/api/v1/agents/owner/helper/versions", tt.token, PublishVersionRequest{
+				Version: "1.0." + string(rune('0'+i)),
+				Spec:    testSpec("helper"),
+			})
+			expectStatus(t, w, tt.wantPublish)
+
+			w = s.request(http.MethodPost, "/api/v1/agents/owner/helper/collaborators", tt.token, InviteCollaboratorRequest{
+				Username:   "stranger",
+				Permission: models.PermissionRead,
+			})
+			expectStatus(t, w, tt.wantInvite)
+		})
+	}
+}
+
+func TestOrgRolePermissions(t *testing.T) {
+	s := newTestServer(t)
+	ownerToken, ownerID := s.register("orgowner")
+	adminToken, adminID := s.register("orgadmin")
+	memberToken, memberID := s.register("orgmember")
+	s.store.AddOrgMember("acme", ownerID, models.OrgRoleOwner)
+	s.store.AddOrgMember("acme", adminID, models.OrgRoleAdmin)
+	s.store.AddOrgMember("acme", memberID, models.OrgRoleMember)
+	s.addAgent("acme", "bot", models.VisibilityPrivate, ownerID)
+
+	tests := []struct {
+		caller    string
+		token     string
+		wantAdmin int // 修改可见性和查看组织审计日志
+	}{
+		{"member", memberToken, http.StatusForbidden},
+		{"admin", adminToken, http.StatusOK},
+	}
+	for i, tt := range tests {
+		t.Run(tt.caller, func(t *testing.T) {
+			// 成员都可以读取和发布组织的私有智能体
+			w := s.request(http.MethodGet, "/api/v1/agents/acme/bot", tt.token, nil)
+			expectStatus(t, w, http.StatusOK)
+
+			w = s.request(http.MethodPost, "/api/v1/agents/acme/bot/versions", tt.token, PublishVersionRequest{
+				Version: "1.0." + string(rune('0'+i)),
+				Spec:    testSpec("bot"),
+			})
+			expectStatus(t, w, http.StatusCreated)
+
+			w = s.request(http.MethodPut, "/api/v1/agents/acme/bot", tt.token, CreateAgentRequest{
+				Name:        "bot",
+				Description: "bot",
+				Visibility:  models.VisibilityUnlisted,
+			})
+			expectStatus(t, w, tt.wantAdmin)
+
+			w = s.request(http.MethodGet, "/api/v1/orgs/acme/audit", tt.token, nil)
+			expectStatus(t, w, tt.wantAdmin)
+		})
+	}
+
+	w := s.request(http.MethodDelete, "/api/v1/agents/acme/bot", memberToken, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodDelete, "/api/v1/agents/acme/bot", ownerToken, nil)
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestTransferAgent(t *testing.T) {
+	s := newTestServer(t)
+	ownerToken, ownerID := s.register("orgowner")
+	memberToken, memberID := s.register("orgmember")
+	s.store.AddOrgMember("acme", ownerID, models.OrgRoleOwner)
+	s.store.AddOrgMember("acme", memberID, models.OrgRoleMember)
+	s.store.AddOrgMember("other", memberID, models.OrgRoleOwner)
+	s.addAgent("acme", "bot", models.VisibilityPublic, memberID)
+
+	// 普通成员即使是作者也不能把智能体转走
+	w := s.request(http.MethodPost, "/api/v1/agents/acme/bot/transfer", memberToken, TransferAgentRequest{Namespace: "orgmember"})
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodPost, "/api/v1/agents/acme/bot/transfer", memberToken, TransferAgentRequest{Namespace: "other"})
+	expectStatus(t, w, http.StatusForbidden)
+
+	// 目标命名空间也需要管理权限
+	w = s.request(http.MethodPost, "/api/v1/agents/acme/bot/transfer", ownerToken, TransferAgentRequest{Namespace: "other"})
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = s.request(http.MethodPost, "/api/v1/agents/acme/bot/transfer", ownerToken, TransferAgentRequest{Namespace: "orgowner"})
+	expectStatus(t, w, http.StatusOK)
+	moved := decode[MoveAgentResponse](t, w)
+	if moved.Agent.FullName != "orgowner/bot" || moved.Agent.AuthorID != ownerID {
+		t.Fatalf("unexpected transfer result %+v", moved.Agent)
+	}
+
+	// 原作者不再保留任何权限
+	w = s.request(http.MethodDelete, "/api/v1/agents/orgowner/bot", memberToken, nil)
+	expectStatus(t, w, http.StatusForbidden)
+}
+
+func TestTransferDoesNotKeepAuthorAdmin(t *testing.T) {
+	s := newTestServer(t)
+	ownerToken, ownerID := s.register("orgowner")
+	adminToken, adminID := s.register("orgadmin")
+	s.store.AddOrgMember("acme", ownerID, models.OrgRoleOwner)
+	s.store.AddOrgMember("acme", adminID, models.OrgRoleAdmin)
+	s.createAgent(adminToken, "bot", models.VisibilityPublic)
+
+	w := s.request(http.MethodPost, "/api/v1/agents/orgadmin/bot/transfer", adminToken, TransferAgentRequest{Namespace: "acme"})
+	expectStatus(t, w, http.StatusOK)
+
+	// 降为普通成员后，作为原作者也只有 publish 权限
+	s.store.AddOrgMember("acme", adminID, models.OrgRoleMember)
+	w = s.request(http.MethodDelete, "/api/v1/agents/acme/bot", adminToken, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodPost, "/api/v1/agents/acme/bot/transfer", adminToken, TransferAgentRequest{Namespace: "orgadmin"})
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = s.request(http.MethodDelete, "/api/v1/agents/acme/bot", ownerToken, nil)
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestRemoveCollaborator(t *testing.T) {
+	s := newTestServer(t)
+	owner, _ := s.register("owner")
+	reader, _ := s.register("reader")
+	publisher, _ := s.register("publisher")
+	stranger, _ := s.register("stranger")
+
+	s.createAgent(owner, "helper", models.VisibilityPublic)
+	s.addCollaborator(owner, "owner/helper", reader, "reader", models.PermissionRead)
+	s.addCollaborator(owner, "owner/helper", publisher, "publisher", models.PermissionPublish)
+
+	w := s.request(http.MethodPost, "/api/v1/agents/owner/helper/rename", owner, RenameAgentRequest{Name: "assistant"})
+	expectStatus(t, w, http.StatusOK)
+
+	remove := func(token, fullName, username string) *httptest.ResponseRecorder {
+		return s.request(http.MethodDelete, "/api/v1/agents/"+fullName+"/collaborators/"+username, token, nil)
+	}
+
+	// 写操作不跟随重定向，旧名称上的移除请求不会生效
+	expectStatus(t, remove(owner, "owner/helper", "reader"), http.StatusNotFound)
+	expectStatus(t, remove(reader, "owner/helper", "reader"), http.StatusNotFound)
+
+	expectStatus(t, remove(stranger, "owner/assistant", "reader"), http.StatusForbidden)
+	expectStatus(t, remove(publisher, "owner/assistant", "reader"), http.StatusForbidden)
+
+	// 协作者可以移除自己
+	expectStatus(t, remove(reader, "owner/assistant", "reader"), http.StatusOK)
+	expectStatus(t, remove(owner, "owner/assistant", "reader"), http.StatusNotFound)
+	expectStatus(t, remove(owner, "owner/assistant", "publisher"), http.StatusOK)
+}
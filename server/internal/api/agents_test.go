@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/storage/memory"
+)
+
+func TestAgentCRUD(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	bob, _ := s.register("bob")
+
+	agent := s.createAgent(alice, "helper", "")
+	if agent.FullName != "alice/helper" || agent.Visibility != models.VisibilityPublic {
+		t.Fatalf("unexpected agent %+v", agent)
+	}
+
+	w := s.request(http.MethodPost, "/api/v1/agents", alice, CreateAgentRequest{Name: "helper", Description: "again"})
+	expectStatus(t, w, http.StatusConflict)
+
+	w = s.request(http.MethodPost, "/api/v1/agents", alice, CreateAgentRequest{Name: "bad", Description: "x", Category: "no-such-category"})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if detail := decode[AgentDetailResponse](t, w); detail.Agent.ID != agent.ID || detail.LatestVersion != nil {
+		t.Errorf("unexpected detail %+v", detail)
+	}
+
+	update := CreateAgentRequest{Name: "helper", Description: "updated", Category: "coding"}
+	w = s.request(http.MethodPut, "/api/v1/agents/alice/helper", bob, update)
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodPut, "/api/v1/agents/alice/helper", alice, update)
+	expectStatus(t, w, http.StatusOK)
+	if updated := decode[*models.Agent](t, w); updated.Description != "updated" || updated.Category != "coding" {
+		t.Errorf("update not applied: %+v", updated)
+	}
+
+	w = s.request(http.MethodDelete, "/api/v1/agents/alice/helper", bob, nil)
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodDelete, "/api/v1/agents/alice/helper", alice, nil)
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper", "", nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestAgentRenameRedirect(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	s.createAgent(alice, "helper", "")
+
+	w := s.request(http.MethodPost, "/api/v1/agents/alice/helper/rename", alice, RenameAgentRequest{Name: "assistant"})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if loc := w.Header().Get("Location"); loc != "/api/v1/agents/alice/assistant" {
+		t.Errorf("Location = %q", loc)
+	}
+
+	// 写操作不跟随重定向
+	w = s.request(http.MethodPut, "/api/v1/agents/alice/helper", alice, CreateAgentRequest{Name: "helper", Description: "x"})
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+// failingListStore 列出智能体时总是失败
+type failingListStore struct {
+	*memory.Store
+}
+
+func (failingListStore) ListAgents(ctx context.Context, opts storage.ListAgentsOptions) ([]*models.Agent, error) {
+	return nil, errors.New("database unavailable")
+}
+
+func TestDiscoveryListErrors(t *testing.T) {
+	s := newTestServer(t)
+	s.router = NewRouter(s.cfg, failingListStore{s.store}, nil, nil)
+
+	for _, path := range []string{"/api/v1/trending", "/api/v1/featured"} {
+		t.Run(path, func(t *testing.T) {
+			w := s.request(http.MethodGet, path, "", nil)
+			expectStatus(t, w, http.StatusInternalServerError)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agenthub/server/internal/logging"
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 10000
+)
+
+// audit_log 中字符串列的长度上限 (按字符计)，超出的部分在写入前截断
+const (
+	auditActorNameMax  = 64
+	auditIPMax         = 64
+	auditUserAgentMax  = 500
+	auditTargetIDMax   = 64
+	auditTargetNameMax = 255
+	auditNamespaceMax  = 32
+)
+
+// recordAudit 追加一条审计记录，补全操作者、IP 和 User-Agent
+// 写入失败只记录日志，不影响业务请求；来自请求的字段会被截断到列长度，
+// 客户端不能通过超长的 User-Agent 或登录名让记录写入失败
+func (h *Handler) recordAudit(c *gin.Context, event *models.AuditEvent) {
+	event.ID = uuid.New().String()
+	event.CreatedAt = time.Now()
+	event.IP = c.ClientIP()
+	event.UserAgent = c.Request.UserAgent()
+	if event.ActorID == "" {
+		event.ActorID = c.GetString("user_id")
+	}
+	if event.ActorName == "" {
+		event.ActorName = c.GetString("username")
+	}
+	event.ActorName = truncateString(event.ActorName, auditActorNameMax)
+	event.IP = truncateString(event.IP, auditIPMax)
+	event.UserAgent = truncateString(event.UserAgent, auditUserAgentMax)
+	event.TargetID = truncateString(event.TargetID, auditTargetIDMax)
+	event.TargetName = truncateString(event.TargetName, auditTargetNameMax)
+	event.Namespace = truncateString(event.Namespace, auditNamespaceMax)
+
+	// 业务操作已经完成，审计记录不随客户端断开而丢失
+	ctx, cancel := detachedContext(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.CreateAuditEvent(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// truncateString 截断到最多 max 个字符，不会切断多字节字符
+// 非法的 UTF-8 替换为 U+FFFD 并去掉 NUL 字符，PostgreSQL 的文本列不接受它们
+func truncateString(s string, max int) string {
+	s = strings.ToValidUTF8(strings.ReplaceAll(s, "\x00", ""), "\uFFFD")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// agentAuditEvent 创建针对智能体的审计记录
+func agentAuditEvent(action string, agent *models.Agent) *models.AuditEvent {
+	return &models.AuditEvent{
+		Action:     action,
+		TargetType: "agent",
+		TargetID:   agent.ID,
+		TargetName: agent.FullName,
+		AgentID:    agent.ID,
+		Namespace:  agent.Namespace,
+	}
+}
+
+// auditDiff 比较两个对象的 JSON 表示，只返回有差异的字段
+// before 或 after 为 nil 时返回另一方的全部字段
+func auditDiff(before, after interface{}) (map[string]interface{}, map[string]interface{}) {
+	b := toFieldMap(before)
+	a := toFieldMap(after)
+	if b == nil || a == nil {
+		return b, a
+	}
+
+	diffBefore := map[string]interface{}{}
+	diffAfter := map[string]interface{}{}
+	for key, value := range a {
+		if !reflect.DeepEqual(b[key], value) {
+			diffBefore[key] = b[key]
+			diffAfter[key] = value
+		}
+	}
+	for key, value := range b {
+		if _, ok := a[key]; !ok {
+			diffBefore[key] = value
+		}
+	}
+	return diffBefore, diffAfter
+}
+
+func toFieldMap(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// ===== 审计日志查询 =====
+
+// ListAgentAudit 查询智能体的审计日志 (需要 admin 权限)
+func (h *Handler) ListAgentAudit(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agent, ok := h.requireAgentPermission(ctx, c, c.Param("namespace"), c.Param("name"), models.PermissionAdmin)
+	if !ok {
+		return
+	}
+
+	h.writeAuditEvents(ctx, c, storage.AuditQuery{AgentID: agent.ID})
+}
+
+// ListOrgAudit 查询组织的审计日志 (需要组织 owner 或 admin 角色)
+func (h *Handler) ListOrgAudit(c *gin.Context) {
+	org := c.Param("org")
+
+	ctx := c.Request.Context()
+
+	// 非成员时 GetOrgRole 返回 sql.ErrNoRows，与非管理员一样视为无权限
+	role, err := h.store.GetOrgRole(ctx, org, c.GetString("user_id"))
+	if err != nil {
+		abortWithError(c, notFound(err, errPermissionDenied))
+		return
+	}
+	if models.OrgRolePermission(role) != models.PermissionAdmin {
+		abortWithError(c, errPermissionDenied)
+		return
+	}
+
+	h.writeAuditEvents(ctx, c, storage.AuditQuery{Namespace: org})
+}
+
+// ListAuditLog 查询全站审计日志 (管理员)
+func (h *Handler) ListAuditLog(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	h.writeAuditEvents(ctx, c, storage.AuditQuery{
+		AgentID:   c.Query("agent_id"),
+		Namespace: c.Query("namespace"),
+		ActorID:   c.Query("actor_id"),
+	})
+}
+
+// writeAuditEvents 解析通用过滤参数并输出审计记录
+// format=jsonl 时以 JSON Lines 格式导出
+func (h *Handler) writeAuditEvents(ctx context.Context, c *gin.Context, q storage.AuditQuery) {
+	q.Action = c.Query("action")
+
+	var err error
+	if since := c.Query("since"); since != "" {
+		if q.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			abortWithError(c, validationError("since", "invalid since, expected RFC3339"))
+			return
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		if q.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			abortWithError(c, validationError("until", "invalid until, expected RFC3339"))
+			return
+		}
+	}
+
+	page, err := parsePage(c, storage.SortRecent, defaultAuditLimit, maxAuditLimit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	q.PageOptions = page.query()
+
+	events, err := h.store.ListAuditEvents(ctx, q)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	// 导出为 JSON Lines 时下一页地址只在 Link 头中给出
+	result := newPage(c, page, events, func(e *models.AuditEvent) storage.Cursor {
+		return storage.RecentCursor(e.CreatedAt, e.ID)
+	})
+
+	if c.Query("format") == "jsonl" {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+		c.Status(http.StatusOK)
+		enc := json.NewEncoder(c.Writer)
+		for _, event := range result.Items {
+			if err := enc.Encode(event); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
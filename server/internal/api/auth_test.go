@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice")
+
+	w := s.request(http.MethodPost, "/api/v1/auth/register", "", RegisterRequest{
+		Username: "alice",
+		Email:    "other@example.com",
+		Password: "password123",
+	})
+	expectStatus(t, w, http.StatusConflict)
+
+	for _, login := range []string{"alice", "alice@example.com"} {
+		w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: login, Password: "password123"})
+		expectStatus(t, w, http.StatusOK)
+		if resp := decode[AuthResponse](t, w); resp.Token == "" || resp.User.Username != "alice" {
+			t.Errorf("login %s: unexpected response %+v", login, resp)
+		}
+	}
+
+	w = s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "alice", Password: "wrong-password"})
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestAuthRequired(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"missing token", ""},
+		{"invalid token", "not-a-jwt"},
+		{"api key on jwt route", apiKeyPrefix + "0123456789abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.request(http.MethodPost, "/api/v1/agents", tt.token, CreateAgentRequest{Name: "x", Description: "x"})
+			expectStatus(t, w, http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice")
+	s.register("bob")
+
+	limit := s.cfg.RateLimit.Login.MaxFailures
+	for i := 0; i < limit; i++ {
+		w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "alice", Password: "wrong-password"})
+		expectStatus(t, w, http.StatusUnauthorized)
+	}
+
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "alice", Password: "password123"})
+	expectStatus(t, w, http.StatusTooManyRequests)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("locked login response has no Retry-After")
+	}
+
+	// 其他账号不受影响
+	w = s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "bob", Password: "password123"})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestLoginLockoutUnderConcurrency(t *testing.T) {
+	s := newTestServer(t)
+	s.register("alice")
+
+	const attempts = 15
+	var wg sync.WaitGroup
+	codes := make(chan int, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "alice", Password: "wrong-password"})
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	// 只有通过计数的尝试才会校验密码并返回 401
+	verified := 0
+	for code := range codes {
+		switch code {
+		case http.StatusUnauthorized:
+			verified++
+		case http.StatusTooManyRequests:
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if limit := s.cfg.RateLimit.Login.MaxFailures; verified > limit {
+		t.Errorf("%d parallel attempts reached password verification, want at most %d", verified, limit)
+	}
+}
+
+func TestLoginSuccessDoesNotCountAgainstIP(t *testing.T) {
+	s := newTestServer(t)
+	s.cfg.RateLimit.Login.MaxFailuresPerIP = 3
+	s.router = NewRouter(s.cfg, s.store, nil, nil)
+	s.register("alice")
+
+	for i := 0; i < 5; i++ {
+		w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "alice", Password: "password123"})
+		expectStatus(t, w, http.StatusOK)
+	}
+	for i := 0; i < 3; i++ {
+		w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "nobody", Password: "password123"})
+		expectStatus(t, w, http.StatusUnauthorized)
+	}
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: "alice", Password: "password123"})
+	expectStatus(t, w, http.StatusTooManyRequests)
+}
+
+func TestFailedLoginAuditIsTruncated(t *testing.T) {
+	s := newTestServer(t)
+
+	login := strings.Repeat("x", 1000)
+	userAgent := strings.Repeat("a", 5000)
+	w := s.request(http.MethodPost, "/api/v1/auth/login", "", LoginRequest{Login: login, Password: "password123"},
+		"User-Agent", userAgent)
+	expectStatus(t, w, http.StatusUnauthorized)
+
+	events, err := s.store.ListAuditEvents(context.Background(), storage.AuditQuery{
+		Action:      models.AuditUserLoginFailed,
+		PageOptions: storage.PageOptions{Limit: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d failed-login events, want 1", len(events))
+	}
+	event := events[0]
+	if event.TargetName != login[:auditTargetNameMax] {
+		t.Errorf("target_name has %d characters, want %d", len(event.TargetName), auditTargetNameMax)
+	}
+	if event.UserAgent != userAgent[:auditUserAgentMax] {
+		t.Errorf("user_agent has %d characters, want %d", len(event.UserAgent), auditUserAgentMax)
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc"},
+		{"智能体市场", 3, "智能体"},
+		{"a\x00b", 10, "ab"},
+		{"a\xffb", 10, "a�b"},
+	}
+	for _, tt := range tests {
+		if got := truncateString(tt.in, tt.max); got != tt.want {
+			t.Errorf("truncateString(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/agenthub/server/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest 客户端在响应前断开 (沿用 nginx 的 499)，只出现在日志和指标中
+const StatusClientClosedRequest = 499
+
+// writeDeadlineGrace 连接写超时比处理时限多出的时间，超时后仍能写出 504 响应
+const writeDeadlineGrace = 5 * time.Second
+
+const (
+	// baseContextKey 第一个时限中间件保存的原始请求 context，路由上的时限基于它重新计算
+	baseContextKey = "base_context"
+	// abortReasonKey 请求被中止的原因 (metrics.ReasonCancelled / metrics.ReasonTimeout)
+	abortReasonKey = "abort_reason"
+)
+
+// BudgetMiddleware 为请求设置处理时限
+// context 派生自 c.Request.Context()，客户端断开或超出时限时，正在执行的数据库查询和下游调用随之取消。
+// 可以同时挂在分组和路由上，路由上的时限替换分组的时限，因此可以比分组更长
+func BudgetMiddleware(budget time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var base context.Context
+		value, nested := c.Get(baseContextKey)
+		if nested {
+			base = value.(context.Context)
+		} else {
+			base = c.Request.Context()
+			c.Set(baseContextKey, base)
+			c.Writer = &budgetWriter{ResponseWriter: c.Writer, c: c}
+		}
+
+		ctx, cancel := context.WithTimeout(base, budget)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// http.Server.WriteTimeout 是所有路由共用的固定值，比调用和审计查询的时限短，
+		// 这里按路由时限重新设置连接的写超时，并留出写入超时响应的时间；测试用的 ResponseRecorder 不支持，忽略错误
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(budget + writeDeadlineGrace))
+
+		c.Next()
+
+		// 只有最内层 (处理器实际使用) 的 context 代表请求结果；
+		// 必须在 cancel 之前读取，此时 context 已结束只可能是客户端断开或超时
+		if c.Request.Context() == ctx {
+			if reason := metrics.AbortReason(ctx.Err()); reason != "" {
+				c.Set(abortReasonKey, reason)
+			}
+		}
+		if !nested {
+			if reason := c.GetString(abortReasonKey); reason != "" {
+				metrics.RequestAborted(c.FullPath(), reason)
+			}
+		}
+	}
+}
+
+// budgetWriter 请求已被中止时，将处理器返回的 5xx 改写为 499 或 504
+// 存储层此时返回的是 context 错误，不应作为服务端故障统计
+type budgetWriter struct {
+	gin.ResponseWriter
+	c *gin.Context
+}
+
+// Unwrap 供 http.ResponseController 找到底层连接
+func (w *budgetWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *budgetWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError {
+		switch metrics.AbortReason(w.c.Request.Context().Err()) {
+		case metrics.ReasonCancelled:
+			code = StatusClientClosedRequest
+		case metrics.ReasonTimeout:
+			code = http.StatusGatewayTimeout
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// requestOutcome 请求结果，用于访问日志
+func requestOutcome(c *gin.Context) string {
+	if reason := c.GetString(abortReasonKey); reason != "" {
+		return reason
+	}
+	return "completed"
+}
+
+// detachedContext 返回不随请求取消的 context，保留请求 logger 和链路信息
+// 用于审计记录、登录失败计数等结果已经确定、必须落库的写操作
+func detachedContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBudgetExtendsWriteDeadline(t *testing.T) {
+	slow := func(c *gin.Context) {
+		time.Sleep(300 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	}
+	r := gin.New()
+	r.GET("/budget", BudgetMiddleware(5*time.Second), slow)
+	r.GET("/plain", slow)
+
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/budget")
+	if err != nil {
+		t.Fatalf("route with a longer budget was cut off: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Errorf("got %d %q", resp.StatusCode, body)
+	}
+
+	// 没有时限中间件的路由仍受 WriteTimeout 限制
+	if resp, err := http.Get(srv.URL + "/plain"); err == nil {
+		resp.Body.Close()
+		t.Error("route without a budget outlived the server WriteTimeout")
+	}
+}
+
+func TestBudgetTimeoutStatus(t *testing.T) {
+	r := gin.New()
+	r.GET("/slow", BudgetMiddleware(20*time.Millisecond), func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		abortWithError(c, c.Request.Context().Err())
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	expectStatus(t, w, http.StatusGatewayTimeout)
+}
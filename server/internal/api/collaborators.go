@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ===== 协作者 =====
+
+// ListCollaborators 列出智能体协作者
+func (h *Handler) ListCollaborators(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agent, ok := h.requireAgentPermission(ctx, c, c.Param("namespace"), c.Param("name"), models.PermissionAdmin)
+	if !ok {
+		return
+	}
+
+	collabs, err := h.store.ListCollaborators(ctx, agent.ID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if collabs == nil {
+		collabs = []*models.AgentCollaborator{}
+	}
+
+	c.JSON(http.StatusOK, singlePage(collabs))
+}
+
+// InviteCollaboratorRequest 邀请协作者请求
+type InviteCollaboratorRequest struct {
+	Username   string `json:"username" binding:"required"`
+	Permission string `json:"permission" binding:"required,oneof=read publish admin"`
+}
+
+// InviteCollaborator 邀请协作者
+// 已是协作者时只更新权限，不改变邀请状态
+func (h *Handler) InviteCollaborator(c *gin.Context) {
+	var req InviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	agent, ok := h.requireAgentPermission(ctx, c, c.Param("namespace"), c.Param("name"), models.PermissionAdmin)
+	if !ok {
+		return
+	}
+
+	invitee, err := h.store.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		abortWithError(c, notFound(err, errUserNotFound))
+		return
+	}
+	if invitee.ID == agent.AuthorID || invitee.Username == agent.Namespace {
+		abortWithError(c, validationError("username", "user already owns this agent"))
+		return
+	}
+
+	collab := &models.AgentCollaborator{
+		ID:         uuid.New().String(),
+		AgentID:    agent.ID,
+		UserID:     invitee.ID,
+		Username:   invitee.Username,
+		Permission: req.Permission,
+		Status:     models.CollaboratorPending,
+		InvitedBy:  c.GetString("user_id"),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := h.store.UpsertCollaborator(ctx, collab); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	event := agentAuditEvent(models.AuditCollabInvite, agent)
+	event.After = map[string]interface{}{"username": invitee.Username, "permission": req.Permission}
+	h.recordAudit(c, event)
+
+	c.JSON(http.StatusCreated, collab)
+}
+
+// RemoveCollaborator 移除协作者
+// 智能体管理员可移除任何协作者，协作者也可以移除自己
+func (h *Handler) RemoveCollaborator(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agent, ok := h.getWritableAgent(ctx, c, c.Param("namespace"), c.Param("name"))
+	if !ok {
+		return
+	}
+
+	user, err := h.store.GetUserByUsername(ctx, c.Param("username"))
+	if err != nil {
+		abortWithError(c, notFound(err, errCollaboratorMissing))
+		return
+	}
+
+	if user.ID != c.GetString("user_id") && !h.hasAgentPermission(ctx, c, agent, models.PermissionAdmin) {
+		abortWithError(c, errPermissionDenied)
+		return
+	}
+
+	if err := h.store.DeleteCollaborator(ctx, agent.ID, user.ID); err != nil {
+		abortWithError(c, notFound(err, errCollaboratorMissing))
+		return
+	}
+
+	event := agentAuditEvent(models.AuditCollabRemove, agent)
+	event.Before = map[string]interface{}{"username": user.Username}
+	h.recordAudit(c, event)
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "collaborator removed"})
+}
+
+// ListInvitations 列出当前用户待接受的协作邀请
+func (h *Handler) ListInvitations(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	invitations, err := h.store.ListInvitations(ctx, c.GetString("user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if invitations == nil {
+		invitations = []*models.AgentCollaborator{}
+	}
+
+	c.JSON(http.StatusOK, singlePage(invitations))
+}
+
+// AcceptInvitation 接受协作邀请
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agentID, err := h.store.AcceptInvitation(ctx, c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		abortWithError(c, notFound(err, errInvitationNotFound))
+		return
+	}
+
+	h.recordInvitationAudit(ctx, c, models.AuditInvitationAccept, agentID)
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "invitation accepted"})
+}
+
+// DeclineInvitation 拒绝协作邀请
+func (h *Handler) DeclineInvitation(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agentID, err := h.store.DeleteInvitation(ctx, c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		abortWithError(c, notFound(err, errInvitationNotFound))
+		return
+	}
+
+	h.recordInvitationAudit(ctx, c, models.AuditInvitationDecline, agentID)
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "invitation declined"})
+}
+
+// recordInvitationAudit 记录邀请的接受或拒绝
+func (h *Handler) recordInvitationAudit(ctx context.Context, c *gin.Context, action, agentID string) {
+	event := &models.AuditEvent{Action: action, TargetType: "collaborator", TargetID: c.Param("id"), AgentID: agentID}
+	if agent, err := h.store.GetAgentByID(ctx, agentID); err == nil {
+		event = agentAuditEvent(action, agent)
+		event.TargetType = "collaborator"
+		event.TargetID = c.Param("id")
+	}
+	h.recordAudit(c, event)
+}
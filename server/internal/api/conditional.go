@@ -0,0 +1,128 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// HTTP 缓存 (RFC 9110 第 13 节，RFC 9111)
+// 响应带强 ETag，可能时带 Last-Modified；客户端用 If-None-Match / If-Modified-Since 重新验证，未变化时返回 304。
+// 已发布的具体版本内容不可变，但弃用状态、可见性和删除仍会变化，只允许短时间缓存，过期后重新验证；
+// 其余响应每次使用前都需要重新验证。
+const (
+	cacheVersion    = "max-age=300"
+	cacheRevalidate = "no-cache"
+)
+
+// cacheHeaders 响应的验证器和缓存策略
+type cacheHeaders struct {
+	ETag         string    // 强 ETag，不含引号；为空时由 writeCachedJSON 取响应体的哈希
+	LastModified time.Time // 零值表示不提供 Last-Modified
+	CacheControl string    // 缓存策略，public / private 由 checkNotModified 决定
+	Private      bool      // 响应包含私有智能体的数据，不允许共享缓存保存
+}
+
+// checkNotModified 设置缓存相关的响应头，条件请求满足时回复 304 并返回 true
+func checkNotModified(c *gin.Context, h cacheHeaders) bool {
+	header := c.Writer.Header()
+
+	// 可选认证的接口对不同调用方返回的内容不同，已认证的响应只允许客户端自己缓存
+	scope := "public"
+	if h.Private || c.GetString("user_id") != "" {
+		scope = "private"
+	}
+	header.Set("Cache-Control", scope+", "+h.CacheControl)
+	header.Add("Vary", "Authorization, X-API-Key")
+
+	etag := ""
+	if h.ETag != "" {
+		etag = `"` + h.ETag + `"`
+		header.Set("ETag", etag)
+	}
+	lastModified := h.LastModified.UTC().Truncate(time.Second)
+	if !h.LastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if !notModified(c.Request, etag, lastModified) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// notModified 判断条件请求，If-None-Match 存在时忽略 If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		// If-None-Match 使用弱比较
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
+
+// writeCachedJSON 输出 JSON 响应并处理条件请求
+func writeCachedJSON(c *gin.Context, v interface{}, h cacheHeaders) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if h.ETag == "" {
+		h.ETag = contentDigest(body)
+	}
+	if checkNotModified(c, h) {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// contentDigest 内容的 SHA-256 十六进制摘要，也用作版本的 Digest
+func contentDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// versionCacheHeaders 版本及其文件的缓存策略
+// ETag 为版本摘要；通过 latest 访问时指向的版本会变化，每次都要重新验证。
+// 不使用 immutable：智能体改为私有或被删除、版本被弃用后，缓存最多在 max-age 内继续使用旧响应
+func versionCacheHeaders(agent *models.Agent, version *models.AgentVersion, tag string) cacheHeaders {
+	h := cacheHeaders{
+		ETag:         version.Digest,
+		LastModified: version.PublishedAt,
+		CacheControl: cacheVersion,
+		Private:      agent.Visibility == models.VisibilityPrivate,
+	}
+	if tag == "latest" {
+		h.CacheControl = cacheRevalidate
+	}
+	return h
+}
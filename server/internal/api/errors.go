@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/agenthub/server/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType 错误响应的媒体类型 (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// errorDocsURL 错误码文档地址前缀，响应的 type 字段为该前缀加错误码
+const errorDocsURL = "https://docs.agenthub.dev/errors/"
+
+// 错误码，客户端据此处理错误和显示本地化提示；已发布的错误码不能改变含义
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeInvalidSpec        = "invalid_spec"
+	CodeInvalidPackage     = "invalid_package"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeInvalidAPIKey      = "invalid_api_key"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeAgentNotFound      = "agent_not_found"
+	CodeVersionNotFound    = "version_not_found"
+	CodeUserNotFound       = "user_not_found"
+	CodeConflict           = "conflict"
+	CodeAgentExists        = "agent_exists"
+	CodeVersionExists      = "version_exists"
+	CodeUsernameTaken      = "username_taken"
+	CodeEmailTaken         = "email_taken"
+	CodeRateLimited        = "rate_limited"
+	CodeLoginLocked        = "login_locked"
+	CodeRequestCancelled   = "request_cancelled"
+	CodeTimeout            = "timeout"
+	CodeUnavailable        = "service_unavailable"
+	CodeInternal           = "internal_error"
+)
+
+// APIError 统一错误响应，序列化为 RFC 7807 problem+json
+// type 为错误码文档地址，detail 为英文说明，其余为扩展字段
+type APIError struct {
+	Type       string        `json:"type"`
+	Title      string        `json:"title"`
+	Status     int           `json:"status"`
+	Code       string        `json:"code"`
+	Message    string        `json:"detail"`
+	Details    []ErrorDetail `json:"details,omitempty"`
+	Instance   string        `json:"instance,omitempty"`
+	RequestID  string        `json:"request_id,omitempty"`
+	RetryAfter int           `json:"retry_after,omitempty"`
+
+	// Legacy 与 detail 相同，兼容只读取 error 字段的旧版客户端
+	Legacy string `json:"error"`
+}
+
+// ErrorDetail 错误明细，例如单个字段的校验失败
+type ErrorDetail struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule,omitempty"` // 校验规则，例如 required、min、email
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewError 创建错误
+func NewError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// withMessage 返回替换说明后的副本，预定义错误是共享的，不能直接修改
+func (e *APIError) withMessage(format string, args ...interface{}) *APIError {
+	clone := *e
+	clone.Message = fmt.Sprintf(format, args...)
+	return &clone
+}
+
+// 预定义错误
+var (
+	errInvalidBody         = NewError(http.StatusBadRequest, CodeInvalidRequest, "malformed request body")
+	errValidationFailed    = NewError(http.StatusBadRequest, CodeValidationFailed, "request validation failed")
+	errInvalidSpec         = NewError(http.StatusBadRequest, CodeInvalidSpec, "invalid agent spec")
+	errUnauthorized        = NewError(http.StatusUnauthorized, CodeUnauthorized, "authentication required")
+	errInvalidCredentials  = NewError(http.StatusUnauthorized, CodeInvalidCredentials, "invalid credentials")
+	errInvalidAPIKey       = NewError(http.StatusUnauthorized, CodeInvalidAPIKey, "invalid API key")
+	errPermissionDenied    = NewError(http.StatusForbidden, CodeForbidden, "permission denied")
+	errNotFound            = NewError(http.StatusNotFound, CodeNotFound, "resource not found")
+	errAgentNotFound       = NewError(http.StatusNotFound, CodeAgentNotFound, "agent not found")
+	errVersionNotFound     = NewError(http.StatusNotFound, CodeVersionNotFound, "version not found")
+	errUserNotFound        = NewError(http.StatusNotFound, CodeUserNotFound, "user not found")
+	errConflict            = NewError(http.StatusConflict, CodeConflict, "resource already exists")
+	errAgentExists         = NewError(http.StatusConflict, CodeAgentExists, "agent already exists")
+	errVersionExists       = NewError(http.StatusConflict, CodeVersionExists, "version already exists")
+	errUsernameTaken       = NewError(http.StatusConflict, CodeUsernameTaken, "username already exists")
+	errEmailTaken          = NewError(http.StatusConflict, CodeEmailTaken, "email already exists")
+	errRateLimited         = NewError(http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded")
+	errLoginLocked         = NewError(http.StatusTooManyRequests, CodeLoginLocked, "too many failed login attempts")
+	errRequestCancelled    = NewError(StatusClientClosedRequest, CodeRequestCancelled, "request cancelled by client")
+	errTimeout             = NewError(http.StatusGatewayTimeout, CodeTimeout, "request timed out")
+	errRateLimiterDown     = NewError(http.StatusServiceUnavailable, CodeUnavailable, "rate limiter unavailable")
+	errInternal            = NewError(http.StatusInternalServerError, CodeInternal, "internal server error")
+	errRouteNotFound       = NewError(http.StatusNotFound, CodeNotFound, "route not found")
+	errAdminRequired       = NewError(http.StatusForbidden, CodeForbidden, "admin required")
+	errNamespaceForbidden  = NewError(http.StatusForbidden, CodeForbidden, "no access to target namespace")
+	errCollaboratorMissing = NewError(http.StatusNotFound, CodeNotFound, "collaborator not found")
+	errInvitationNotFound  = NewError(http.StatusNotFound, CodeNotFound, "invitation not found")
+	errWebhookNotFound     = NewError(http.StatusNotFound, CodeNotFound, "webhook not found")
+	errDeliveryNotFound    = NewError(http.StatusNotFound, CodeNotFound, "delivery not found")
+	errKeyNotFound         = NewError(http.StatusNotFound, CodeNotFound, "key not found")
+	errFileNotFound        = NewError(http.StatusNotFound, CodeNotFound, "file not found")
+	errPackageNotFound     = NewError(http.StatusNotFound, CodeNotFound, "package not found")
+	errInvalidPackage      = NewError(http.StatusBadRequest, CodeInvalidPackage, "invalid package")
+	errPackageTooLarge     = NewError(http.StatusRequestEntityTooLarge, CodeInvalidPackage, "package too large")
+	errPackagesUnavailable = NewError(http.StatusServiceUnavailable, CodeUnavailable, "package storage unavailable")
+)
+
+func init() {
+	// 校验错误使用 JSON 字段名，与请求体保持一致
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// abortWithError 写入 problem+json 错误响应并中止处理链
+// err 可以是 *APIError，也可以是存储层或 context 的原始错误，由 toAPIError 统一映射；
+// 映射为 5xx 的原始错误会记入访问日志，响应中只返回通用说明
+func abortWithError(c *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		c.Error(err)
+	}
+
+	resp := *apiErr
+	resp.Type = errorDocsURL + resp.Code
+	resp.Title = http.StatusText(resp.Status)
+	if resp.Status == StatusClientClosedRequest {
+		resp.Title = "Client Closed Request"
+	}
+	resp.Instance = c.Request.URL.Path
+	resp.RequestID = c.GetString("request_id")
+	resp.Legacy = resp.Message
+
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(resp.Status, &resp)
+}
+
+// toAPIError 将错误映射为 API 错误
+// sql.ErrNoRows 为 404，storage.ErrDuplicate (唯一约束冲突) 为 409，客户端断开和超时分别为 499 和 504，其余为 500
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.Is(err, sql.ErrNoRows):
+		return errNotFound
+	case errors.Is(err, storage.ErrDuplicate):
+		return errConflict
+	case errors.Is(err, context.Canceled):
+		return errRequestCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return errTimeout
+	}
+	return errInternal
+}
+
+// notFound 存储层返回 sql.ErrNoRows 时替换为具体资源的错误，其余错误原样返回
+// 用于区分资源不存在和数据库故障
+func notFound(err error, apiErr *APIError) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return apiErr
+	}
+	return err
+}
+
+// conflict 存储层返回 storage.ErrDuplicate 时替换为具体资源的错误，其余错误原样返回
+func conflict(err error, apiErr *APIError) error {
+	if errors.Is(err, storage.ErrDuplicate) {
+		return apiErr
+	}
+	return err
+}
+
+// validationError 单个字段不合法
+func validationError(field, message string) *APIError {
+	resp := errValidationFailed.withMessage("%s", message)
+	resp.Details = []ErrorDetail{{Field: field, Message: message}}
+	return resp
+}
+
+// bindError 将请求体解析和校验错误转换为 400，校验失败时逐字段列出原因
+func bindError(err error) *APIError {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		resp := *errValidationFailed
+		for _, fe := range verrs {
+			resp.Details = append(resp.Details, ErrorDetail{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: validationMessage(fe),
+			})
+		}
+		return &resp
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		resp := *errInvalidBody
+		resp.Details = []ErrorDetail{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+		}}
+		return &resp
+	}
+
+	if errors.Is(err, io.EOF) {
+		return errInvalidBody.withMessage("request body is empty")
+	}
+	return errInvalidBody
+}
+
+// validationMessage 单个字段校验失败的英文说明
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "email":
+		return fe.Field() + " must be a valid email address"
+	case "url":
+		return fe.Field() + " must be a valid URL"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", fe.Field(), fe.Param(), lengthUnit(fe.Kind()))
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", fe.Field(), fe.Param(), lengthUnit(fe.Kind()))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), fe.Param())
+	case "semver":
+		return fe.Field() + " must be a semantic version such as 1.2.0"
+	}
+	return fmt.Sprintf("%s failed the %s check", fe.Field(), fe.Tag())
+}
+
+// lengthUnit min/max 对字符串限制长度，对切片限制元素个数，对数字限制取值
+func lengthUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+	return ""
+}
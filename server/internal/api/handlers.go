@@ -1,39 +1,48 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
+	"github.com/agenthub/server/internal/blobstore"
 	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/health"
+	"github.com/agenthub/server/internal/logging"
+	"github.com/agenthub/server/internal/metrics"
 	"github.com/agenthub/server/internal/models"
 	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/tracing"
+	"github.com/agenthub/server/internal/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
 )
 
 // Handler API 处理器
 type Handler struct {
-	cfg   *config.Config
-	store *storage.Storage
+	cfg    *config.Config
+	store  storage.Store
+	blobs  blobstore.Store // 项目包，存储类型尚未实现时为 nil，只能发布 spec
+	hooks  *webhook.Dispatcher
+	health *health.Checker
 }
 
 // NewHandler 创建处理器
-func NewHandler(cfg *config.Config, store *storage.Storage) *Handler {
-	return &Handler{cfg: cfg, store: store}
-}
-
-// Health 健康检查
-func (h *Handler) Health(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"version": "1.0.0",
-		"time":    time.Now().UTC(),
-	})
+func NewHandler(cfg *config.Config, store storage.Store, hooks *webhook.Dispatcher, checker *health.Checker) *Handler {
+	blobs, err := blobstore.New(cfg.Storage)
+	if err != nil {
+		slog.Warn("package uploads disabled", "error", err)
+	}
+	return &Handler{cfg: cfg, store: store, blobs: blobs, hooks: hooks, health: checker}
 }
 
 // ===== 认证 =====
@@ -50,29 +59,28 @@ type RegisterRequest struct {
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// 检查用户名是否存在
 	if _, err := h.store.GetUserByUsername(ctx, req.Username); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "username already exists"})
+		abortWithError(c, errUsernameTaken)
 		return
 	}
 
 	// 检查邮箱是否存在
 	if _, err := h.store.GetUserByEmail(ctx, req.Email); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
+		abortWithError(c, errEmailTaken)
 		return
 	}
 
 	// 哈希密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		abortWithError(c, err)
 		return
 	}
 
@@ -93,21 +101,35 @@ func (h *Handler) Register(c *gin.Context) {
 	}
 
 	if err := h.store.CreateUser(ctx, user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		// 并发注册时由唯一约束兜底
+		abortWithError(c, conflict(err, errUsernameTaken))
 		return
 	}
+	metrics.UserRegistered()
+
+	h.recordAudit(c, &models.AuditEvent{
+		Action:     models.AuditUserRegister,
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		TargetType: "user",
+		TargetID:   user.ID,
+		TargetName: user.Username,
+	})
 
 	// 生成 token
 	token, err := h.generateToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"user":  sanitizeUser(user),
-		"token": token,
-	})
+	c.JSON(http.StatusCreated, AuthResponse{User: sanitizeUser(user), Token: token})
+}
+
+// AuthResponse 注册和登录响应
+type AuthResponse struct {
+	User  *UserResponse `json:"user"`
+	Token string        `json:"token"`
 }
 
 // LoginRequest 登录请求
@@ -120,51 +142,92 @@ type LoginRequest struct {
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	// 查找用户
-	var user *models.User
-	var err error
-	if user, err = h.store.GetUserByUsername(ctx, req.Login); err != nil {
-		if user, err = h.store.GetUserByEmail(ctx, req.Login); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-			return
-		}
+	// 查找用户，存储层查询失败时也会返回非 nil 的空用户，统一置为 nil
+	user, err := h.store.GetUserByUsername(ctx, req.Login)
+	if err != nil {
+		user, err = h.store.GetUserByEmail(ctx, req.Login)
+	}
+	if err != nil {
+		user = nil
+	}
+
+	// 账号或 IP 近期尝试过多时暂时拒绝，不区分账号是否存在
+	throttle := h.newLoginThrottle(c, req.Login, user)
+	locked, err := throttle.check(ctx)
+	if err != nil {
+		abortWithError(c, errRateLimiterDown)
+		return
+	}
+	if locked != nil {
+		abortTooManyRequests(c, errLoginLocked, locked)
+		return
+	}
+
+	// 尝试在 check 中已经计入，失败时无需再记录
+	if user == nil {
+		h.recordAudit(c, &models.AuditEvent{
+			Action:     models.AuditUserLoginFailed,
+			TargetType: "user",
+			TargetName: req.Login,
+		})
+		abortWithError(c, errInvalidCredentials)
+		return
 	}
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		h.recordAudit(c, &models.AuditEvent{
+			Action:     models.AuditUserLoginFailed,
+			TargetType: "user",
+			TargetID:   user.ID,
+			TargetName: user.Username,
+		})
+		abortWithError(c, errInvalidCredentials)
 		return
 	}
 
+	throttle.succeed(ctx)
+
 	// 生成 token
 	token, err := h.generateToken(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user":  sanitizeUser(user),
-		"token": token,
+	h.recordAudit(c, &models.AuditEvent{
+		Action:     models.AuditUserLogin,
+		ActorID:    user.ID,
+		ActorName:  user.Username,
+		TargetType: "user",
+		TargetID:   user.ID,
+		TargetName: user.Username,
 	})
+
+	c.JSON(http.StatusOK, AuthResponse{User: sanitizeUser(user), Token: token})
 }
 
 // RefreshToken 刷新令牌
 func (h *Handler) RefreshToken(c *gin.Context) {
 	// 简化实现
-	c.JSON(http.StatusOK, gin.H{"message": "refresh token"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "refresh token"})
 }
 
 // Logout 登出
 func (h *Handler) Logout(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+	h.recordAudit(c, &models.AuditEvent{
+		Action:     models.AuditUserLogout,
+		TargetType: "user",
+		TargetID:   c.GetString("user_id"),
+		TargetName: c.GetString("username"),
+	})
+	c.JSON(http.StatusOK, MessageResponse{Message: "logged out"})
 }
 
 // generateToken 生成 JWT token
@@ -173,7 +236,7 @@ func (h *Handler) generateToken(user *models.User) (string, error) {
 		UserID:   user.ID,
 		Username: user.Username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(h.cfg.Auth.TokenExpiry) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.cfg.Auth.TokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "agenthub",
 		},
@@ -189,12 +252,11 @@ func (h *Handler) generateToken(user *models.User) (string, error) {
 func (h *Handler) GetUser(c *gin.Context) {
 	username := c.Param("username")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	user, err := h.store.GetUserByUsername(ctx, username)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		abortWithError(c, notFound(err, errUserNotFound))
 		return
 	}
 
@@ -205,58 +267,67 @@ func (h *Handler) GetUser(c *gin.Context) {
 func (h *Handler) GetUserAgents(c *gin.Context) {
 	username := c.Param("username")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	sort := storage.AgentSort(c.Query("sort"))
+	page, err := parsePage(c, sort, defaultPageSize, maxPageSize)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
 
-	agents, total, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
-		Author:   username,
-		Page:     1,
-		PageSize: 20,
+	// 本人或组织成员可以看到私有和不公开的智能体
+	agents, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
+		PageOptions:    page.query(),
+		Author:         username,
+		Sort:           sort,
+		IncludePrivate: h.hasNamespaceAccess(ctx, c, username) && currentAPIKeyAllowsPrivate(c),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list agents"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"agents": agents,
-		"total":  total,
-	})
+	writePage(c, page, agents, agentCursor(sort))
 }
 
 // UpdateProfile 更新用户资料
 func (h *Handler) UpdateProfile(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "profile updated"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "profile updated"})
 }
 
 // ===== 智能体 =====
 
 // ListAgents 列出智能体
 func (h *Handler) ListAgents(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	agents, total, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
-		Page:     page,
-		PageSize: pageSize,
-		Category: c.Query("category"),
-		Search:   c.Query("q"),
-		Sort:     c.Query("sort"),
-	})
+	sort := storage.AgentSort(c.Query("sort"))
+	page, err := parsePage(c, sort, defaultPageSize, maxPageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list agents"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"agents":    agents,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
+	ctx := c.Request.Context()
+
+	agents, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
+		PageOptions: page.query(),
+		Category:    c.Query("category"),
+		Search:      c.Query("q"),
+		Sort:        sort,
 	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	writePage(c, page, agents, agentCursor(sort))
+}
+
+// agentCursor 智能体列表的游标
+func agentCursor(sort string) func(*models.Agent) storage.Cursor {
+	return func(agent *models.Agent) storage.Cursor {
+		return storage.AgentCursor(agent, sort)
+	}
 }
 
 // GetAgent 获取智能体详情
@@ -264,24 +335,44 @@ func (h *Handler) GetAgent(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
 		return
 	}
 
-	// 获取最新版本
-	version, _ := h.store.GetLatestVersion(ctx, agent.ID)
+	// 获取最新版本，PostgreSQL 实现查询失败时也会返回非 nil 的空版本
+	version, err := h.store.GetLatestVersion(ctx, agent.ID)
+	if err != nil {
+		version = nil
+	}
+
+	resp := AgentDetailResponse{Agent: agent, LatestVersion: version}
+	if setRedirectHeaders(c, agent, namespace, name) {
+		resp.ResolvedName = agent.FullName
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"agent":          agent,
-		"latest_version": version,
+	// 发布新版本不更新智能体的 updated_at，取两者中较晚的时间
+	lastModified := agent.UpdatedAt
+	if version != nil && version.PublishedAt.After(lastModified) {
+		lastModified = version.PublishedAt
+	}
+	writeCachedJSON(c, resp, cacheHeaders{
+		LastModified: lastModified,
+		CacheControl: cacheRevalidate,
+		Private:      agent.Visibility == models.VisibilityPrivate,
 	})
 }
 
+// AgentDetailResponse 智能体详情
+type AgentDetailResponse struct {
+	Agent         *models.Agent        `json:"agent"`
+	LatestVersion *models.AgentVersion `json:"latest_version"`          // 尚未发布任何版本时为 null
+	ResolvedName  string               `json:"resolved_name,omitempty"` // 通过旧名称访问时为当前全名
+}
+
 // CreateAgentRequest 创建智能体请求
 type CreateAgentRequest struct {
 	Name        string   `json:"name" binding:"required"`
@@ -289,7 +380,7 @@ type CreateAgentRequest struct {
 	Category    string   `json:"category"`
 	Tags        []string `json:"tags"`
 	License     string   `json:"license"`
-	Visibility  string   `json:"visibility"`
+	Visibility  string   `json:"visibility" binding:"omitempty,oneof=public private unlisted"`
 	Homepage    string   `json:"homepage"`
 	Repository  string   `json:"repository"`
 }
@@ -298,19 +389,23 @@ type CreateAgentRequest struct {
 func (h *Handler) CreateAgent(c *gin.Context) {
 	var req CreateAgentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
 	userID := c.GetString("user_id")
 	username := c.GetString("username")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
 	// 检查是否已存在
 	if _, err := h.store.GetAgent(ctx, username, req.Name); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "agent already exists"})
+		abortWithError(c, errAgentExists)
+		return
+	}
+
+	if !h.validateCategory(ctx, req.Category) {
+		abortWithError(c, validationError("category", "unknown category: "+req.Category))
 		return
 	}
 
@@ -336,11 +431,18 @@ func (h *Handler) CreateAgent(c *gin.Context) {
 	}
 
 	if err := h.store.CreateAgent(ctx, agent); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create agent"})
+		abortWithError(c, conflict(err, errAgentExists))
 		return
 	}
 
+	// 命名空间所有者重新使用旧名称时，新智能体优先于重定向
+	h.store.DeleteAgentRedirect(ctx, username, req.Name)
+
 	agent.FullName = username + "/" + req.Name
+
+	event := agentAuditEvent(models.AuditAgentCreate, agent)
+	_, event.After = auditDiff(nil, agent)
+	h.recordAudit(c, event)
 	c.JSON(http.StatusCreated, agent)
 }
 
@@ -348,43 +450,54 @@ func (h *Handler) CreateAgent(c *gin.Context) {
 func (h *Handler) UpdateAgent(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
-	username := c.GetString("username")
 
-	if namespace != username {
-		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+	agent, ok := h.requireAgentPermission(ctx, c, namespace, name, models.PermissionPublish)
+	if !ok {
 		return
 	}
 
 	var req CreateAgentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		abortWithError(c, bindError(err))
 		return
 	}
 
+	if !h.validateCategory(ctx, req.Category) {
+		abortWithError(c, validationError("category", "unknown category: "+req.Category))
+		return
+	}
+
+	before := *agent
 	agent.Description = req.Description
 	agent.Category = req.Category
 	agent.Tags = req.Tags
 	agent.License = req.License
-	if req.Visibility != "" {
+	if req.Visibility != "" && req.Visibility != agent.Visibility {
+		// 修改可见性需要 admin 权限
+		if !h.hasAgentPermission(ctx, c, agent, models.PermissionAdmin) {
+			abortWithError(c, errPermissionDenied)
+			return
+		}
 		agent.Visibility = req.Visibility
 	}
 	agent.Homepage = req.Homepage
 	agent.Repository = req.Repository
 
 	if err := h.store.UpdateAgent(ctx, agent); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update agent"})
+		abortWithError(c, err)
 		return
 	}
 
+	action := models.AuditAgentUpdate
+	if before.Visibility != agent.Visibility {
+		action = models.AuditAgentVisibility
+	}
+	event := agentAuditEvent(action, agent)
+	event.Before, event.After = auditDiff(&before, agent)
+	h.recordAudit(c, event)
+
 	c.JSON(http.StatusOK, agent)
 }
 
@@ -392,28 +505,26 @@ func (h *Handler) UpdateAgent(c *gin.Context) {
 func (h *Handler) DeleteAgent(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
-	username := c.GetString("username")
-
-	if namespace != username {
-		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
-		return
-	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+	agent, ok := h.requireAgentPermission(ctx, c, namespace, name, models.PermissionAdmin)
+	if !ok {
 		return
 	}
 
 	if err := h.store.DeleteAgent(ctx, agent.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete agent"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "agent deleted"})
+	event := agentAuditEvent(models.AuditAgentDelete, agent)
+	event.Before, _ = auditDiff(agent, nil)
+	h.recordAudit(c, event)
+
+	h.hooks.Publish(ctx, models.EventAgentDeleted, agent, nil)
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "agent deleted"})
 }
 
 // ===== 版本管理 =====
@@ -423,22 +534,30 @@ func (h *Handler) ListVersions(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
+	page, err := parsePage(c, storage.SortRecent, defaultPageSize, maxPageSize)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+		abortWithError(c, err)
+		return
+	}
+
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
 		return
 	}
 
-	versions, err := h.store.ListVersions(ctx, agent.ID)
+	versions, err := h.store.ListVersions(ctx, agent.ID, page.query())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list versions"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"versions": versions})
+	setRedirectHeaders(c, agent, namespace, name)
+	writePage(c, page, versions, func(v *models.AgentVersion) storage.Cursor {
+		return storage.RecentCursor(v.PublishedAt, v.ID)
+	})
 }
 
 // GetVersion 获取特定版本
@@ -447,72 +566,107 @@ func (h *Handler) GetVersion(c *gin.Context) {
 	name := c.Param("name")
 	versionTag := c.Param("version")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
 		return
 	}
 
-	var version *models.AgentVersion
-	if versionTag == "latest" {
-		version, err = h.store.GetLatestVersion(ctx, agent.ID)
-	} else {
-		version, err = h.store.GetVersion(ctx, agent.ID, versionTag)
+	version, err := h.lookupVersion(ctx, agent.ID, versionTag)
+	if err != nil {
+		abortWithError(c, notFound(err, errVersionNotFound))
+		return
 	}
 
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+	setRedirectHeaders(c, agent, namespace, name)
+
+	// 弃用会改变响应中的 status，ETag 随之变化，重新验证的客户端能看到弃用状态
+	cache := versionCacheHeaders(agent, version, versionTag)
+	if version.Status != "active" {
+		cache.ETag += "-" + version.Status
+	}
+	// 客户端已有缓存时不计入下载次数
+	if checkNotModified(c, cache) {
 		return
 	}
 
+	if version.PackageDigest != "" {
+		files, err := h.store.ListFiles(ctx, version.ID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		version.Files = files
+	}
+
 	// 增加下载次数
 	h.store.IncrementDownloads(ctx, agent.ID)
+	metrics.VersionPulled()
 
 	c.JSON(http.StatusOK, version)
 }
 
-// PublishVersionRequest 发布版本请求
+// lookupVersion 按版本号获取版本，latest 表示最新版本
+func (h *Handler) lookupVersion(ctx context.Context, agentID, tag string) (*models.AgentVersion, error) {
+	if tag == "latest" {
+		return h.store.GetLatestVersion(ctx, agentID)
+	}
+	return h.store.GetVersion(ctx, agentID, tag)
+}
+
+// PublishVersionRequest 发布版本请求，也可以 multipart/form-data 提交并附带项目包
 type PublishVersionRequest struct {
-	Version   string `json:"version" binding:"required"`
-	Spec      string `json:"spec" binding:"required"`
-	Changelog string `json:"changelog"`
+	Version   string `json:"version" form:"version" binding:"required,semver"`
+	Spec      string `json:"spec" form:"spec" binding:"required"`
+	Changelog string `json:"changelog" form:"changelog"`
 }
 
 // PublishVersion 发布新版本
+// dry_run=true 时执行全部校验并返回将要发布的版本，不保存版本和项目包；
+// 自己命名空间下尚未创建的智能体也可以 dry run，此时返回的版本没有 agent_id
 func (h *Handler) PublishVersion(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
-	username := c.GetString("username")
 	userID := c.GetString("user_id")
-
-	if namespace != username {
-		c.JSON(http.StatusForbidden, gin.H{"error": "permission denied"})
-		return
-	}
+	dryRun := c.Query("dry_run") == "true"
 
 	var req PublishVersionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	pkg, err := bindPublishRequest(c, &req)
+	if err != nil {
+		abortWithError(c, err)
 		return
 	}
 
 	// 验证 spec
 	var spec models.AgentSpec
 	if err := yaml.Unmarshal([]byte(req.Spec), &spec); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid agent spec: " + err.Error()})
+		abortWithError(c, errInvalidSpec.withMessage("invalid agent spec: %v", err))
+		return
+	}
+	if details := validateSpec(&spec); len(details) > 0 {
+		resp := *errInvalidSpec
+		resp.Details = details
+		abortWithError(c, &resp)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
-		return
+	var agent *models.Agent
+	if dryRun {
+		var err error
+		if agent, err = h.newAgentForDryRun(ctx, c, namespace, name, &spec); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+	if agent == nil {
+		var ok bool
+		if agent, ok = h.requireAgentPermission(ctx, c, namespace, name, models.PermissionPublish); !ok {
+			return
+		}
 	}
 
 	version := &models.AgentVersion{
@@ -525,30 +679,184 @@ func (h *Handler) PublishVersion(c *gin.Context) {
 		PublishedAt: time.Now(),
 		PublishedBy: userID,
 		Status:      "active",
+		Digest:      contentDigest([]byte(req.Spec)),
 		Size:        int64(len(req.Spec)),
 	}
 
+	// 项目包按摘要保存，发布失败时留下的对象可以被同样内容的发布复用
+	if pkg != nil {
+		if h.blobs == nil {
+			abortWithError(c, errPackagesUnavailable)
+			return
+		}
+		files, err := inspectPackage(pkg, req.Spec)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		version.PackageDigest = contentDigest(pkg)
+		version.PackageSize = int64(len(pkg))
+		version.Files = files
+	}
+
+	if dryRun {
+		// 尚未创建的智能体没有任何版本
+		if agent.ID != "" {
+			_, err := h.store.GetVersion(ctx, agent.ID, req.Version)
+			if err == nil {
+				abortWithError(c, errVersionExists)
+				return
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				abortWithError(c, err)
+				return
+			}
+		}
+		c.JSON(http.StatusOK, version)
+		return
+	}
+
+	if pkg != nil {
+		if err := h.blobs.Put(ctx, packageKey(version.PackageDigest), bytes.NewReader(pkg)); err != nil {
+			abortWithError(c, err)
+			return
+		}
+	}
+
 	if err := h.store.CreateVersion(ctx, version); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish version"})
+		abortWithError(c, conflict(err, errVersionExists))
 		return
 	}
 
+	event := agentAuditEvent(models.AuditVersionPublish, agent)
+	event.TargetType = "version"
+	event.TargetID = version.ID
+	event.TargetName = agent.FullName + "@" + version.Version
+	event.After = map[string]interface{}{"version": version.Version, "digest": version.Digest, "size": version.Size}
+	if version.PackageDigest != "" {
+		event.After["package_digest"] = version.PackageDigest
+		event.After["package_size"] = version.PackageSize
+	}
+	h.recordAudit(c, event)
+
+	h.hooks.Publish(ctx, models.EventVersionPublished, agent, map[string]interface{}{"version": version})
+	metrics.VersionPublished()
+
 	c.JSON(http.StatusCreated, version)
 }
 
-// GetFile 获取文件
+// newAgentForDryRun 在自己的命名空间下 dry run 尚未创建的智能体时，返回将要创建的智能体 (ID 为空)
+// 并执行创建时的校验；智能体已存在或不是自己的命名空间时返回 nil，按已有智能体检查权限
+func (h *Handler) newAgentForDryRun(ctx context.Context, c *gin.Context, namespace, name string, spec *models.AgentSpec) (*models.Agent, error) {
+	if namespace != c.GetString("username") {
+		return nil, nil
+	}
+	if _, err := h.store.GetAgent(ctx, namespace, name); !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if !h.validateCategory(ctx, spec.Metadata.Category) {
+		return nil, validationError("category", "unknown category: "+spec.Metadata.Category)
+	}
+	return &models.Agent{Namespace: namespace, Name: name, FullName: namespace + "/" + name}, nil
+}
+
+// DeprecateVersionRequest 弃用版本请求
+type DeprecateVersionRequest struct {
+	Message string `json:"message" binding:"max=500"`
+}
+
+// DeprecateVersion 将版本标记为弃用，已弃用的版本仍可拉取
+func (h *Handler) DeprecateVersion(c *gin.Context) {
+	var req DeprecateVersionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abortWithError(c, bindError(err))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	agent, ok := h.requireAgentPermission(ctx, c, c.Param("namespace"), c.Param("name"), models.PermissionPublish)
+	if !ok {
+		return
+	}
+
+	version, err := h.store.GetVersion(ctx, agent.ID, c.Param("version"))
+	if err != nil {
+		abortWithError(c, notFound(err, errVersionNotFound))
+		return
+	}
+	if version.Status == "deprecated" {
+		c.JSON(http.StatusOK, version)
+		return
+	}
+
+	if err := h.store.UpdateVersionStatus(ctx, version.ID, "deprecated"); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	previousStatus := version.Status
+	version.Status = "deprecated"
+
+	event := agentAuditEvent(models.AuditVersionDeprecate, agent)
+	event.TargetType = "version"
+	event.TargetID = version.ID
+	event.TargetName = agent.FullName + "@" + version.Version
+	event.Before = map[string]interface{}{"status": previousStatus}
+	event.After = map[string]interface{}{"status": version.Status, "message": req.Message}
+	h.recordAudit(c, event)
+
+	h.hooks.Publish(ctx, models.EventVersionDeprecated, agent, map[string]interface{}{
+		"version": version,
+		"message": req.Message,
+	})
+
+	c.JSON(http.StatusOK, version)
+}
+
+// specFileName 版本中目前唯一的文件
+const specFileName = "agentspec.yaml"
+
+// GetFile 获取版本中的文件，version 查询参数默认为 latest
 func (h *Handler) GetFile(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "get file"})
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	versionTag := c.DefaultQuery("version", "latest")
+
+	ctx := c.Request.Context()
+
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
+		return
+	}
+
+	version, err := h.lookupVersion(ctx, agent.ID, versionTag)
+	if err != nil {
+		abortWithError(c, notFound(err, errVersionNotFound))
+		return
+	}
+	if strings.TrimPrefix(c.Param("path"), "/") != specFileName {
+		abortWithError(c, errFileNotFound)
+		return
+	}
+
+	setRedirectHeaders(c, agent, namespace, name)
+	if checkNotModified(c, versionCacheHeaders(agent, version, versionTag)) {
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", []byte(version.Spec))
 }
 
 // LikeAgent 点赞
 func (h *Handler) LikeAgent(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "liked"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "liked"})
 }
 
 // UnlikeAgent 取消点赞
 func (h *Handler) UnlikeAgent(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "unliked"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "unliked"})
 }
 
 // ===== 搜索 =====
@@ -557,151 +865,411 @@ func (h *Handler) UnlikeAgent(c *gin.Context) {
 func (h *Handler) Search(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		abortWithError(c, validationError("q", "query is required"))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	sort := storage.AgentSort(c.Query("sort"))
+	page, err := parsePage(c, sort, defaultPageSize, maxPageSize)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
 
-	agents, total, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
-		Search:   query,
-		Page:     1,
-		PageSize: 20,
+	agents, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
+		PageOptions: page.query(),
+		Search:      query,
+		Category:    c.Query("category"),
+		Sort:        sort,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "search failed"})
+		abortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"agents": agents,
-		"total":  total,
-		"query":  query,
-	})
+	writePage(c, page, agents, agentCursor(sort))
 }
 
 // ListCategories 列出分类
 func (h *Handler) ListCategories(c *gin.Context) {
-	categories := []gin.H{
-		{"id": "assistant", "name": "通用助手", "count": 0},
-		{"id": "coding", "name": "编程开发", "count": 0},
-		{"id": "writing", "name": "写作创作", "count": 0},
-		{"id": "analysis", "name": "数据分析", "count": 0},
-		{"id": "creative", "name": "创意设计", "count": 0},
-		{"id": "education", "name": "教育学习", "count": 0},
-		{"id": "business", "name": "商业办公", "count": 0},
-		{"id": "research", "name": "研究探索", "count": 0},
-		{"id": "tooling", "name": "工具效率", "count": 0},
-		{"id": "other", "name": "其他", "count": 0},
+	ctx := c.Request.Context()
+
+	categories, err := h.store.ListCategories(ctx)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	// 计数失败不影响分类列表
+	counts, _ := h.store.CategoryCounts(ctx)
+
+	lang := requestLang(c)
+	for _, category := range categories {
+		category.Name = category.LocalizedName(lang)
+		category.Count = counts[category.ID]
+	}
+	// 父分类计数包含子分类
+	byID := make(map[string]*models.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	for _, category := range categories {
+		if parent, ok := byID[category.ParentID]; ok {
+			parent.Count += counts[category.ID]
+		}
+	}
+
+	c.Header("Vary", "Accept-Language")
+	writeCachedJSON(c, singlePage(categories), cacheHeaders{CacheControl: cacheRevalidate})
+}
+
+// CreateCategoryRequest 创建分类请求
+type CreateCategoryRequest struct {
+	ID        string `json:"id" binding:"required,max=32"`
+	ParentID  string `json:"parent_id"`
+	NameZh    string `json:"name_zh" binding:"required,max=64"`
+	NameEn    string `json:"name_en" binding:"required,max=64"`
+	Icon      string `json:"icon"`
+	SortOrder int    `json:"sort_order"`
+}
+
+// CreateCategory 创建分类 (管理员)
+func (h *Handler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if _, err := h.store.GetCategory(ctx, req.ID); err == nil {
+		abortWithError(c, errConflict.withMessage("category already exists"))
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"categories": categories})
+
+	// 只支持一级子分类
+	if req.ParentID != "" {
+		parent, err := h.store.GetCategory(ctx, req.ParentID)
+		if err != nil {
+			abortWithError(c, notFound(err, validationError("parent_id", "parent category not found")))
+			return
+		}
+		if parent.ParentID != "" {
+			abortWithError(c, validationError("parent_id", "subcategories cannot be nested"))
+			return
+		}
+	}
+
+	category := &models.Category{
+		ID:        req.ID,
+		ParentID:  req.ParentID,
+		NameZh:    req.NameZh,
+		NameEn:    req.NameEn,
+		Icon:      req.Icon,
+		SortOrder: req.SortOrder,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.store.CreateCategory(ctx, category); err != nil {
+		abortWithError(c, conflict(err, errConflict.withMessage("category already exists")))
+		return
+	}
+
+	event := &models.AuditEvent{Action: models.AuditCategoryCreate, TargetType: "category", TargetID: category.ID}
+	_, event.After = auditDiff(nil, category)
+	h.recordAudit(c, event)
+
+	category.Name = category.LocalizedName(requestLang(c))
+	c.JSON(http.StatusCreated, category)
+}
+
+// validateCategory 检查分类是否存在于注册表
+func (h *Handler) validateCategory(ctx context.Context, id string) bool {
+	if id == "" {
+		return true
+	}
+	_, err := h.store.GetCategory(ctx, id)
+	return err == nil
 }
 
 // GetTrending 获取热门
 func (h *Handler) GetTrending(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agents, _, _ := h.store.ListAgents(ctx, storage.ListAgentsOptions{
-		Sort:     "downloads",
-		Page:     1,
-		PageSize: 10,
+	agents, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
+		PageOptions: storage.PageOptions{Limit: 10},
+		Sort:        storage.SortDownloads,
 	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"agents": agents})
+	writeCachedJSON(c, singlePage(agents), cacheHeaders{CacheControl: cacheRevalidate})
 }
 
 // GetFeatured 获取推荐
 func (h *Handler) GetFeatured(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agents, _, _ := h.store.ListAgents(ctx, storage.ListAgentsOptions{
-		Sort:     "likes",
-		Page:     1,
-		PageSize: 10,
+	agents, err := h.store.ListAgents(ctx, storage.ListAgentsOptions{
+		PageOptions: storage.PageOptions{Limit: 10},
+		Sort:        storage.SortLikes,
 	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"agents": agents})
+	writeCachedJSON(c, singlePage(agents), cacheHeaders{CacheControl: cacheRevalidate})
 }
 
 // ===== API Keys =====
 
 // ListAPIKeys 列出 API Keys
 func (h *Handler) ListAPIKeys(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"keys": []interface{}{}})
+	userID := c.GetString("user_id")
+
+	ctx := c.Request.Context()
+
+	keys, err := h.store.ListAPIKeys(ctx, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if keys == nil {
+		keys = []*models.APIKey{}
+	}
+
+	c.JSON(http.StatusOK, singlePage(keys))
+}
+
+// CreateAPIKeyRequest 创建 API Key 请求
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=64"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1"`
 }
 
 // CreateAPIKey 创建 API Key
 func (h *Handler) CreateAPIKey(c *gin.Context) {
-	userID := c.GetString("user_id")
-	keyID := uuid.New().String()[:8]
-	apiKey := "ak_" + userID + "_" + keyID
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !containsString(models.ValidScopes, scope) {
+			abortWithError(c, validationError("scopes", "unknown scope: "+scope))
+			return
+		}
+	}
+
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	key := &models.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    c.GetString("user_id"),
+		Name:      req.Name,
+		KeyPrefix: rawKey[:len(apiKeyPrefix)+8],
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    req.Scopes,
+		Tier:      models.TierFree,
+		CreatedAt: time.Now(),
+		IsActive:  true,
+	}
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	ctx := c.Request.Context()
 
-	c.JSON(http.StatusCreated, gin.H{
-		"key":     apiKey,
-		"message": "请保存此密钥，它不会再次显示",
+	if err := h.store.CreateAPIKey(ctx, key); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	event := &models.AuditEvent{Action: models.AuditAPIKeyCreate, TargetType: "api_key", TargetID: key.ID, TargetName: key.Name}
+	_, event.After = auditDiff(nil, key)
+	h.recordAudit(c, event)
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{
+		Key:     rawKey,
+		APIKey:  key,
+		Message: "请保存此密钥，它不会再次显示",
 	})
 }
 
+// CreateAPIKeyResponse 创建 API Key 响应，完整密钥只在此时返回一次
+type CreateAPIKeyResponse struct {
+	Key     string         `json:"key"`
+	APIKey  *models.APIKey `json:"api_key"`
+	Message string         `json:"message"`
+}
+
 // DeleteAPIKey 删除 API Key
 func (h *Handler) DeleteAPIKey(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "key deleted"})
+	ctx := c.Request.Context()
+
+	if err := h.store.DeleteAPIKey(ctx, c.GetString("user_id"), c.Param("id")); err != nil {
+		abortWithError(c, notFound(err, errKeyNotFound))
+		return
+	}
+
+	h.recordAudit(c, &models.AuditEvent{Action: models.AuditAPIKeyDelete, TargetType: "api_key", TargetID: c.Param("id")})
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "key deleted"})
+}
+
+// UpdateAPIKeyTierRequest 修改 API Key 等级请求
+type UpdateAPIKeyTierRequest struct {
+	Tier string `json:"tier" binding:"required"`
+}
+
+// UpdateAPIKeyTier 修改 API Key 的配额等级 (管理员)
+// 等级必须在 rate_limit.tiers 中配置，修改后的倍数从下一个请求开始生效
+func (h *Handler) UpdateAPIKeyTier(c *gin.Context) {
+	var req UpdateAPIKeyTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+	if _, ok := h.cfg.RateLimit.Tiers[req.Tier]; !ok {
+		abortWithError(c, validationError("tier", "unknown tier: "+req.Tier))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	key, err := h.store.UpdateAPIKeyTier(ctx, c.Param("id"), req.Tier)
+	if err != nil {
+		abortWithError(c, notFound(err, errKeyNotFound))
+		return
+	}
+
+	h.recordAudit(c, &models.AuditEvent{
+		Action:     models.AuditAPIKeyTier,
+		TargetType: "api_key",
+		TargetID:   key.ID,
+		TargetName: key.Name,
+		After:      map[string]interface{}{"tier": key.Tier, "user_id": key.UserID},
+	})
+
+	c.JSON(http.StatusOK, key)
 }
 
 // ===== Agent 调用 =====
 
 // InvokeAgent 调用智能体
+// 服务端还没有执行引擎 (模型供应商、工作流、远程运行时都未接入)，目前返回占位响应，
+// 链路中只有 agent.invoke 及其下的存储查询 span
 func (h *Handler) InvokeAgent(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
 		return
 	}
 
+	// 调用指标按智能体全名统计，只统计已找到的智能体，避免标签基数随任意路径增长
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "agent.invoke", attribute.String("agent.name", agent.FullName))
+	defer span.End()
+
 	version, err := h.store.GetLatestVersion(ctx, agent.ID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "no version available"})
+		tracing.RecordError(span, err)
+		metrics.ObserveInvocation(agent.FullName, time.Since(start), 0, 0, invocationFailure(ctx, "no_version"))
+		abortWithError(c, notFound(err, errVersionNotFound.withMessage("no version available")))
 		return
 	}
 
 	// 解析请求
 	var input map[string]interface{}
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		tracing.RecordError(span, err)
+		metrics.ObserveInvocation(agent.FullName, time.Since(start), 0, 0, "bad_request")
+		abortWithError(c, bindError(err))
 		return
 	}
 
+	span.SetAttributes(attribute.String("agent.version", version.Version))
+
+	setRedirectHeaders(c, agent, namespace, name)
+
+	// 模型供应商调用必须使用 ctx，客户端断开或超出 INVOKE_TIMEOUT 时立即停止，不再消耗 token
+	if reason := metrics.AbortReason(ctx.Err()); reason != "" {
+		tracing.RecordError(span, ctx.Err())
+		metrics.ObserveInvocation(agent.FullName, time.Since(start), 0, 0, reason)
+		abortWithError(c, ctx.Err())
+		return
+	}
+	metrics.ObserveInvocation(agent.FullName, time.Since(start), 0, 0, "")
+	logging.FromContext(ctx).Info("agent invoked",
+		"agent", agent.FullName,
+		"version", version.Version,
+		"duration", time.Since(start),
+	)
+
 	// TODO: 实际调用智能体
-	c.JSON(http.StatusOK, gin.H{
-		"agent":   agent.FullName,
-		"version": version.Version,
-		"input":   input,
-		"output":  "智能体响应将在这里",
+	c.JSON(http.StatusOK, InvokeResponse{
+		Agent:   agent.FullName,
+		Version: version.Version,
+		Input:   input,
+		Output:  "智能体响应将在这里",
 	})
 }
 
+// InvokeResponse 同步调用响应
+type InvokeResponse struct {
+	Agent   string                 `json:"agent"`
+	Version string                 `json:"version"`
+	Input   map[string]interface{} `json:"input"`
+	Output  interface{}            `json:"output"` // 结构由智能体 interface.output 定义
+}
+
 // InvokeAgentStream 流式调用智能体
 func (h *Handler) InvokeAgentStream(c *gin.Context) {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	ctx := c.Request.Context()
 
-	agent, err := h.store.GetAgent(ctx, namespace, name)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "agent not found"})
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
 		return
 	}
 
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "agent.invoke", attribute.String("agent.name", agent.FullName))
+	defer func() {
+		// 流式调用中途断开时记为 cancelled，超出时限记为 timeout
+		reason := metrics.AbortReason(ctx.Err())
+		if reason != "" {
+			tracing.RecordError(span, ctx.Err())
+		}
+		span.End()
+		metrics.ObserveInvocation(agent.FullName, time.Since(start), 0, 0, reason)
+	}()
+
+	setRedirectHeaders(c, agent, namespace, name)
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -711,16 +1279,63 @@ func (h *Handler) InvokeAgentStream(c *gin.Context) {
 	c.Writer.Flush()
 }
 
+// invocationFailure 调用失败的原因，请求已被取消或超时时以此为准
+func invocationFailure(ctx context.Context, reason string) string {
+	if aborted := metrics.AbortReason(ctx.Err()); aborted != "" {
+		return aborted
+	}
+	return reason
+}
+
+// requestLang 确定响应语言 (zh/en)
+// 优先使用 lang 查询参数，其次是 Accept-Language 请求头
+func requestLang(c *gin.Context) string {
+	lang := c.Query("lang")
+	if lang == "" {
+		lang = c.GetHeader("Accept-Language")
+	}
+	if strings.HasPrefix(strings.ToLower(lang), "en") {
+		return "en"
+	}
+	return "zh"
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UserResponse 返回给客户端的用户信息，不含密码哈希、状态等内部字段
+type UserResponse struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	DisplayName string    `json:"display_name"`
+	Avatar      string    `json:"avatar"`
+	Bio         string    `json:"bio"`
+	IsVerified  bool      `json:"is_verified"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // sanitizeUser 清理用户敏感信息
-func sanitizeUser(user *models.User) gin.H {
-	return gin.H{
-		"id":           user.ID,
-		"username":     user.Username,
-		"email":        user.Email,
-		"display_name": user.DisplayName,
-		"avatar":       user.Avatar,
-		"bio":          user.Bio,
-		"is_verified":  user.IsVerified,
-		"created_at":   user.CreatedAt,
+func sanitizeUser(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		Avatar:      user.Avatar,
+		Bio:         user.Bio,
+		IsVerified:  user.IsVerified,
+		CreatedAt:   user.CreatedAt,
 	}
 }
+
+// MessageResponse 只包含说明的响应，用于没有资源可返回的操作
+type MessageResponse struct {
+	Message string `json:"message"`
+}
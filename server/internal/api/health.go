@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/agenthub/server/internal/health"
+	"github.com/agenthub/server/internal/version"
+	"github.com/gin-gonic/gin"
+)
+
+// readyzTimeout 整个 readiness 检查的时间上限，单个依赖的超时由 Checker 控制
+const readyzTimeout = 5 * time.Second
+
+// Livez 存活检查，只要进程能处理请求就返回 200，不检查外部依赖
+func (h *Handler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{
+		Status:  health.StatusOK,
+		Version: version.Version,
+		Commit:  version.Commit,
+	})
+}
+
+// LivenessResponse 存活检查响应
+type LivenessResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+// Readyz 就绪检查，逐项返回数据库、Redis 和对象存储的状态与延迟
+// 任一依赖不可用或服务正在关闭时返回 503
+func (h *Handler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyzTimeout)
+	defer cancel()
+
+	report := h.health.Check(ctx)
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, ReadinessResponse{
+		Status:  report.Status,
+		Checks:  report.Checks,
+		Version: version.Version,
+		Commit:  version.Commit,
+		Time:    time.Now().UTC(),
+	})
+}
+
+// ReadinessResponse 就绪检查响应
+type ReadinessResponse struct {
+	Status  string                    `json:"status"` // ok, failing, draining
+	Checks  map[string]*health.Result `json:"checks"`
+	Version string                    `json:"version"`
+	Commit  string                    `json:"commit"`
+	Time    time.Time                 `json:"time"`
+}
+
+// Health 兼容旧的 /api/v1/health，等同于 /readyz
+func (h *Handler) Health(c *gin.Context) {
+	h.Readyz(c)
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// testServer 基于内存存储的完整路由，不依赖 PostgreSQL 和 Redis
+type testServer struct {
+	t      *testing.T
+	cfg    *config.Config
+	store  *memory.Store
+	router *gin.Engine
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Server.Mode = "test"
+	cfg.Storage.LocalPath = t.TempDir()
+	store := memory.New()
+	return &testServer{t: t, cfg: cfg, store: store, router: NewRouter(cfg, store, nil, nil)}
+}
+
+// request 发送请求，body 不为 nil 时编码为 JSON
+// token 以 ak_ 开头时作为 API Key 发送，否则作为 JWT
+func (s *testServer) request(method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encode request: %v", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case strings.HasPrefix(token, apiKeyPrefix):
+		req.Header.Set("X-API-Key", token)
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// register 通过接口注册用户，返回令牌和用户 ID
+func (s *testServer) register(username string) (token, userID string) {
+	s.t.Helper()
+	w := s.request(http.MethodPost, "/api/v1/auth/register", "", RegisterRequest{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "password123",
+	})
+	expectStatus(s.t, w, http.StatusCreated)
+	resp := decode[AuthResponse](s.t, w)
+	return resp.Token, resp.User.ID
+}
+
+// createAdmin 直接在存储中创建管理员，接口不能注册管理员
+func (s *testServer) createAdmin(username string) string {
+	s.t.Helper()
+	user := &models.User{
+		ID:        uuid.New().String(),
+		Username:  username,
+		Email:     username + "@example.com",
+		IsAdmin:   true,
+		Status:    "active",
+		CreatedAt: time.Now(),
+	}
+	if err := s.store.CreateUser(context.Background(), user); err != nil {
+		s.t.Fatalf("create admin: %v", err)
+	}
+	token, err := (&Handler{cfg: s.cfg}).generateToken(user)
+	if err != nil {
+		s.t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// createAgent 通过接口在当前用户的命名空间下创建智能体
+func (s *testServer) createAgent(token, name, visibility string) *models.Agent {
+	s.t.Helper()
+	w := s.request(http.MethodPost, "/api/v1/agents", token, CreateAgentRequest{
+		Name:        name,
+		Description: "test agent " + name,
+		Visibility:  visibility,
+	})
+	expectStatus(s.t, w, http.StatusCreated)
+	return decode[*models.Agent](s.t, w)
+}
+
+// addAgent 直接在存储中创建智能体，用于组织命名空间
+func (s *testServer) addAgent(namespace, name, visibility, authorID string) *models.Agent {
+	s.t.Helper()
+	agent := &models.Agent{
+		ID:          uuid.New().String(),
+		Namespace:   namespace,
+		Name:        name,
+		Description: "test agent " + name,
+		Visibility:  visibility,
+		AuthorID:    authorID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.store.CreateAgent(context.Background(), agent); err != nil {
+		s.t.Fatalf("create agent: %v", err)
+	}
+	agent.FullName = namespace + "/" + name
+	return agent
+}
+
+// testSpec 通过服务端校验的最小 spec
+func testSpec(name string) string {
+	return "version: 1.0.0\nmetadata:\n  name: " + name + "\n  description: test\nruntime:\n  type: prompt\n"
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, want, w.Body.String())
+	}
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode response: %v; body: %s", err, w.Body.String())
+	}
+	return v
+}
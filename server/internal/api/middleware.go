@@ -2,11 +2,11 @@ package api
 
 import (
 	"context"
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/models"
 	"github.com/agenthub/server/internal/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -24,16 +24,14 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
-			c.Abort()
+			abortWithError(c, errUnauthorized.withMessage("missing authorization header"))
 			return
 		}
 
 		// 提取 token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization format"})
-			c.Abort()
+			abortWithError(c, errUnauthorized.withMessage("invalid authorization format"))
 			return
 		}
 
@@ -46,68 +44,112 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			abortWithError(c, errUnauthorized.withMessage("invalid token"))
 			return
 		}
 
 		// 设置用户信息到上下文
+		// 可选认证可能已按同一请求中的 X-API-Key 设置了 API Key，JWT 认证的请求不受 API Key 的权限范围限制
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("api_key", (*models.APIKey)(nil))
 
 		c.Next()
 	}
 }
 
 // APIKeyMiddleware API Key 认证中间件
-func APIKeyMiddleware(store *storage.Storage) gin.HandlerFunc {
+func APIKeyMiddleware(store storage.KeyRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
+		apiKey := extractAPIKey(c)
 		if apiKey == "" {
-			// 也支持 Bearer 格式
-			authHeader := c.GetHeader("Authorization")
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				apiKey = strings.TrimPrefix(authHeader, "Bearer ")
-			}
-		}
-
-		if apiKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing API key"})
-			c.Abort()
+			abortWithError(c, errInvalidAPIKey.withMessage("missing API key"))
 			return
 		}
 
-		// 验证 API Key (简化实现)
-		// 实际应该查询数据库验证
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		ctx := c.Request.Context()
 
-		userID, err := validateAPIKey(ctx, store, apiKey)
+		key, err := validateAPIKey(ctx, store, apiKey)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
-			c.Abort()
+			abortWithError(c, errInvalidAPIKey)
 			return
 		}
 
-		c.Set("user_id", userID)
+		setAPIKeyContext(c, key)
 		c.Next()
 	}
 }
 
+// extractAPIKey 从请求头中提取 API Key
+// 支持 X-API-Key 和 Authorization: Bearer ak_... 两种格式
+func extractAPIKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	authHeader := c.GetHeader("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer "+apiKeyPrefix) {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return ""
+}
+
+// setAPIKeyContext 设置 API Key 认证信息到上下文
+func setAPIKeyContext(c *gin.Context, key *models.APIKey) {
+	c.Set("user_id", key.UserID)
+	c.Set("api_key", key)
+}
+
 // validateAPIKey 验证 API Key
-func validateAPIKey(ctx context.Context, store *storage.Storage, key string) (string, error) {
-	// 简化实现：API key 格式为 ak_<user_id>_<random>
-	// 实际应该查询数据库验证哈希值
-	parts := strings.Split(key, "_")
-	if len(parts) < 3 || parts[0] != "ak" {
-		return "", jwt.ErrInvalidKey
+func validateAPIKey(ctx context.Context, store storage.KeyRepository, raw string) (*models.APIKey, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, jwt.ErrInvalidKey
+	}
+
+	key, err := store.GetAPIKeyByHash(ctx, hashAPIKey(raw))
+	if err != nil {
+		return nil, err
+	}
+	if !key.IsActive || (key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now())) {
+		return nil, jwt.ErrInvalidKey
+	}
+
+	store.TouchAPIKey(ctx, key.ID)
+	return key, nil
+}
+
+// AdminMiddleware 管理员权限中间件 (需在 AuthMiddleware 之后使用)
+func AdminMiddleware(store storage.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		user, err := store.GetUserByID(ctx, c.GetString("user_id"))
+		if err != nil {
+			abortWithError(c, notFound(err, errAdminRequired))
+			return
+		}
+		if !user.IsAdmin {
+			abortWithError(c, errAdminRequired)
+			return
+		}
+
+		c.Next()
 	}
-	return parts[1], nil
 }
 
 // OptionalAuthMiddleware 可选认证中间件
-func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// 支持 JWT 和 API Key，认证失败时按匿名请求处理；挂在 /api/v1 分组上，限流和处理器据此识别调用方
+func OptionalAuthMiddleware(cfg *config.Config, store storage.KeyRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := extractAPIKey(c); apiKey != "" {
+			ctx := c.Request.Context()
+
+			if key, err := validateAPIKey(ctx, store, apiKey); err == nil {
+				setAPIKeyContext(c, key)
+			}
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.Next()
@@ -134,42 +176,3 @@ func OptionalAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		c.Next()
 	}
 }
-
-// RateLimitMiddleware 速率限制中间件
-func RateLimitMiddleware(store *storage.Storage, limit int, window time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 获取客户端标识
-		clientID := c.ClientIP()
-		if userID, exists := c.Get("user_id"); exists {
-			clientID = userID.(string)
-		}
-
-		ctx := context.Background()
-		key := "ratelimit:" + clientID
-
-		// 使用 Redis 进行速率限制
-		count, err := store.Redis().Incr(ctx, key).Result()
-		if err != nil {
-			c.Next()
-			return
-		}
-
-		if count == 1 {
-			store.Redis().Expire(ctx, key, window)
-		}
-
-		if count > int64(limit) {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "rate limit exceeded",
-				"retry_after": window.Seconds(),
-			})
-			c.Abort()
-			return
-		}
-
-		c.Header("X-RateLimit-Limit", string(rune(limit)))
-		c.Header("X-RateLimit-Remaining", string(rune(limit-int(count))))
-
-		c.Next()
-	}
-}
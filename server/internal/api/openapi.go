@@ -0,0 +1,53 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIYAML 接口文档，以 YAML 维护便于审阅，对外以 JSON 提供
+// 修改路由、请求或响应结构时需同步更新，cmd/openapi-check 会比对两者
+//
+//go:embed openapi.yaml
+var openAPIYAML []byte
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  map[string]interface{}
+	openAPIJSON []byte
+	openAPIErr  error
+)
+
+// loadOpenAPI 解析内嵌的文档，只在第一次调用时解析
+func loadOpenAPI() (map[string]interface{}, []byte, error) {
+	openAPIOnce.Do(func() {
+		if err := yaml.Unmarshal(openAPIYAML, &openAPIDoc); err != nil {
+			openAPIErr = fmt.Errorf("parse openapi.yaml: %w", err)
+			return
+		}
+		openAPIJSON, openAPIErr = json.Marshal(openAPIDoc)
+	})
+	return openAPIDoc, openAPIJSON, openAPIErr
+}
+
+// OpenAPISpec 接口文档 (JSON)
+func OpenAPISpec() ([]byte, error) {
+	_, data, err := loadOpenAPI()
+	return data, err
+}
+
+// OpenAPI 返回接口文档
+func (h *Handler) OpenAPI(c *gin.Context) {
+	data, err := OpenAPISpec()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,467 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agenthub/server/internal/health"
+	"github.com/agenthub/server/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// schemaBinding 组件 schema 对应的 Go 类型
+// request 为请求体：binding:"required" 的字段必须列入 required，校验规则需与 schema 约束一致；
+// 否则为响应体：不带 omitempty 的非指针字段总会输出，必须列入 required
+type schemaBinding struct {
+	typ     reflect.Type
+	request bool
+}
+
+func responseOf[T any]() schemaBinding {
+	return schemaBinding{typ: reflect.TypeOf((*T)(nil)).Elem()}
+}
+
+func requestOf[T any]() schemaBinding {
+	return schemaBinding{typ: reflect.TypeOf((*T)(nil)).Elem(), request: true}
+}
+
+// openAPISchemas 文档中的组件 schema 与处理器使用的类型
+// 新增请求或响应类型时在此登记，未登记的 schema 和类型都会被 VerifyOpenAPI 报告
+var openAPISchemas = map[string]schemaBinding{
+	"Problem":       responseOf[APIError](),
+	"ProblemDetail": responseOf[ErrorDetail](),
+	"Message":       responseOf[MessageResponse](),
+
+	"Liveness":    responseOf[LivenessResponse](),
+	"Readiness":   responseOf[ReadinessResponse](),
+	"HealthCheck": responseOf[health.Result](),
+
+	"RegisterRequest": requestOf[RegisterRequest](),
+	"LoginRequest":    requestOf[LoginRequest](),
+	"AuthResponse":    responseOf[AuthResponse](),
+	"User":            responseOf[UserResponse](),
+
+	"Agent":                responseOf[models.Agent](),
+	"AgentDetail":          responseOf[AgentDetailResponse](),
+	"CreateAgentRequest":   requestOf[CreateAgentRequest](),
+	"TransferAgentRequest": requestOf[TransferAgentRequest](),
+	"RenameAgentRequest":   requestOf[RenameAgentRequest](),
+	"MoveAgentResponse":    responseOf[MoveAgentResponse](),
+	"AgentPage":            responseOf[Page[*models.Agent]](),
+
+	"AgentVersion":            responseOf[models.AgentVersion](),
+	"AgentFile":               responseOf[models.AgentFile](),
+	"PublishVersionRequest":   requestOf[PublishVersionRequest](),
+	"DeprecateVersionRequest": requestOf[DeprecateVersionRequest](),
+	"VersionPage":             responseOf[Page[*models.AgentVersion]](),
+
+	"Collaborator":              responseOf[models.AgentCollaborator](),
+	"InviteCollaboratorRequest": requestOf[InviteCollaboratorRequest](),
+	"CollaboratorList":          responseOf[Page[*models.AgentCollaborator]](),
+
+	"AuditEvent":     responseOf[models.AuditEvent](),
+	"AuditEventPage": responseOf[Page[*models.AuditEvent]](),
+
+	"Webhook":              responseOf[models.Webhook](),
+	"CreateWebhookRequest": requestOf[CreateWebhookRequest](),
+	"CreatedWebhook":       responseOf[CreateWebhookResponse](),
+	"WebhookList":          responseOf[Page[*models.Webhook]](),
+	"WebhookDelivery":      responseOf[models.WebhookDelivery](),
+	"DeliveryPage":         responseOf[Page[*models.WebhookDelivery]](),
+
+	"Category":              responseOf[models.Category](),
+	"CreateCategoryRequest": requestOf[CreateCategoryRequest](),
+	"CategoryList":          responseOf[Page[*models.Category]](),
+
+	"APIKey":                  responseOf[models.APIKey](),
+	"CreateAPIKeyRequest":     requestOf[CreateAPIKeyRequest](),
+	"CreatedAPIKey":           responseOf[CreateAPIKeyResponse](),
+	"APIKeyList":              responseOf[Page[*models.APIKey]](),
+	"UpdateAPIKeyTierRequest": requestOf[UpdateAPIKeyTierRequest](),
+
+	"InvokeRequest":  requestOf[map[string]interface{}](),
+	"InvokeResponse": responseOf[InvokeResponse](),
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+
+	routeParam = regexp.MustCompile(`[:*]([A-Za-z_][A-Za-z0-9_]*)`)
+	specParam  = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// VerifyOpenAPI 比对路由和处理器类型与接口文档，返回全部不一致之处，为空表示一致
+// 检查项：路由与文档中的路径和方法一一对应，路径参数一致；$ref 均可解析；
+// 组件 schema 与 openAPISchemas 中登记的类型字段名、类型、必填字段和校验规则一致
+func VerifyOpenAPI(routes gin.RoutesInfo) ([]string, error) {
+	doc, _, err := loadOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+	v := &contractVerifier{doc: doc}
+	v.checkRoutes(routes)
+	v.checkRefs("#", doc)
+	v.checkSchemas()
+	sort.Strings(v.problems)
+	return v.problems, nil
+}
+
+type contractVerifier struct {
+	doc      map[string]interface{}
+	problems []string
+}
+
+func (v *contractVerifier) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// checkRoutes 路由与文档路径双向比对，gin 的 :param 和 *param 对应文档中的 {param}
+func (v *contractVerifier) checkRoutes(routes gin.RoutesInfo) {
+	paths := asMap(v.doc["paths"])
+	seen := make(map[string]bool)
+
+	for _, route := range routes {
+		path := routeParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		seen[method+" "+path] = true
+
+		item := asMap(paths[path])
+		op := asMap(item[method])
+		if op == nil {
+			v.addf("route %s %s is not documented", route.Method, path)
+			continue
+		}
+		v.checkPathParams(method, path, item, op)
+	}
+
+	for path, raw := range paths {
+		for method := range asMap(raw) {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options":
+			default:
+				continue
+			}
+			if !seen[method+" "+path] {
+				v.addf("documented operation %s %s has no route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+// checkPathParams 路径中的参数必须全部声明为 in: path，且不能声明路径中没有的参数
+func (v *contractVerifier) checkPathParams(method, path string, item, op map[string]interface{}) {
+	want := make(map[string]bool)
+	for _, m := range specParam.FindAllStringSubmatch(path, -1) {
+		want[m[1]] = true
+	}
+
+	declared := make(map[string]bool)
+	params := append(asSlice(item["parameters"]), asSlice(op["parameters"])...)
+	for _, raw := range params {
+		param := v.resolve(asMap(raw))
+		if param["in"] != "path" {
+			continue
+		}
+		name, _ := param["name"].(string)
+		declared[name] = true
+		if !want[name] {
+			v.addf("%s %s declares path parameter %q that is not in the path", strings.ToUpper(method), path, name)
+		}
+	}
+	for name := range want {
+		if !declared[name] {
+			v.addf("%s %s does not declare path parameter %q", strings.ToUpper(method), path, name)
+		}
+	}
+}
+
+// checkRefs 文档中所有 $ref 必须指向存在的组件
+func (v *contractVerifier) checkRefs(at string, node interface{}) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := n["$ref"].(string); ok && v.lookup(ref) == nil {
+			v.addf("%s: unresolved $ref %s", at, ref)
+		}
+		for key, child := range n {
+			v.checkRefs(at+"/"+pointerEscaper.Replace(key), child)
+		}
+	case []interface{}:
+		for i, child := range n {
+			v.checkRefs(at+"/"+strconv.Itoa(i), child)
+		}
+	}
+}
+
+// checkSchemas 组件 schema 与登记的 Go 类型双向比对
+func (v *contractVerifier) checkSchemas() {
+	schemas := asMap(asMap(v.doc["components"])["schemas"])
+	for name := range schemas {
+		if _, ok := openAPISchemas[name]; !ok {
+			v.addf("schema %s is not bound to a Go type", name)
+		}
+	}
+
+	for name, binding := range openAPISchemas {
+		schema := asMap(schemas[name])
+		if schema == nil {
+			v.addf("schema %s (%s) is missing from the document", name, binding.typ)
+			continue
+		}
+		if binding.typ.Kind() == reflect.Map {
+			if schema["type"] != "object" || schema["additionalProperties"] == nil {
+				v.addf("schema %s: %s must be an object with additionalProperties", name, binding.typ)
+			}
+			continue
+		}
+		v.checkStruct(name, schema, binding)
+	}
+}
+
+// checkStruct 比对字段名、字段类型、必填字段和校验规则
+func (v *contractVerifier) checkStruct(name string, schema map[string]interface{}, binding schemaBinding) {
+	props := asMap(schema["properties"])
+	required := make(map[string]bool)
+	for _, r := range asSlice(schema["required"]) {
+		if s, ok := r.(string); ok {
+			required[s] = true
+		}
+	}
+
+	fields := jsonFields(binding.typ)
+	for _, f := range fields {
+		at := name + "." + f.name
+		prop := asMap(props[f.name])
+		if prop == nil {
+			v.addf("%s: field %s.%s is not documented", at, binding.typ, f.field.Name)
+			continue
+		}
+		v.checkType(at, prop, f.field.Type)
+
+		var wantRequired bool
+		if binding.request {
+			wantRequired = f.rules["required"] != nil
+			v.checkRules(at, prop, f)
+		} else {
+			wantRequired = !f.omitempty && f.field.Type.Kind() != reflect.Ptr
+		}
+		if wantRequired != required[f.name] {
+			v.addf("%s: required should be %t", at, wantRequired)
+		}
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.name] = true
+	}
+	for prop := range props {
+		if !known[prop] {
+			v.addf("%s.%s: documented property has no field in %s", name, prop, binding.typ)
+		}
+	}
+	for r := range required {
+		if !known[r] {
+			v.addf("%s: required property %s has no field in %s", name, r, binding.typ)
+		}
+	}
+}
+
+// checkType 比对字段类型，$ref 必须指向该类型登记的 schema
+func (v *contractVerifier) checkType(at string, prop map[string]interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if ref, ok := prop["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if binding, ok := openAPISchemas[name]; !ok || derefType(binding.typ) != t {
+			v.addf("%s: $ref %s does not match %s", at, ref, t)
+		}
+		return
+	}
+
+	typ, format := schemaType(t)
+	if prop["type"] != nil && prop["type"] != typ || prop["type"] == nil && typ != "" {
+		v.addf("%s: type %v does not match %s (want %q)", at, prop["type"], t, typ)
+		return
+	}
+	if format == "date-time" && prop["format"] != format {
+		v.addf("%s: %s must have format date-time", at, t)
+	}
+
+	switch typ {
+	case "array":
+		if items := asMap(prop["items"]); items != nil {
+			v.checkType(at+"[]", items, t.Elem())
+		} else {
+			v.addf("%s: array without items", at)
+		}
+	case "object":
+		if t.Kind() == reflect.Map {
+			if elem := asMap(prop["additionalProperties"]); elem != nil {
+				v.checkType(at+"{}", elem, t.Elem())
+			} else if prop["additionalProperties"] != true {
+				v.addf("%s: map without additionalProperties", at)
+			}
+		}
+	}
+}
+
+// checkRules 请求字段的 binding 校验规则需与 schema 约束一致
+func (v *contractVerifier) checkRules(at string, prop map[string]interface{}, f jsonField) {
+	if params, ok := f.rules["oneof"]; ok {
+		var enum []string
+		for _, e := range asSlice(prop["enum"]) {
+			enum = append(enum, fmt.Sprint(e))
+		}
+		if strings.Join(enum, " ") != strings.Join(params, " ") {
+			v.addf("%s: enum %v does not match oneof=%s", at, enum, strings.Join(params, " "))
+		}
+	}
+	if _, ok := f.rules["email"]; ok && prop["format"] != "email" {
+		v.addf("%s: binding email requires format: email", at)
+	}
+
+	minKey, maxKey := "minimum", "maximum"
+	switch derefType(f.field.Type).Kind() {
+	case reflect.String:
+		minKey, maxKey = "minLength", "maxLength"
+	case reflect.Slice, reflect.Array:
+		minKey, maxKey = "minItems", "maxItems"
+	}
+	for rule, key := range map[string]string{"min": minKey, "max": maxKey} {
+		want := ""
+		if params := f.rules[rule]; len(params) > 0 {
+			want = params[0]
+		}
+		got := ""
+		if prop[key] != nil {
+			got = fmt.Sprint(prop[key])
+		}
+		if want != got {
+			v.addf("%s: %s %q does not match binding %s=%s", at, key, got, rule, want)
+		}
+	}
+}
+
+// resolve 展开组件引用，无法解析时返回原节点
+func (v *contractVerifier) resolve(node map[string]interface{}) map[string]interface{} {
+	if ref, ok := node["$ref"].(string); ok {
+		if target := asMap(v.lookup(ref)); target != nil {
+			return target
+		}
+	}
+	return node
+}
+
+// lookup 按 JSON Pointer 查找文档内的节点
+func (v *contractVerifier) lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var node interface{} = v.doc
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = pointerUnescaper.Replace(part)
+		m := asMap(node)
+		if m == nil {
+			return nil
+		}
+		node = m[part]
+	}
+	return node
+}
+
+// jsonField 结构体中会序列化的字段
+type jsonField struct {
+	name      string
+	field     reflect.StructField
+	omitempty bool
+	rules     map[string][]string // binding 校验规则及参数
+}
+
+// jsonFields 按 encoding/json 的规则列出字段，展开匿名嵌入的结构体
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" || !sf.IsExported() && !sf.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" && derefType(sf.Type).Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(derefType(sf.Type))...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := jsonField{name: name, field: sf, rules: make(map[string][]string)}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "omitempty" {
+				f.omitempty = true
+			}
+		}
+		if binding := sf.Tag.Get("binding"); binding != "" {
+			for _, rule := range strings.Split(binding, ",") {
+				key, param, _ := strings.Cut(rule, "=")
+				f.rules[key] = strings.Fields(param)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// schemaType Go 类型对应的 JSON Schema 类型，空字符串表示任意值
+func schemaType(t reflect.Type) (typ, format string) {
+	switch {
+	case t == timeType:
+		return "string", "date-time"
+	case t == rawType:
+		return "", ""
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string", ""
+	case reflect.Bool:
+		return "boolean", ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer", ""
+	case reflect.Float32, reflect.Float64:
+		return "number", ""
+	case reflect.Slice, reflect.Array:
+		return "array", ""
+	case reflect.Map, reflect.Struct:
+		return "object", ""
+	}
+	return "", ""
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/agenthub/server/internal/config"
+)
+
+// TestOpenAPIContract 路由和处理器类型与 openapi.yaml 不一致时失败
+func TestOpenAPIContract(t *testing.T) {
+	routes := NewRouter(config.Default(), nil, nil, nil).Routes()
+
+	problems, err := VerifyOpenAPI(routes)
+	if err != nil {
+		t.Fatalf("VerifyOpenAPI: %v", err)
+	}
+	for _, p := range problems {
+		t.Error(p)
+	}
+}
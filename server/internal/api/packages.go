@@ -0,0 +1,216 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/agenthub/server/internal/blobstore"
+	"github.com/agenthub/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+// 项目包限制，与 CLI 解压时的限制一致
+const (
+	maxPackageSize     = 50 << 20  // 压缩后的字节数
+	maxPackageUnpacked = 100 << 20 // 解压后的总字节数
+	maxPackageFiles    = 1000      // 文件和目录总数
+
+	// publishFormOverhead multipart 请求中包以外的部分 (spec、changelog 和分隔符) 的上限
+	publishFormOverhead = 1 << 20
+)
+
+// packageKey 项目包在对象存储中的位置，按内容摘要命名
+func packageKey(digest string) string {
+	return "packages/sha256/" + digest + ".tar.gz"
+}
+
+// bindPublishRequest 解析发布请求
+// multipart/form-data 时 version、spec、changelog 为表单字段，package 字段可以附带项目包
+func bindPublishRequest(c *gin.Context, req *PublishVersionRequest) ([]byte, error) {
+	if c.ContentType() != binding.MIMEMultipartPOSTForm {
+		if err := c.ShouldBindJSON(req); err != nil {
+			return nil, bindError(err)
+		}
+		return nil, nil
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPackageSize+publishFormOverhead)
+	if err := c.Request.ParseMultipartForm(maxPackageSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errPackageTooLarge
+		}
+		return nil, errInvalidBody
+	}
+	if err := c.ShouldBindWith(req, binding.FormMultipart); err != nil {
+		return nil, bindError(err)
+	}
+
+	header, err := c.FormFile("package")
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errInvalidBody
+	}
+	if header.Size > maxPackageSize {
+		return nil, errPackageTooLarge
+	}
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, maxPackageSize))
+}
+
+// inspectPackage 校验项目包并列出其中的文件
+// 只接受相对路径的普通文件和目录，包中的 agentspec.yaml 必须与发布的 spec 一致
+func inspectPackage(data []byte, spec string) ([]*models.AgentFile, error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errInvalidPackage.withMessage("package is not a gzip archive")
+	}
+	defer gzr.Close()
+
+	var files []*models.AgentFile
+	var total int64
+	entries := 0
+	seen := make(map[string]bool)
+	now := time.Now()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errInvalidPackage.withMessage("malformed tar archive: %v", err)
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+
+		name := path.Clean(strings.ReplaceAll(header.Name, "\\", "/"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, errInvalidPackage.withMessage("path %q escapes the package root", header.Name)
+		}
+
+		entries++
+		if entries > maxPackageFiles {
+			return nil, errInvalidPackage.withMessage("package has more than %d entries", maxPackageFiles)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, errInvalidPackage.withMessage("%q is not a regular file or directory", name)
+		}
+
+		if seen[name] {
+			return nil, errInvalidPackage.withMessage("duplicate entry %q", name)
+		}
+		seen[name] = true
+
+		if header.Size < 0 || total+header.Size > maxPackageUnpacked {
+			return nil, errInvalidPackage.withMessage("unpacked size exceeds %d bytes", maxPackageUnpacked)
+		}
+		total += header.Size
+
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return nil, errInvalidPackage.withMessage("malformed tar archive: %v", err)
+		}
+
+		mimeType := mime.TypeByExtension(path.Ext(name))
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		files = append(files, &models.AgentFile{
+			ID:        uuid.New().String(),
+			Path:      name,
+			Size:      header.Size,
+			Digest:    hex.EncodeToString(h.Sum(nil)),
+			MimeType:  mimeType,
+			CreatedAt: now,
+		})
+	}
+
+	specDigest := contentDigest([]byte(spec))
+	for _, f := range files {
+		if f.Path == specFileName {
+			if f.Digest != specDigest {
+				return nil, errInvalidPackage.withMessage("%s in package does not match spec", specFileName)
+			}
+			return files, nil
+		}
+	}
+	return nil, errInvalidPackage.withMessage("package must contain %s", specFileName)
+}
+
+// GetPackage 下载版本的项目包，ETag 为包的摘要
+func (h *Handler) GetPackage(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	versionTag := c.Param("version")
+
+	ctx := c.Request.Context()
+
+	agent, ok := h.getReadableAgent(ctx, c, namespace, name)
+	if !ok {
+		abortWithError(c, errAgentNotFound)
+		return
+	}
+
+	version, err := h.lookupVersion(ctx, agent.ID, versionTag)
+	if err != nil {
+		abortWithError(c, notFound(err, errVersionNotFound))
+		return
+	}
+	if version.PackageDigest == "" {
+		abortWithError(c, errPackageNotFound)
+		return
+	}
+
+	setRedirectHeaders(c, agent, namespace, name)
+	cache := versionCacheHeaders(agent, version, versionTag)
+	cache.ETag = version.PackageDigest
+	if checkNotModified(c, cache) {
+		return
+	}
+
+	if h.blobs == nil {
+		abortWithError(c, errPackagesUnavailable)
+		return
+	}
+	r, err := h.blobs.Open(ctx, packageKey(version.PackageDigest))
+	if errors.Is(err, blobstore.ErrNotFound) {
+		abortWithError(c, errPackageNotFound)
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	defer r.Close()
+
+	c.DataFromReader(http.StatusOK, version.PackageSize, "application/gzip", r, nil)
+}
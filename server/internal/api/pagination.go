@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/agenthub/server/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// 列表默认和最大条数
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Page 列表接口的统一响应
+// next_cursor 不为空表示还有下一页，原样作为 cursor 参数传回即可，下一页地址同时在 Link 头 (rel="next") 中给出。
+// 不提供总数：按条件计数需要扫描全部匹配的记录，代价随数据量增长
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// pageRequest 解析后的分页参数
+type pageRequest struct {
+	limit int
+	after *storage.Cursor
+}
+
+// query 存储层查询参数，多取一条用于判断是否还有下一页
+func (p pageRequest) query() storage.PageOptions {
+	return storage.PageOptions{Limit: p.limit + 1, After: p.after}
+}
+
+// parsePage 解析 limit 和 cursor 参数
+// limit 超出范围时截断到 [1, maxLimit]，page_size 是 limit 的旧名称；
+// cursor 只能用于生成它的排序方式，sort 为当前列表的排序方式
+func parsePage(c *gin.Context, sort string, defaultLimit, maxLimit int) (pageRequest, error) {
+	p := pageRequest{limit: defaultLimit}
+
+	// 不再支持偏移分页，旧客户端继续翻页会一直拿到第一页，直接报错
+	if page := c.Query("page"); page != "" && page != "1" {
+		return p, validationError("page", "page is no longer supported, follow next_cursor instead")
+	}
+
+	raw := c.Query("limit")
+	if raw == "" {
+		raw = c.Query("page_size")
+	}
+	if raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return p, validationError("limit", "limit must be an integer")
+		}
+		switch {
+		case limit <= 0:
+			limit = defaultLimit
+		case limit > maxLimit:
+			limit = maxLimit
+		}
+		p.limit = limit
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := decodeCursor(raw)
+		if err != nil || cursor.Sort != sort {
+			return p, validationError("cursor", "invalid cursor")
+		}
+		p.after = cursor
+	}
+	return p, nil
+}
+
+// newPage 将多取一条的查询结果截断为一页，有下一页时生成游标并设置 Link 头
+func newPage[T any](c *gin.Context, p pageRequest, items []T, cursorOf func(T) storage.Cursor) Page[T] {
+	page := Page[T]{Items: items}
+	if len(items) > p.limit {
+		page.Items = items[:p.limit]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(cursorOf(page.Items[p.limit-1]))
+		setNextLink(c, page.NextCursor)
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// writePage 输出一页列表，ETag 为响应体的哈希
+// 列表不提供 Last-Modified：删除或隐藏条目不会留下时间戳，只能按内容判断是否变化
+func writePage[T any](c *gin.Context, p pageRequest, items []T, cursorOf func(T) storage.Cursor) {
+	writeCachedJSON(c, newPage(c, p, items, cursorOf), cacheHeaders{CacheControl: cacheRevalidate})
+}
+
+// singlePage 不分页的列表 (数量有上限，例如 API Key、分类)，响应结构与分页列表相同
+func singlePage[T any](items []T) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items}
+}
+
+// setNextLink 设置下一页的 Link 头 (RFC 8288)，保留其余查询参数
+func setNextLink(c *gin.Context, cursor string) {
+	q := c.Request.URL.Query()
+	q.Set("cursor", cursor)
+	q.Del("page")
+	next := url.URL{Path: c.Request.URL.Path, RawQuery: q.Encode()}
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
+
+// encodeCursor 游标对客户端不透明，编码方式可以随时调整
+func encodeCursor(cursor storage.Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (*storage.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	cursor := &storage.Cursor{}
+	if err := json.Unmarshal(data, cursor); err != nil {
+		return nil, err
+	}
+	if err := cursor.Validate(); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
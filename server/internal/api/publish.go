@@ -0,0 +1,43 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// runtimeTypes spec 支持的运行时类型
+var runtimeTypes = []string{"prompt", "python", "nodejs", "docker", "remote"}
+
+// validateSpec 检查发布所需的 spec 字段，返回全部不符合的字段
+func validateSpec(spec *models.AgentSpec) []ErrorDetail {
+	var details []ErrorDetail
+
+	name := spec.Metadata.Name
+	switch {
+	case name == "":
+		details = append(details, ErrorDetail{Field: "metadata.name", Rule: "required", Message: "metadata.name is required"})
+	case len(name) < 3 || len(name) > 64 || !agentNamePattern.MatchString(name):
+		details = append(details, ErrorDetail{
+			Field:   "metadata.name",
+			Rule:    "pattern",
+			Param:   agentNamePattern.String(),
+			Message: "metadata.name must be 3-64 lowercase letters, digits or hyphens",
+		})
+	}
+
+	runtime := spec.Runtime.Type
+	switch {
+	case runtime == "":
+		details = append(details, ErrorDetail{Field: "runtime.type", Rule: "required", Message: "runtime.type is required"})
+	case !containsString(runtimeTypes, runtime):
+		details = append(details, ErrorDetail{
+			Field:   "runtime.type",
+			Rule:    "oneof",
+			Param:   strings.Join(runtimeTypes, " "),
+			Message: "runtime.type must be one of: " + strings.Join(runtimeTypes, " "),
+		})
+	}
+
+	return details
+}
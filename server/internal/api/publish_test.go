@@ -0,0 +1,208 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// tarEntry 测试用的包内条目，link 不为空时写入符号链接
+type tarEntry struct {
+	name string
+	body string
+	link string
+}
+
+func buildPackage(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.link != "" {
+			header = &tar.Header{Name: e.name, Mode: 0o777, Linkname: e.link, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// publishMultipart 以 multipart/form-data 发布版本并附带项目包
+func (s *testServer) publishMultipart(token, fullName, version, spec string, pkg []byte) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("version", version)
+	mw.WriteField("spec", spec)
+	part, err := mw.CreateFormFile("package", "package.tar.gz")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	part.Write(pkg)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/"+fullName+"/versions", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPublishVersion(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	s.createAgent(alice, "helper", "")
+
+	publish := func(version, spec string) *httptest.ResponseRecorder {
+		return s.request(http.MethodPost, "/api/v1/agents/alice/helper/versions", alice, PublishVersionRequest{Version: version, Spec: spec})
+	}
+
+	w := publish("1.0.0", testSpec("helper"))
+	expectStatus(t, w, http.StatusCreated)
+	if v := decode[*models.AgentVersion](t, w); v.Version != "1.0.0" || v.Digest != contentDigest([]byte(testSpec("helper"))) {
+		t.Errorf("unexpected version %+v", v)
+	}
+
+	expectStatus(t, publish("1.0.0", testSpec("helper")), http.StatusConflict)
+	expectStatus(t, publish("one", testSpec("helper")), http.StatusBadRequest)
+	expectStatus(t, publish("1.0.1", "version: 1.0.1\nruntime:\n  type: prompt\n"), http.StatusBadRequest)
+	expectStatus(t, publish("1.0.1", "metadata: ["), http.StatusBadRequest)
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper/versions/1.0.0", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	cacheControl := w.Header().Get("Cache-Control")
+	if !strings.Contains(cacheControl, "max-age=300") || strings.Contains(cacheControl, "immutable") {
+		t.Errorf("concrete version Cache-Control = %q", cacheControl)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper/versions/latest", "", nil)
+	expectStatus(t, w, http.StatusOK)
+	if cacheControl := w.Header().Get("Cache-Control"); strings.Contains(cacheControl, "max-age=300") {
+		t.Errorf("latest Cache-Control = %q", cacheControl)
+	}
+}
+
+func TestPublishDryRun(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	s.createAgent(alice, "helper", "")
+
+	w := s.request(http.MethodPost, "/api/v1/agents/alice/helper/versions?dry_run=true", alice,
+		PublishVersionRequest{Version: "1.0.0", Spec: testSpec("helper")})
+	expectStatus(t, w, http.StatusOK)
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper/versions/1.0.0", "", nil)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = s.request(http.MethodPost, "/api/v1/agents/alice/helper/versions", alice,
+		PublishVersionRequest{Version: "1.0.0", Spec: testSpec("helper")})
+	expectStatus(t, w, http.StatusCreated)
+
+	w = s.request(http.MethodPost, "/api/v1/agents/alice/helper/versions?dry_run=true", alice,
+		PublishVersionRequest{Version: "1.0.0", Spec: testSpec("helper")})
+	expectStatus(t, w, http.StatusConflict)
+}
+
+func TestPublishDryRunNewAgent(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	bob, _ := s.register("bob")
+
+	dryRun := func(token, fullName, spec string) *httptest.ResponseRecorder {
+		return s.request(http.MethodPost, "/api/v1/agents/"+fullName+"/versions?dry_run=true", token,
+			PublishVersionRequest{Version: "1.0.0", Spec: spec})
+	}
+
+	w := dryRun(alice, "alice/helper", testSpec("helper"))
+	expectStatus(t, w, http.StatusOK)
+	if v := decode[*models.AgentVersion](t, w); v.AgentID != "" || v.Version != "1.0.0" {
+		t.Errorf("unexpected version %+v", v)
+	}
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper", alice, nil)
+	expectStatus(t, w, http.StatusNotFound)
+
+	// 创建智能体时的校验同样执行
+	expectStatus(t, dryRun(alice, "alice/helper", "version: 1.0.0\nmetadata:\n  name: helper\n  description: test\n  category: no-such-category\nruntime:\n  type: prompt\n"), http.StatusBadRequest)
+	expectStatus(t, dryRun(alice, "alice/helper", "version: 1.0.0\n"), http.StatusBadRequest)
+
+	// 其他命名空间下不存在的智能体不能 dry run，也不能发布
+	expectStatus(t, dryRun(bob, "alice/helper", testSpec("helper")), http.StatusNotFound)
+	w = s.request(http.MethodPost, "/api/v1/agents/alice/helper/versions", alice,
+		PublishVersionRequest{Version: "1.0.0", Spec: testSpec("helper")})
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestPublishPackage(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	s.createAgent(alice, "helper", models.VisibilityPrivate)
+
+	spec := testSpec("helper")
+	pkg := buildPackage(t,
+		tarEntry{name: specFileName, body: spec},
+		tarEntry{name: "prompts/system.md", body: "You are helpful."},
+	)
+	w := s.publishMultipart(alice, "alice/helper", "1.0.0", spec, pkg)
+	expectStatus(t, w, http.StatusCreated)
+	version := decode[*models.AgentVersion](t, w)
+	if version.PackageDigest != contentDigest(pkg) || len(version.Files) != 2 {
+		t.Fatalf("unexpected version %+v", version)
+	}
+
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper/versions/1.0.0/package", alice, nil)
+	expectStatus(t, w, http.StatusOK)
+	if !bytes.Equal(w.Body.Bytes(), pkg) {
+		t.Error("downloaded package differs from the uploaded one")
+	}
+
+	// 私有智能体的项目包对其他用户不可见
+	w = s.request(http.MethodGet, "/api/v1/agents/alice/helper/versions/1.0.0/package", "", nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestInspectPackageRejectsUnsafeEntries(t *testing.T) {
+	spec := testSpec("helper")
+	tests := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{"parent traversal", []tarEntry{{name: specFileName, body: spec}, {name: "../evil", body: "x"}}},
+		{"nested traversal", []tarEntry{{name: specFileName, body: spec}, {name: "a/../../evil", body: "x"}}},
+		{"absolute path", []tarEntry{{name: specFileName, body: spec}, {name: "/etc/passwd", body: "x"}}},
+		{"symlink", []tarEntry{{name: specFileName, body: spec}, {name: "link", link: "/etc/passwd"}}},
+		{"duplicate entry", []tarEntry{{name: specFileName, body: spec}, {name: "./" + specFileName, body: spec}}},
+		{"spec mismatch", []tarEntry{{name: specFileName, body: "other"}}},
+		{"missing spec", []tarEntry{{name: "README.md", body: "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := inspectPackage(buildPackage(t, tt.entries...), spec); err == nil {
+				t.Error("package was accepted")
+			}
+		})
+	}
+
+	if _, err := inspectPackage([]byte("not gzip"), spec); err == nil {
+		t.Error("non-gzip package was accepted")
+	}
+}
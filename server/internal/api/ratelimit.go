@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/logging"
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicy 路由组限流策略
+type RateLimitPolicy struct {
+	Name     string // 计数 key 前缀，例如 auth、search、invoke
+	Rule     config.RateLimitRule
+	FailOpen bool // 限流存储不可用时放行；认证接口应拒绝请求
+}
+
+// rateLimitRemainingKey 上下文中记录的最小剩余次数，多个策略叠加时响应头反映最严格的一个
+const rateLimitRemainingKey = "ratelimit_remaining"
+
+// RateLimitMiddleware 滑动窗口速率限制中间件
+// 挂在认证中间件 (包括可选认证) 之后按 API Key 或用户计数，API Key 的配额按等级倍数放大；匿名请求按客户端 IP 计数
+func RateLimitMiddleware(cfg *config.Config, limiter storage.RateLimiter, policy RateLimitPolicy) gin.HandlerFunc {
+	if !cfg.RateLimit.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		subject, limit := rateLimitSubject(c, cfg, policy.Rule.Requests)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
+		defer cancel()
+
+		result, err := limiter.Allow(ctx, policy.Name+":"+subject, limit, policy.Rule.Window)
+		if err != nil {
+			if policy.FailOpen {
+				logging.FromContext(ctx).Warn("rate limiter unavailable, allowing request", "policy", policy.Name, "error", err)
+				c.Next()
+				return
+			}
+			abortWithError(c, errRateLimiterDown)
+			return
+		}
+
+		setRateLimitHeaders(c, policy.Rule.Window, result)
+		if !result.Allowed {
+			abortTooManyRequests(c, errRateLimited, result)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitSubject 返回计数对象和该对象的配额
+func rateLimitSubject(c *gin.Context, cfg *config.Config, limit int) (string, int) {
+	if key := currentAPIKey(c); key != nil {
+		factor := cfg.RateLimit.Tiers[key.Tier]
+		if factor <= 0 {
+			factor = 1
+		}
+		return "key:" + key.ID, limit * factor
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		return "user:" + userID, limit
+	}
+	return "ip:" + c.ClientIP(), limit
+}
+
+// setRateLimitHeaders 写入 RateLimit-* 响应头 (IETF draft-ietf-httpapi-ratelimit-headers)
+// 已有更严格的策略写入过时保留原值，被拒绝的请求总是以拒绝它的策略为准
+func setRateLimitHeaders(c *gin.Context, window time.Duration, result *storage.RateLimitResult) {
+	if prev, ok := c.Get(rateLimitRemainingKey); ok && result.Allowed && prev.(int) <= result.Remaining {
+		return
+	}
+	c.Set(rateLimitRemainingKey, result.Remaining)
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(secondsUntil(result.ResetAt)))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.Limit, int(window.Seconds())))
+}
+
+// abortTooManyRequests 返回 429 和 Retry-After
+func abortTooManyRequests(c *gin.Context, apiErr *APIError, result *storage.RateLimitResult) {
+	retryAfter := secondsUntil(result.ResetAt)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	resp := *apiErr
+	resp.RetryAfter = retryAfter
+	abortWithError(c, &resp)
+}
+
+// secondsUntil 距 t 的秒数，向上取整且至少为 1
+func secondsUntil(t time.Time) int {
+	seconds := int(math.Ceil(time.Until(t).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// ===== 登录防暴力破解 =====
+
+// loginThrottle 登录尝试计数，同时按账号和 IP 限制；每次尝试先计数，成功后撤销
+// 账号存在时按用户 ID 计数，用户名和邮箱登录共享同一计数；账号不存在时按登录名计数，响应与存在时一致
+type loginThrottle struct {
+	limiter storage.RateLimiter
+	cfg     config.LoginLimitConfig
+	account string
+	ip      string
+}
+
+func (h *Handler) newLoginThrottle(c *gin.Context, login string, user *models.User) *loginThrottle {
+	account := "name:" + strings.ToLower(strings.TrimSpace(login))
+	if user != nil {
+		account = "user:" + user.ID
+	}
+	return &loginThrottle{
+		limiter: h.store,
+		cfg:     h.cfg.RateLimit.Login,
+		account: "login:account:" + account,
+		ip:      "login:ip:" + c.ClientIP(),
+	}
+}
+
+// check 在校验密码之前为账号和 IP 各计一次尝试，返回 nil 表示允许尝试
+// 计数和判断在限流器中原子完成，并发的猜测请求不会都在记录失败之前通过检查；
+// 计数不随请求取消，否则客户端在发出请求后立即断开即可绕过锁定
+func (t *loginThrottle) check(ctx context.Context) (*storage.RateLimitResult, error) {
+	ctx, cancel := detachedContext(ctx, time.Second)
+	defer cancel()
+
+	var counted []string
+	for _, rule := range t.rules() {
+		result, err := t.limiter.Allow(ctx, rule.key, rule.limit, t.cfg.Window)
+		if err != nil {
+			t.refund(ctx, counted)
+			return nil, err
+		}
+		if !result.Allowed {
+			// 被拒绝的尝试不进行校验，已计入的其他计数撤销
+			t.refund(ctx, counted)
+			return result, nil
+		}
+		counted = append(counted, rule.key)
+	}
+	return nil, nil
+}
+
+// succeed 登录成功后清除账号计数，并撤销本次计入 IP 的尝试，IP 之前的失败仍然保留
+func (t *loginThrottle) succeed(ctx context.Context) {
+	ctx, cancel := detachedContext(ctx, time.Second)
+	defer cancel()
+
+	if t.cfg.MaxFailures > 0 {
+		if err := t.limiter.Reset(ctx, t.account); err != nil {
+			logging.FromContext(ctx).Warn("failed to reset login failures", "error", err)
+		}
+	}
+	if t.cfg.MaxFailuresPerIP > 0 {
+		t.refund(ctx, []string{t.ip})
+	}
+}
+
+// refund 撤销已计入的尝试
+func (t *loginThrottle) refund(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := t.limiter.Refund(ctx, key); err != nil {
+			logging.FromContext(ctx).Warn("failed to refund login attempt", "error", err)
+		}
+	}
+}
+
+type loginRule struct {
+	key   string
+	limit int
+}
+
+// rules 返回启用的计数规则，上限为 0 表示不限制
+func (t *loginThrottle) rules() []loginRule {
+	var rules []loginRule
+	if t.cfg.MaxFailures > 0 {
+		rules = append(rules, loginRule{t.account, t.cfg.MaxFailures})
+	}
+	if t.cfg.MaxFailuresPerIP > 0 {
+		rules = append(rules, loginRule{t.ip, t.cfg.MaxFailuresPerIP})
+	}
+	return rules
+}
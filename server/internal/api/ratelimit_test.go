@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+func TestAPIKeyTierRateLimit(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+	admin := s.createAdmin("root")
+
+	w := s.request(http.MethodPost, "/api/v1/keys", alice, CreateAPIKeyRequest{Name: "ci"})
+	expectStatus(t, w, http.StatusCreated)
+	created := decode[CreateAPIKeyResponse](t, w)
+
+	// 全局限流按 API Key 及其等级计数
+	limitOf := func() int {
+		t.Helper()
+		w := s.request(http.MethodGet, "/api/v1/categories", created.Key, nil)
+		expectStatus(t, w, http.StatusOK)
+		limit, _ := strconv.Atoi(w.Header().Get("RateLimit-Limit"))
+		return limit
+	}
+	if got, want := limitOf(), s.cfg.RateLimit.Requests; got != want {
+		t.Errorf("free tier limit = %d, want %d", got, want)
+	}
+
+	path := "/api/v1/admin/keys/" + created.APIKey.ID + "/tier"
+	w = s.request(http.MethodPut, path, alice, UpdateAPIKeyTierRequest{Tier: "pro"})
+	expectStatus(t, w, http.StatusForbidden)
+	w = s.request(http.MethodPut, path, admin, UpdateAPIKeyTierRequest{Tier: "platinum"})
+	expectStatus(t, w, http.StatusBadRequest)
+	w = s.request(http.MethodPut, "/api/v1/admin/keys/missing/tier", admin, UpdateAPIKeyTierRequest{Tier: "pro"})
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = s.request(http.MethodPut, path, admin, UpdateAPIKeyTierRequest{Tier: "pro"})
+	expectStatus(t, w, http.StatusOK)
+	if key := decode[*models.APIKey](t, w); key.Tier != "pro" {
+		t.Errorf("tier = %q, want pro", key.Tier)
+	}
+
+	if got, want := limitOf(), s.cfg.RateLimit.Requests*s.cfg.RateLimit.Tiers["pro"]; got != want {
+		t.Errorf("pro tier limit = %d, want %d", got, want)
+	}
+}
+
+func TestRateLimitCountsUsersSeparately(t *testing.T) {
+	s := newTestServer(t)
+	s.cfg.RateLimit.Requests = 2
+	s.router = NewRouter(s.cfg, s.store, nil, nil)
+	alice, _ := s.register("alice")
+	bob, _ := s.register("bob")
+
+	for i := 0; i < 2; i++ {
+		expectStatus(t, s.request(http.MethodGet, "/api/v1/categories", alice, nil), http.StatusOK)
+	}
+	w := s.request(http.MethodGet, "/api/v1/categories", alice, nil)
+	expectStatus(t, w, http.StatusTooManyRequests)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("rate limited response has no Retry-After")
+	}
+
+	// 同一 IP 的其他已登录用户单独计数
+	expectStatus(t, s.request(http.MethodGet, "/api/v1/categories", bob, nil), http.StatusOK)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/agenthub/server/internal/logging"
+	"github.com/agenthub/server/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 请求 ID 请求头，客户端传入的值会原样回传，否则由服务端生成
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern 接受的客户端请求 ID，拒绝过长或含特殊字符的值以免污染日志
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// sensitiveHeaders 日志中需要隐藏的请求头
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"X-Api-Key":     true,
+	"Cookie":        true,
+}
+
+// RequestIDMiddleware 为每个请求分配请求 ID，并将带有 request_id 的 logger 放入请求 context
+func RequestIDMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.New().String()
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+
+		reqLogger := logger.With("request_id", id)
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), reqLogger))
+		c.Next()
+	}
+}
+
+// LoggerMiddleware 结构化访问日志，替代 gin.Logger
+// 5xx 记为 error，4xx 记为 warn；客户端断开记为 info，超时记为 warn，通过 outcome 字段区分
+// debug 级别额外输出隐藏了凭证的请求头
+func LoggerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		status := c.Writer.Status()
+		outcome := requestOutcome(c)
+		level := slog.LevelInfo
+		switch {
+		case outcome == metrics.ReasonCancelled:
+		case outcome == metrics.ReasonTimeout:
+			level = slog.LevelWarn
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logger := logging.FromContext(c.Request.Context())
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.String("outcome", outcome),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if userID := c.GetString("user_id"); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		if logger.Enabled(c.Request.Context(), slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("headers", redactHeaders(c.Request.Header)))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// RecoveryMiddleware 捕获 panic 并记录到请求 logger
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err interface{}) {
+		logging.FromContext(c.Request.Context()).Error("panic recovered",
+			"panic", err,
+			"stack", string(debug.Stack()),
+		)
+		abortWithError(c, errInternal)
+	})
+}
+
+// redactHeaders 复制请求头并隐藏凭证
+func redactHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[name] {
+			out[name] = "[REDACTED]"
+			continue
+		}
+		if len(values) > 0 {
+			out[name] = values[0]
+		}
+	}
+	return out
+}
@@ -1,33 +1,69 @@
 package api
 
 import (
+	"log/slog"
+	"time"
+
 	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/health"
+	"github.com/agenthub/server/internal/metrics"
 	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/tracing"
+	"github.com/agenthub/server/internal/webhook"
 	"github.com/gin-gonic/gin"
 )
 
-// NewRouter 创建路由
-func NewRouter(cfg *config.Config, store *storage.Storage) *gin.Engine {
+// NewRouter 创建路由，checker 为 nil 时 /readyz 不检查任何依赖
+func NewRouter(cfg *config.Config, store storage.Store, hooks *webhook.Dispatcher, checker *health.Checker) *gin.Engine {
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.New()
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	r.Use(RequestIDMiddleware(slog.Default()))
+	r.Use(tracing.Middleware())
+	r.Use(LoggerMiddleware())
+	r.Use(RecoveryMiddleware())
+	r.Use(metrics.Middleware())
 	r.Use(CORSMiddleware())
 
 	// 创建处理器
-	h := NewHandler(cfg, store)
+	if checker == nil {
+		checker = health.NewChecker(time.Second)
+	}
+	h := NewHandler(cfg, store, hooks, checker)
+	r.NoRoute(func(c *gin.Context) { abortWithError(c, errRouteNotFound) })
+
+	// 存活与就绪检查，不经过限流，供负载均衡和 Kubernetes 探针使用
+	r.GET("/livez", h.Livez)
+	r.GET("/readyz", h.Readyz)
+
+	// 速率限制：全局规则之外，认证、搜索、调用、发布各自独立计数
+	limit := func(policy RateLimitPolicy) gin.HandlerFunc {
+		return RateLimitMiddleware(cfg, store, policy)
+	}
+	rl := cfg.RateLimit
+
+	// 处理时限：审计日志查询和智能体调用在路由或分组上使用更长的时限
+	auditBudget := BudgetMiddleware(cfg.Server.AuditTimeout)
 
 	// API 版本
-	v1 := r.Group("/api/v1")
+	// 先按可选认证识别调用方，全局、搜索和发布限流才能按 API Key (及其等级) 或用户计数；
+	// 需要登录的路由再由 AuthMiddleware 校验
+	v1 := r.Group("/api/v1", BudgetMiddleware(cfg.Server.RequestTimeout), OptionalAuthMiddleware(cfg, store), limit(RateLimitPolicy{
+		Name:     "global",
+		Rule:     config.RateLimitRule{Requests: rl.Requests, Window: rl.Window},
+		FailOpen: true,
+	}))
 	{
-		// 健康检查
+		// 健康检查 (兼容旧客户端，等同于 /readyz)
 		v1.GET("/health", h.Health)
 
+		// 接口文档
+		v1.GET("/openapi.json", h.OpenAPI)
+
 		// 认证
-		auth := v1.Group("/auth")
+		auth := v1.Group("/auth", limit(RateLimitPolicy{Name: "auth", Rule: rl.Auth}))
 		{
 			auth.POST("/register", h.Register)
 			auth.POST("/login", h.Login)
@@ -50,22 +86,68 @@ func NewRouter(cfg *config.Config, store *storage.Storage) *gin.Engine {
 			agents.GET("/:namespace/:name", h.GetAgent)
 			agents.GET("/:namespace/:name/versions", h.ListVersions)
 			agents.GET("/:namespace/:name/versions/:version", h.GetVersion)
+			agents.GET("/:namespace/:name/versions/:version/package", h.GetPackage)
 			agents.GET("/:namespace/:name/files/*path", h.GetFile)
 
 			// 需要认证
-			agents.POST("", AuthMiddleware(cfg), h.CreateAgent)
+			publish := limit(RateLimitPolicy{Name: "publish", Rule: rl.Publish, FailOpen: true})
+			agents.POST("", AuthMiddleware(cfg), publish, h.CreateAgent)
 			agents.PUT("/:namespace/:name", AuthMiddleware(cfg), h.UpdateAgent)
 			agents.DELETE("/:namespace/:name", AuthMiddleware(cfg), h.DeleteAgent)
-			agents.POST("/:namespace/:name/versions", AuthMiddleware(cfg), h.PublishVersion)
+			agents.POST("/:namespace/:name/versions", AuthMiddleware(cfg), publish, h.PublishVersion)
+			agents.POST("/:namespace/:name/versions/:version/deprecate", AuthMiddleware(cfg), h.DeprecateVersion)
 			agents.POST("/:namespace/:name/like", AuthMiddleware(cfg), h.LikeAgent)
 			agents.DELETE("/:namespace/:name/like", AuthMiddleware(cfg), h.UnlikeAgent)
+
+			agents.POST("/:namespace/:name/transfer", AuthMiddleware(cfg), h.TransferAgent)
+			agents.POST("/:namespace/:name/rename", AuthMiddleware(cfg), h.RenameAgent)
+
+			// 协作者
+			agents.GET("/:namespace/:name/collaborators", AuthMiddleware(cfg), h.ListCollaborators)
+			agents.POST("/:namespace/:name/collaborators", AuthMiddleware(cfg), h.InviteCollaborator)
+			agents.DELETE("/:namespace/:name/collaborators/:username", AuthMiddleware(cfg), h.RemoveCollaborator)
+
+			// 审计日志
+			agents.GET("/:namespace/:name/audit", auditBudget, AuthMiddleware(cfg), h.ListAgentAudit)
+		}
+
+		// 组织
+		orgs := v1.Group("/orgs")
+		{
+			orgs.GET("/:org/audit", auditBudget, AuthMiddleware(cfg), h.ListOrgAudit)
+		}
+
+		// 管理
+		admin := v1.Group("/admin", auditBudget, AuthMiddleware(cfg), AdminMiddleware(store))
+		{
+			admin.GET("/audit", h.ListAuditLog)
+			admin.PUT("/keys/:id/tier", h.UpdateAPIKeyTier)
+		}
+
+		// 协作邀请
+		invitations := v1.Group("/invitations", AuthMiddleware(cfg))
+		{
+			invitations.GET("", h.ListInvitations)
+			invitations.POST("/:id/accept", h.AcceptInvitation)
+			invitations.DELETE("/:id", h.DeclineInvitation)
+		}
+
+		// Webhook
+		webhooks := v1.Group("/webhooks", AuthMiddleware(cfg))
+		{
+			webhooks.GET("", h.ListWebhooks)
+			webhooks.POST("", h.CreateWebhook)
+			webhooks.DELETE("/:id", h.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", h.RedeliverWebhook)
 		}
 
 		// 搜索
-		v1.GET("/search", h.Search)
+		v1.GET("/search", limit(RateLimitPolicy{Name: "search", Rule: rl.Search, FailOpen: true}), h.Search)
 
 		// 分类
 		v1.GET("/categories", h.ListCategories)
+		v1.POST("/categories", AuthMiddleware(cfg), AdminMiddleware(store), h.CreateCategory)
 
 		// 热门/推荐
 		v1.GET("/trending", h.GetTrending)
@@ -81,7 +163,11 @@ func NewRouter(cfg *config.Config, store *storage.Storage) *gin.Engine {
 	}
 
 	// Agent 调用接口 (需要 API Key)
-	invoke := r.Group("/invoke", APIKeyMiddleware(store))
+	invoke := r.Group("/invoke",
+		BudgetMiddleware(cfg.Server.InvokeTimeout),
+		APIKeyMiddleware(store),
+		limit(RateLimitPolicy{Name: "invoke", Rule: rl.Invoke, FailOpen: true}),
+	)
 	{
 		invoke.POST("/:namespace/:name", h.InvokeAgent)
 		invoke.POST("/:namespace/:name/stream", h.InvokeAgentStream)
@@ -95,7 +181,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization, Accept, X-Requested-With, X-API-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Authorization, Accept, X-Requested-With, X-API-Key, X-Request-ID, traceparent, tracestate, If-None-Match, If-Modified-Since")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, X-Request-ID, ETag")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
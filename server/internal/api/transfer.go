@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// agentNamePattern 智能体名称格式，与 agentspec.schema.json 保持一致
+var agentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$`)
+
+// ===== 转移与重命名 =====
+
+// TransferAgentRequest 转移智能体请求
+type TransferAgentRequest struct {
+	Namespace string `json:"namespace" binding:"required"`
+}
+
+// TransferAgent 将智能体转移到另一个命名空间 (用户或组织)
+// 调用者必须是来源和目标命名空间的所有者或组织 owner/admin，协作者和组织普通成员不能转移
+func (h *Handler) TransferAgent(c *gin.Context) {
+	var req TransferAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	agent, ok := h.requireAgentPermission(ctx, c, c.Param("namespace"), c.Param("name"), models.PermissionAdmin)
+	if !ok {
+		return
+	}
+
+	if h.namespacePermission(ctx, c, agent.Namespace) != models.PermissionAdmin {
+		abortWithError(c, errPermissionDenied.withMessage("only the namespace owner or organization admins can transfer this agent"))
+		return
+	}
+	if h.namespacePermission(ctx, c, req.Namespace) != models.PermissionAdmin {
+		abortWithError(c, errNamespaceForbidden)
+		return
+	}
+
+	// 转移到个人命名空间时作者改为该用户，转移到组织时为执行转移的管理员
+	authorID := c.GetString("user_id")
+	if user, err := h.store.GetUserByUsername(ctx, req.Namespace); err == nil {
+		authorID = user.ID
+	}
+	agent.AuthorID = authorID
+
+	h.moveAgent(ctx, c, agent, req.Namespace, agent.Name)
+}
+
+// RenameAgentRequest 重命名智能体请求
+type RenameAgentRequest struct {
+	Name string `json:"name" binding:"required,min=3,max=64"`
+}
+
+// RenameAgent 在当前命名空间内重命名智能体
+func (h *Handler) RenameAgent(c *gin.Context) {
+	var req RenameAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+	if !agentNamePattern.MatchString(req.Name) {
+		abortWithError(c, validationError("name", "invalid agent name"))
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	agent, ok := h.requireAgentPermission(ctx, c, c.Param("namespace"), c.Param("name"), models.PermissionAdmin)
+	if !ok {
+		return
+	}
+
+	h.moveAgent(ctx, c, agent, agent.Namespace, req.Name)
+}
+
+// moveAgent 执行转移或重命名，旧名称保留为重定向，同时保存 agent.AuthorID
+func (h *Handler) moveAgent(ctx context.Context, c *gin.Context, agent *models.Agent, namespace, name string) {
+	if agent.Namespace == namespace && agent.Name == name {
+		abortWithError(c, validationError("name", "agent already has this name"))
+		return
+	}
+
+	if _, err := h.store.GetAgent(ctx, namespace, name); err == nil {
+		abortWithError(c, errAgentExists)
+		return
+	}
+
+	previousName := agent.FullName
+	before := map[string]interface{}{"namespace": agent.Namespace, "name": agent.Name}
+	if err := h.store.MoveAgent(ctx, agent, namespace, name); err != nil {
+		abortWithError(c, conflict(err, errAgentExists))
+		return
+	}
+
+	action := models.AuditAgentRename
+	if before["namespace"] != namespace {
+		action = models.AuditAgentTransfer
+	}
+	event := agentAuditEvent(action, agent)
+	event.Before = before
+	event.After = map[string]interface{}{"namespace": namespace, "name": name}
+	h.recordAudit(c, event)
+
+	// 转移和重命名都会改变引用名称，统一作为 agent.transferred 通知订阅方
+	h.hooks.Publish(ctx, models.EventAgentTransferred, agent, map[string]interface{}{
+		"previous_name": previousName,
+	})
+
+	c.JSON(http.StatusOK, MoveAgentResponse{Agent: agent, PreviousName: previousName})
+}
+
+// MoveAgentResponse 转移或重命名响应
+type MoveAgentResponse struct {
+	Agent        *models.Agent `json:"agent"`
+	PreviousName string        `json:"previous_name"`
+}
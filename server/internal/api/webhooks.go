@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+// ===== Webhook =====
+
+// CreateWebhookRequest 创建 Webhook 请求
+// scope 为 agent 时 target 为 "namespace/name"，为 org 时为组织名，为 user 时可省略
+type CreateWebhookRequest struct {
+	Scope  string   `json:"scope" binding:"required,oneof=agent org user"`
+	Target string   `json:"target"`
+	URL    string   `json:"url" binding:"required,max=500"`
+	Events []string `json:"events" binding:"required,min=1"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=128"`
+}
+
+// CreateWebhook 创建 Webhook 订阅
+// 任何可读取智能体的用户都可以订阅该智能体，组织订阅需要组织成员身份
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, bindError(err))
+		return
+	}
+
+	if err := webhook.ValidateURL(req.URL); err != nil {
+		if errors.Is(err, webhook.ErrForbiddenAddress) {
+			abortWithError(c, validationError("url", "webhook url must not point to a private, loopback or link-local address"))
+			return
+		}
+		abortWithError(c, validationError("url", "invalid webhook url"))
+		return
+	}
+	for _, event := range req.Events {
+		if !containsString(models.WebhookEvents, event) {
+			abortWithError(c, validationError("events", "invalid event: "+event))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+
+	userID := c.GetString("user_id")
+	hook := &models.Webhook{
+		ID:        uuid.New().String(),
+		Scope:     req.Scope,
+		URL:       req.URL,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		IsActive:  true,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+
+	switch req.Scope {
+	case models.WebhookScopeAgent:
+		parts := strings.SplitN(req.Target, "/", 2)
+		if len(parts) != 2 {
+			abortWithError(c, validationError("target", "target must be namespace/name"))
+			return
+		}
+		agent, ok := h.getReadableAgent(ctx, c, parts[0], parts[1])
+		if !ok {
+			abortWithError(c, errAgentNotFound)
+			return
+		}
+		hook.Target = agent.ID
+		hook.TargetName = agent.FullName
+	case models.WebhookScopeOrg:
+		isMember, err := h.store.IsOrgMember(ctx, req.Target, userID)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !isMember {
+			abortWithError(c, errPermissionDenied.withMessage("not a member of this organization"))
+			return
+		}
+		hook.Target = req.Target
+		hook.TargetName = req.Target
+	case models.WebhookScopeUser:
+		user, err := h.store.GetUserByID(ctx, userID)
+		if err != nil {
+			abortWithError(c, notFound(err, errUnauthorized.withMessage("user not found")))
+			return
+		}
+		if req.Target != "" && req.Target != user.Username {
+			abortWithError(c, errPermissionDenied.withMessage("can only subscribe to your own namespace"))
+			return
+		}
+		hook.Target = user.Username
+		hook.TargetName = user.Username
+	}
+
+	if hook.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		hook.Secret = secret
+	}
+
+	if err := h.store.CreateWebhook(ctx, hook); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	h.recordAudit(c, &models.AuditEvent{
+		Action:     models.AuditWebhookCreate,
+		TargetType: "webhook",
+		TargetID:   hook.ID,
+		TargetName: hook.TargetName,
+		After:      map[string]interface{}{"scope": hook.Scope, "url": hook.URL, "events": hook.Events},
+	})
+
+	c.JSON(http.StatusCreated, CreateWebhookResponse{
+		Webhook: hook,
+		Secret:  hook.Secret,
+		Message: "请保存此密钥，用于校验 X-AgentHub-Signature-256 签名，它不会再次显示",
+	})
+}
+
+// CreateWebhookResponse 创建 Webhook 响应，签名密钥只在此时返回一次
+type CreateWebhookResponse struct {
+	Webhook *models.Webhook `json:"webhook"`
+	Secret  string          `json:"secret"`
+	Message string          `json:"message"`
+}
+
+// ListWebhooks 列出当前用户创建的 Webhook
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	hooks, err := h.store.ListWebhooks(ctx, c.GetString("user_id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if hooks == nil {
+		hooks = []*models.Webhook{}
+	}
+
+	c.JSON(http.StatusOK, singlePage(hooks))
+}
+
+// DeleteWebhook 删除 Webhook
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if err := h.store.DeleteWebhook(ctx, c.Param("id"), c.GetString("user_id")); err != nil {
+		abortWithError(c, notFound(err, errWebhookNotFound))
+		return
+	}
+
+	h.recordAudit(c, &models.AuditEvent{Action: models.AuditWebhookDelete, TargetType: "webhook", TargetID: c.Param("id")})
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "webhook deleted"})
+}
+
+// ListWebhookDeliveries 列出 Webhook 的投递记录
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	hook, ok := h.getOwnWebhook(ctx, c)
+	if !ok {
+		return
+	}
+
+	page, err := parsePage(c, storage.SortRecent, defaultDeliveryLimit, maxDeliveryLimit)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	deliveries, err := h.store.ListWebhookDeliveries(ctx, hook.ID, page.query())
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	writePage(c, page, deliveries, func(d *models.WebhookDelivery) storage.Cursor {
+		return storage.RecentCursor(d.CreatedAt, d.ID)
+	})
+}
+
+// RedeliverWebhook 以相同负载重新投递
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	hook, ok := h.getOwnWebhook(ctx, c)
+	if !ok {
+		return
+	}
+
+	original, err := h.store.GetWebhookDelivery(ctx, c.Param("delivery_id"))
+	if err != nil {
+		abortWithError(c, notFound(err, errDeliveryNotFound))
+		return
+	}
+	if original.WebhookID != hook.ID {
+		abortWithError(c, errDeliveryNotFound)
+		return
+	}
+
+	delivery, err := h.hooks.Redeliver(ctx, original)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// getOwnWebhook 获取当前用户创建的 Webhook，不存在或无权访问时写入 404
+func (h *Handler) getOwnWebhook(ctx context.Context, c *gin.Context) (*models.Webhook, bool) {
+	hook, err := h.store.GetWebhook(ctx, c.Param("id"))
+	if err != nil {
+		abortWithError(c, notFound(err, errWebhookNotFound))
+		return nil, false
+	}
+	if hook.CreatedBy != c.GetString("user_id") {
+		abortWithError(c, errWebhookNotFound)
+		return nil, false
+	}
+	return hook, true
+}
+
+// generateWebhookSecret 生成 Webhook 签名密钥
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
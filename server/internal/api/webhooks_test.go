@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+func TestCreateWebhookRejectsInternalURLs(t *testing.T) {
+	s := newTestServer(t)
+	alice, _ := s.register("alice")
+
+	create := func(url string) int {
+		w := s.request(http.MethodPost, "/api/v1/webhooks", alice, CreateWebhookRequest{
+			Scope:  models.WebhookScopeUser,
+			URL:    url,
+			Events: []string{models.EventVersionPublished},
+		})
+		return w.Code
+	}
+
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://api.localhost/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+		"ftp://example.com/hook",
+	} {
+		if code := create(url); code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", url, code, http.StatusBadRequest)
+		}
+	}
+
+	if code := create("https://hooks.example.com/agenthub"); code != http.StatusCreated {
+		t.Errorf("public url: status = %d, want %d", code, http.StatusCreated)
+	}
+}
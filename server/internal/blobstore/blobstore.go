@@ -0,0 +1,103 @@
+// Package blobstore 智能体包的对象存储，按 storage.type 选择实现
+// 对象按内容摘要命名，写入后不再修改
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/agenthub/server/internal/config"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("blob not found")
+
+// ErrUnsupported 尚未实现的存储类型
+var ErrUnsupported = errors.New("unsupported storage type")
+
+// Store 对象存储
+type Store interface {
+	// Put 写入对象，同名对象已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open 读取对象，不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// New 按配置创建对象存储，目前只支持 local
+func New(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Type {
+	case "", "local":
+		return &Local{root: cfg.LocalPath}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, cfg.Type)
+	}
+}
+
+// Local 本地目录存储
+type Local struct {
+	root string
+}
+
+// Put 先写入同一目录下的临时文件再重命名，读取方不会看到写了一半的对象
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+// Open 打开对象
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// path 对象路径，key 使用 / 分隔且不能跳出根目录
+func (l *Local) path(key string) (string, error) {
+	cleaned := path.Clean(key)
+	if cleaned != key || path.IsAbs(key) || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(cleaned)), nil
+}
@@ -1,107 +1,576 @@
 package config
 
 import (
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config 应用配置
+// 加载顺序：默认值 → YAML 配置文件 → 环境变量 → 命令行参数，后者覆盖前者
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Storage  StorageConfig
-	Auth     AuthConfig
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Storage   StorageConfig   `yaml:"storage"`
+	Auth      AuthConfig      `yaml:"auth"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Log       LogConfig       `yaml:"log"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	SMTP      SMTPConfig      `yaml:"smtp"`
+	LLM       LLMConfig       `yaml:"llm"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Address string
-	Mode    string // debug, release, test
+	Address     string `yaml:"address"`
+	Mode        string `yaml:"mode"`        // debug, release, test
+	Environment string `yaml:"environment"` // development, staging, production
+	// DrainDelay 收到退出信号后 readiness 先失败，等待该时长再关闭 HTTP 服务器
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// 请求处理时限，超时或客户端断开后取消数据库查询和下游调用
+	RequestTimeout time.Duration `yaml:"request_timeout"` // 普通 API 请求
+	InvokeTimeout  time.Duration `yaml:"invoke_timeout"`  // 智能体调用，包括流式调用
+	AuditTimeout   time.Duration `yaml:"audit_timeout"`   // 审计日志查询
 }
 
 // DatabaseConfig 数据库配置
+// 设置 URL 时忽略其余连接参数
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	URL          string `yaml:"url"`
+	Host         string `yaml:"host"`
+	Port         int    `yaml:"port"`
+	User         string `yaml:"user"`
+	Password     string `yaml:"password"`
+	DBName       string `yaml:"dbname"`
+	SSLMode      string `yaml:"sslmode"`
+	MaxOpenConns int    `yaml:"max_open_conns"`
+	MaxIdleConns int    `yaml:"max_idle_conns"`
 }
 
 // RedisConfig Redis配置
+// 设置 URL 时忽略 Host 和 Port
 type RedisConfig struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
 }
 
 // StorageConfig 对象存储配置
 type StorageConfig struct {
-	Type      string // local, s3, cos
-	LocalPath string
-	Bucket    string
-	Region    string
-	Endpoint  string
+	Type      string `yaml:"type"` // local, s3, cos
+	LocalPath string `yaml:"local_path"`
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	Endpoint  string `yaml:"endpoint"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
 }
 
 // AuthConfig 认证配置
 type AuthConfig struct {
-	JWTSecret     string
-	TokenExpiry   int // 小时
-	RefreshExpiry int // 天
+	JWTSecret          string        `yaml:"jwt_secret"`
+	TokenExpiry        time.Duration `yaml:"token_expiry"`
+	RefreshExpiry      time.Duration `yaml:"refresh_expiry"`
+	GitHubClientID     string        `yaml:"github_client_id"`
+	GitHubClientSecret string        `yaml:"github_client_secret"`
+	GoogleClientID     string        `yaml:"google_client_id"`
+	GoogleClientSecret string        `yaml:"google_client_secret"`
+}
+
+// RateLimitConfig 速率限制配置
+// Requests/Window 为全局默认规则，各路由组在此基础上单独限流
+type RateLimitConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+
+	Auth    RateLimitRule `yaml:"auth"`    // 注册、登录、刷新令牌
+	Search  RateLimitRule `yaml:"search"`  // 搜索
+	Invoke  RateLimitRule `yaml:"invoke"`  // 智能体调用
+	Publish RateLimitRule `yaml:"publish"` // 创建智能体、发布版本
+
+	// Tiers API Key 等级对应的配额倍数，未配置的等级按 1 倍计算
+	Tiers map[string]int `yaml:"tiers"`
+
+	Login LoginLimitConfig `yaml:"login"`
+}
+
+// RateLimitRule 滑动窗口限流规则，window 内最多 requests 次
+type RateLimitRule struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// LoginLimitConfig 登录防暴力破解配置
+// window 内同一账号或同一 IP 失败次数达到上限后暂时拒绝登录，登录成功后清除账号计数
+type LoginLimitConfig struct {
+	MaxFailures      int           `yaml:"max_failures"`        // 每个账号
+	MaxFailuresPerIP int           `yaml:"max_failures_per_ip"` // 每个 IP
+	Window           time.Duration `yaml:"window"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug, info, warn, error
+	Format string `yaml:"format"` // json, text
+}
+
+// MetricsConfig 指标配置
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
 }
 
-// Load 加载配置
-func Load() (*Config, error) {
+// TracingConfig 链路追踪配置
+// exporter 为 otlp 但未设置 endpoint 时回退到 stdout，便于本地调试
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Exporter    string  `yaml:"exporter"` // otlp, stdout
+	Endpoint    string  `yaml:"endpoint"` // OTLP/HTTP 地址，例如 http://localhost:4318
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"` // 0-1，对没有上游采样决定的请求生效
+}
+
+// SMTPConfig 邮件配置
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// LLMConfig 模型供应商配置
+type LLMConfig struct {
+	OpenAIAPIKey     string `yaml:"openai_api_key"`
+	OpenAIBaseURL    string `yaml:"openai_base_url"`
+	AnthropicAPIKey  string `yaml:"anthropic_api_key"`
+	AnthropicBaseURL string `yaml:"anthropic_base_url"`
+	OllamaBaseURL    string `yaml:"ollama_base_url"`
+}
+
+// Flags 命令行参数，非零值覆盖配置文件和环境变量
+type Flags struct {
+	File        string
+	Address     string
+	Mode        string
+	Environment string
+	LogLevel    string
+	MetricsPort int
+}
+
+// Register 注册命令行参数
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.File, "config", "", "YAML 配置文件路径 (也可通过 CONFIG_FILE 指定)")
+	fs.StringVar(&f.Address, "addr", "", "监听地址，例如 :8080")
+	fs.StringVar(&f.Mode, "mode", "", "运行模式: debug, release, test")
+	fs.StringVar(&f.Environment, "env", "", "部署环境: development, staging, production")
+	fs.StringVar(&f.LogLevel, "log-level", "", "日志级别: debug, info, warn, error")
+	fs.IntVar(&f.MetricsPort, "metrics-port", 0, "指标服务端口")
+}
+
+// Default 默认配置
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Address: getEnv("SERVER_ADDRESS", ":8080"),
-			Mode:    getEnv("SERVER_MODE", "debug"),
+			Address:     ":8080",
+			Environment: "development",
+			DrainDelay:  5 * time.Second,
+
+			RequestTimeout: 10 * time.Second,
+			InvokeTimeout:  60 * time.Second,
+			AuditTimeout:   30 * time.Second,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "agenthub"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "agenthub"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:         "localhost",
+			Port:         5432,
+			User:         "agenthub",
+			DBName:       "agenthub",
+			SSLMode:      "disable",
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnvInt("REDIS_PORT", 6379),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvInt("REDIS_DB", 0),
+			Host: "localhost",
+			Port: 6379,
 		},
 		Storage: StorageConfig{
-			Type:      getEnv("STORAGE_TYPE", "local"),
-			LocalPath: getEnv("STORAGE_LOCAL_PATH", "./data/agents"),
-			Bucket:    getEnv("STORAGE_BUCKET", ""),
-			Region:    getEnv("STORAGE_REGION", ""),
-			Endpoint:  getEnv("STORAGE_ENDPOINT", ""),
+			Type:      "local",
+			LocalPath: "./data/agents",
 		},
 		Auth: AuthConfig{
-			JWTSecret:     getEnv("JWT_SECRET", "change-me-in-production"),
-			TokenExpiry:   getEnvInt("TOKEN_EXPIRY_HOURS", 24),
-			RefreshExpiry: getEnvInt("REFRESH_EXPIRY_DAYS", 7),
+			JWTSecret:     defaultJWTSecret,
+			TokenExpiry:   24 * time.Hour,
+			RefreshExpiry: 7 * 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:  true,
+			Requests: 100,
+			Window:   time.Minute,
+			Auth:     RateLimitRule{Requests: 20, Window: time.Minute},
+			Search:   RateLimitRule{Requests: 60, Window: time.Minute},
+			Invoke:   RateLimitRule{Requests: 60, Window: time.Minute},
+			Publish:  RateLimitRule{Requests: 30, Window: time.Hour},
+			Tiers: map[string]int{
+				"free":       1,
+				"pro":        5,
+				"enterprise": 20,
+			},
+			Login: LoginLimitConfig{
+				MaxFailures:      5,
+				MaxFailuresPerIP: 20,
+				Window:           15 * time.Minute,
+			},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Port:    9090,
 		},
-	}, nil
+		Tracing: TracingConfig{
+			Exporter:    "otlp",
+			ServiceName: "agenthub-server",
+			SampleRatio: 1,
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+			From: "noreply@agenthub.dev",
+		},
+		LLM: LLMConfig{
+			OpenAIBaseURL:    "https://api.openai.com/v1",
+			AnthropicBaseURL: "https://api.anthropic.com",
+			OllamaBaseURL:    "http://localhost:11434",
+		},
+	}
+}
+
+// Load 加载并校验配置，flags 可为 nil
+func Load(flags *Flags) (*Config, error) {
+	if flags == nil {
+		flags = &Flags{}
+	}
+	cfg := Default()
+
+	file := flags.File
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file != "" {
+		if err := cfg.loadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	cfg.applyFlags(flags)
+
+	if cfg.Server.Mode == "" {
+		cfg.Server.Mode = "debug"
+		if cfg.IsProduction() {
+			cfg.Server.Mode = "release"
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// IsProduction 是否为生产环境
+func (c *Config) IsProduction() bool {
+	return c.Server.Environment == "production"
+}
+
+// DSN 返回数据库连接串，优先使用 URL
+func (d *DatabaseConfig) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.DBName, d.SSLMode,
+	)
+}
+
+// Addr 返回 Redis 地址 host:port
+func (r *RedisConfig) Addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv 读取环境变量，同时兼容旧的变量名 (DB_HOST、TOKEN_EXPIRY_HOURS 等)
+func (c *Config) loadEnv() error {
+	e := &envReader{}
+
+	// 服务器
+	if host, port := e.str("SERVER_HOST"), e.str("SERVER_PORT"); host != "" || port != "" {
+		if port == "" {
+			port = "8080"
+		}
+		c.Server.Address = net.JoinHostPort(host, port)
+	}
+	e.setStr(&c.Server.Address, "SERVER_ADDRESS")
+	e.setStr(&c.Server.Mode, "SERVER_MODE")
+	e.setStr(&c.Server.Environment, "ENVIRONMENT")
+	e.setDuration(&c.Server.DrainDelay, "SHUTDOWN_DRAIN_DELAY")
+	e.setDuration(&c.Server.RequestTimeout, "REQUEST_TIMEOUT")
+	e.setDuration(&c.Server.InvokeTimeout, "INVOKE_TIMEOUT")
+	e.setDuration(&c.Server.AuditTimeout, "AUDIT_TIMEOUT")
+
+	// 数据库
+	e.setStr(&c.Database.URL, "DATABASE_URL")
+	e.setStr(&c.Database.Host, "DB_HOST")
+	e.setInt(&c.Database.Port, "DB_PORT")
+	e.setStr(&c.Database.User, "DB_USER")
+	e.setStr(&c.Database.Password, "DB_PASSWORD")
+	e.setStr(&c.Database.DBName, "DB_NAME")
+	e.setStr(&c.Database.SSLMode, "DB_SSLMODE")
+	e.setInt(&c.Database.MaxOpenConns, "DATABASE_MAX_CONNECTIONS")
+	e.setInt(&c.Database.MaxIdleConns, "DATABASE_IDLE_CONNECTIONS")
+
+	// Redis
+	e.setStr(&c.Redis.URL, "REDIS_URL")
+	e.setStr(&c.Redis.Host, "REDIS_HOST")
+	e.setInt(&c.Redis.Port, "REDIS_PORT")
+	e.setStr(&c.Redis.Password, "REDIS_PASSWORD")
+	e.setInt(&c.Redis.DB, "REDIS_DB")
+
+	// 对象存储
+	e.setStr(&c.Storage.Type, "STORAGE_TYPE")
+	e.setStr(&c.Storage.LocalPath, "STORAGE_PATH", "STORAGE_LOCAL_PATH")
+	switch c.Storage.Type {
+	case "s3":
+		e.setStr(&c.Storage.Bucket, "S3_BUCKET")
+		e.setStr(&c.Storage.Region, "AWS_REGION")
+		e.setStr(&c.Storage.AccessKey, "AWS_ACCESS_KEY_ID")
+		e.setStr(&c.Storage.SecretKey, "AWS_SECRET_ACCESS_KEY")
+	case "cos":
+		e.setStr(&c.Storage.Bucket, "COS_BUCKET")
+		e.setStr(&c.Storage.Region, "COS_REGION")
+		e.setStr(&c.Storage.AccessKey, "COS_SECRET_ID")
+		e.setStr(&c.Storage.SecretKey, "COS_SECRET_KEY")
+	}
+	e.setStr(&c.Storage.Bucket, "STORAGE_BUCKET")
+	e.setStr(&c.Storage.Region, "STORAGE_REGION")
+	e.setStr(&c.Storage.Endpoint, "STORAGE_ENDPOINT")
+	e.setStr(&c.Storage.AccessKey, "STORAGE_ACCESS_KEY")
+	e.setStr(&c.Storage.SecretKey, "STORAGE_SECRET_KEY")
+
+	// 认证
+	e.setStr(&c.Auth.JWTSecret, "JWT_SECRET")
+	if hours := e.str("TOKEN_EXPIRY_HOURS"); hours != "" {
+		e.setDurationValue(&c.Auth.TokenExpiry, "TOKEN_EXPIRY_HOURS", hours+"h")
+	}
+	e.setDuration(&c.Auth.TokenExpiry, "JWT_EXPIRY")
+	if days := e.str("REFRESH_EXPIRY_DAYS"); days != "" {
+		if n, err := strconv.Atoi(days); err == nil {
+			c.Auth.RefreshExpiry = time.Duration(n) * 24 * time.Hour
+		} else {
+			e.fail("REFRESH_EXPIRY_DAYS", err)
+		}
+	}
+	e.setDuration(&c.Auth.RefreshExpiry, "REFRESH_TOKEN_EXPIRY")
+	e.setStr(&c.Auth.GitHubClientID, "GITHUB_CLIENT_ID")
+	e.setStr(&c.Auth.GitHubClientSecret, "GITHUB_CLIENT_SECRET")
+	e.setStr(&c.Auth.GoogleClientID, "GOOGLE_CLIENT_ID")
+	e.setStr(&c.Auth.GoogleClientSecret, "GOOGLE_CLIENT_SECRET")
+
+	// 速率限制
+	e.setBool(&c.RateLimit.Enabled, "RATE_LIMIT_ENABLED")
+	e.setInt(&c.RateLimit.Requests, "RATE_LIMIT_REQUESTS")
+	e.setDuration(&c.RateLimit.Window, "RATE_LIMIT_WINDOW")
+	for prefix, rule := range map[string]*RateLimitRule{
+		"RATE_LIMIT_AUTH":    &c.RateLimit.Auth,
+		"RATE_LIMIT_SEARCH":  &c.RateLimit.Search,
+		"RATE_LIMIT_INVOKE":  &c.RateLimit.Invoke,
+		"RATE_LIMIT_PUBLISH": &c.RateLimit.Publish,
+	} {
+		e.setInt(&rule.Requests, prefix+"_REQUESTS")
+		e.setDuration(&rule.Window, prefix+"_WINDOW")
+	}
+	e.setInt(&c.RateLimit.Login.MaxFailures, "LOGIN_MAX_FAILURES")
+	e.setInt(&c.RateLimit.Login.MaxFailuresPerIP, "LOGIN_MAX_FAILURES_PER_IP")
+	e.setDuration(&c.RateLimit.Login.Window, "LOGIN_LOCKOUT_WINDOW")
+
+	// 日志与指标
+	e.setStr(&c.Log.Level, "LOG_LEVEL")
+	e.setStr(&c.Log.Format, "LOG_FORMAT")
+	e.setBool(&c.Metrics.Enabled, "METRICS_ENABLED")
+	e.setInt(&c.Metrics.Port, "METRICS_PORT")
+	e.setBool(&c.Tracing.Enabled, "TRACING_ENABLED")
+	e.setStr(&c.Tracing.Exporter, "TRACING_EXPORTER")
+	e.setStr(&c.Tracing.Endpoint, "OTEL_EXPORTER_OTLP_ENDPOINT")
+	e.setStr(&c.Tracing.ServiceName, "OTEL_SERVICE_NAME")
+	e.setFloat(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+
+	// 邮件
+	e.setStr(&c.SMTP.Host, "SMTP_HOST")
+	e.setInt(&c.SMTP.Port, "SMTP_PORT")
+	e.setStr(&c.SMTP.User, "SMTP_USER")
+	e.setStr(&c.SMTP.Password, "SMTP_PASSWORD")
+	e.setStr(&c.SMTP.From, "SMTP_FROM")
+
+	// 模型供应商
+	e.setStr(&c.LLM.OpenAIAPIKey, "OPENAI_API_KEY")
+	e.setStr(&c.LLM.OpenAIBaseURL, "OPENAI_BASE_URL")
+	e.setStr(&c.LLM.AnthropicAPIKey, "ANTHROPIC_API_KEY")
+	e.setStr(&c.LLM.AnthropicBaseURL, "ANTHROPIC_BASE_URL")
+	e.setStr(&c.LLM.OllamaBaseURL, "OLLAMA_BASE_URL")
+
+	return e.err
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func (c *Config) applyFlags(f *Flags) {
+	if f.Address != "" {
+		c.Server.Address = f.Address
+	}
+	if f.Mode != "" {
+		c.Server.Mode = f.Mode
+	}
+	if f.Environment != "" {
+		c.Server.Environment = f.Environment
+	}
+	if f.LogLevel != "" {
+		c.Log.Level = f.LogLevel
+	}
+	if f.MetricsPort != 0 {
+		c.Metrics.Port = f.MetricsPort
 	}
-	return defaultValue
 }
 
-func getEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
+// envReader 读取环境变量，记录第一个解析错误
+// 每个变量 KEY 都支持 KEY_FILE 形式，从文件读取值 (用于 Docker/Kubernetes secrets)
+type envReader struct {
+	err error
+}
+
+// str 按顺序返回第一个已设置的变量值
+func (e *envReader) str(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+		if path := os.Getenv(key + "_FILE"); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				e.fail(key+"_FILE", err)
+				return ""
+			}
+			return strings.TrimSpace(string(data))
 		}
 	}
-	return defaultValue
+	return ""
+}
+
+func (e *envReader) setStr(dst *string, keys ...string) {
+	if value := e.str(keys...); value != "" {
+		*dst = value
+	}
+}
+
+func (e *envReader) setInt(dst *int, key string) {
+	value := e.str(key)
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*dst = n
+}
+
+func (e *envReader) setFloat(dst *float64, key string) {
+	value := e.str(key)
+	if value == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*dst = f
+}
+
+func (e *envReader) setBool(dst *bool, key string) {
+	value := e.str(key)
+	if value == "" {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*dst = b
+}
+
+func (e *envReader) setDuration(dst *time.Duration, key string) {
+	if value := e.str(key); value != "" {
+		e.setDurationValue(dst, key, value)
+	}
+}
+
+// setDurationValue 解析时长，不带单位的整数按秒处理
+func (e *envReader) setDurationValue(dst *time.Duration, key, value string) {
+	if n, err := strconv.Atoi(value); err == nil {
+		*dst = time.Duration(n) * time.Second
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, err)
+		return
+	}
+	*dst = d
+}
+
+func (e *envReader) fail(key string, err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf("invalid %s: %w", key, err)
+	}
+}
+
+// redactURL 隐藏 URL 中的密码
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		return raw
+	}
+	// url.Redacted 使用 xxxxx 占位，这里统一为 redacted
+	return strings.Replace(u.Redacted(), ":xxxxx@", ":"+redacted+"@", 1)
 }
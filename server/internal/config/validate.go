@@ -0,0 +1,199 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultJWTSecret = "change-me-in-production"
+	// minJWTSecretLength 生产环境 JWT 密钥的最小长度 (HS256 建议至少 32 字节)
+	minJWTSecretLength = 32
+
+	redacted = "******"
+)
+
+// insecureJWTSecrets 示例配置中出现过的密钥，生产环境禁止使用
+var insecureJWTSecrets = map[string]bool{
+	defaultJWTSecret: true,
+	"your-super-secret-jwt-key-change-in-production": true,
+	"secret":   true,
+	"changeme": true,
+}
+
+// Validate 校验配置，返回全部问题
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		add("server.mode must be one of debug, release, test, got %q", c.Server.Mode)
+	}
+	switch c.Server.Environment {
+	case "development", "staging", "production":
+	default:
+		add("server.environment must be one of development, staging, production, got %q", c.Server.Environment)
+	}
+
+	if c.Server.DrainDelay < 0 {
+		add("server.drain_delay must not be negative")
+	}
+	if c.Server.RequestTimeout <= 0 {
+		add("server.request_timeout must be positive")
+	}
+	if c.Server.InvokeTimeout <= 0 {
+		add("server.invoke_timeout must be positive")
+	}
+	if c.Server.AuditTimeout <= 0 {
+		add("server.audit_timeout must be positive")
+	}
+
+	if c.Database.URL != "" {
+		if err := checkURL(c.Database.URL, "postgres", "postgresql"); err != nil {
+			add("database.url: %v", err)
+		}
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		add("database connection pool sizes must not be negative")
+	}
+	if c.Redis.URL != "" {
+		if err := checkURL(c.Redis.URL, "redis", "rediss"); err != nil {
+			add("redis.url: %v", err)
+		}
+	}
+
+	if c.Auth.TokenExpiry <= 0 {
+		add("auth.token_expiry must be positive")
+	}
+	if c.Auth.RefreshExpiry <= 0 {
+		add("auth.refresh_expiry must be positive")
+	}
+	if c.IsProduction() {
+		secret := c.Auth.JWTSecret
+		if insecureJWTSecrets[secret] || strings.Contains(strings.ToLower(secret), "change") {
+			add("auth.jwt_secret uses an insecure default, set JWT_SECRET or JWT_SECRET_FILE")
+		} else if len(secret) < minJWTSecretLength {
+			add("auth.jwt_secret must be at least %d characters in production", minJWTSecretLength)
+		}
+	}
+
+	if c.RateLimit.Enabled {
+		for name, rule := range map[string]RateLimitRule{
+			"rate_limit":         {Requests: c.RateLimit.Requests, Window: c.RateLimit.Window},
+			"rate_limit.auth":    c.RateLimit.Auth,
+			"rate_limit.search":  c.RateLimit.Search,
+			"rate_limit.invoke":  c.RateLimit.Invoke,
+			"rate_limit.publish": c.RateLimit.Publish,
+		} {
+			if rule.Requests <= 0 || rule.Window <= 0 {
+				add("%s requests and window must be positive", name)
+			}
+		}
+		for tier, factor := range c.RateLimit.Tiers {
+			if factor <= 0 {
+				add("rate_limit.tiers.%s must be positive", tier)
+			}
+		}
+	}
+	if login := c.RateLimit.Login; (login.MaxFailures > 0 || login.MaxFailuresPerIP > 0) && login.Window <= 0 {
+		add("rate_limit.login.window must be positive")
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level must be one of debug, info, warn, error, got %q", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		add("log.format must be json or text, got %q", c.Log.Format)
+	}
+
+	if c.Metrics.Enabled && (c.Metrics.Port <= 0 || c.Metrics.Port > 65535) {
+		add("metrics.port must be between 1 and 65535")
+	}
+
+	if c.Tracing.Enabled {
+		switch c.Tracing.Exporter {
+		case "otlp", "stdout":
+		default:
+			add("tracing.exporter must be otlp or stdout, got %q", c.Tracing.Exporter)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			add("tracing.sample_ratio must be between 0 and 1")
+		}
+		if c.Tracing.Endpoint != "" {
+			if err := checkURL(c.Tracing.Endpoint, "http", "https"); err != nil {
+				add("tracing.endpoint: %v", err)
+			}
+		}
+	}
+
+	for name, raw := range map[string]string{
+		"llm.openai_base_url":    c.LLM.OpenAIBaseURL,
+		"llm.anthropic_base_url": c.LLM.AnthropicBaseURL,
+		"llm.ollama_base_url":    c.LLM.OllamaBaseURL,
+	} {
+		if raw == "" {
+			continue
+		}
+		if err := checkURL(raw, "http", "https"); err != nil {
+			add("%s: %v", name, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func checkURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("scheme must be one of %s", strings.Join(schemes, ", "))
+}
+
+// Redacted 返回隐藏了密码、密钥等敏感字段的副本，用于打印和日志
+func (c *Config) Redacted() *Config {
+	out := *c
+	mask := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+
+	out.Database.URL = redactURL(out.Database.URL)
+	mask(&out.Database.Password)
+	out.Redis.URL = redactURL(out.Redis.URL)
+	mask(&out.Redis.Password)
+	mask(&out.Storage.SecretKey)
+	mask(&out.Auth.JWTSecret)
+	mask(&out.Auth.GitHubClientSecret)
+	mask(&out.Auth.GoogleClientSecret)
+	mask(&out.SMTP.Password)
+	mask(&out.LLM.OpenAIAPIKey)
+	mask(&out.LLM.AnthropicAPIKey)
+	return &out
+}
+
+// YAML 以配置文件格式输出，时长使用 Go 时长字符串 (例如 24h0m0s)
+func (c *Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
@@ -0,0 +1,145 @@
+// Package health 依赖健康检查，供 /readyz 使用
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 检查状态
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+// CheckFunc 检查一个依赖，返回 nil 表示可用
+type CheckFunc func(ctx context.Context) error
+
+// Result 单个依赖的检查结果
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report 整体检查结果
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+// OK 是否全部可用
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker 依赖检查器
+// 进入关闭流程后 readiness 立即失败，负载均衡摘除实例后再关闭 HTTP 服务器
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]CheckFunc
+	draining atomic.Bool
+}
+
+// NewChecker 创建检查器，timeout 为单个依赖的超时时间
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]CheckFunc),
+	}
+}
+
+// Add 注册依赖检查，需在开始处理请求前完成
+func (c *Checker) Add(name string, check CheckFunc) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+		sort.Strings(c.names)
+	}
+	c.checks[name] = check
+}
+
+// Drain 标记进入关闭流程
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Draining 是否已进入关闭流程
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Check 并发执行全部检查
+func (c *Checker) Check(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, Checks: make(map[string]*Result, len(c.names))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		name, check := name, c.checks[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := c.run(ctx, check)
+			mu.Lock()
+			report.Checks[name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	if c.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check CheckFunc) *Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := &Result{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// DirWritable 检查本地目录是否存在且可写，用于 local 类型的对象存储
+func DirWritable(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return errors.New("not a directory")
+		}
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}
+}
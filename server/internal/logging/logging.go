@@ -0,0 +1,52 @@
+// Package logging 基于 log/slog 的结构化日志，请求级 logger 通过 context 传递
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/agenthub/server/internal/config"
+)
+
+type ctxKey struct{}
+
+// New 按配置创建 logger，format 为 json 或 text
+func New(cfg config.LogConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+}
+
+// ParseLevel 解析日志级别，无法识别时返回 info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// NewContext 返回携带 logger 的 context
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext 取出 context 中的 logger，没有时返回 slog.Default()
+// 请求处理路径上的 logger 带有 request_id 等字段
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
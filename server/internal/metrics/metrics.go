@@ -0,0 +1,259 @@
+// Package metrics 定义服务端 Prometheus 指标，通过独立端口的 /metrics 暴露
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "agenthub"
+
+// 请求和调用被中止的原因，与普通错误分开统计
+const (
+	ReasonCancelled = "cancelled" // 客户端在处理完成前断开
+	ReasonTimeout   = "timeout"   // 超出路由的处理时限
+)
+
+// Registry 服务端指标注册表，不使用全局默认注册表，避免第三方库的指标混入
+var Registry = prometheus.NewRegistry()
+
+// ===== HTTP =====
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	httpRequestsAborted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_aborted_total",
+		Help:      "HTTP requests aborted before completion, by route template and reason (cancelled, timeout).",
+	}, []string{"route", "reason"})
+)
+
+// ===== Redis =====
+
+var redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "redis_command_duration_seconds",
+	Help:      "Redis command latency by command and result.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+}, []string{"command", "result"})
+
+// ===== 智能体调用 =====
+
+var (
+	invocationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "invocation_duration_seconds",
+		Help:      "Agent invocation latency by agent and result.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"agent", "result"})
+
+	invocationTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invocation_tokens_total",
+		Help:      "Tokens consumed by agent invocations, by agent and kind (prompt, completion).",
+	}, []string{"agent", "kind"})
+
+	invocationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invocation_errors_total",
+		Help:      "Failed agent invocations by agent and reason.",
+	}, []string{"agent", "reason"})
+)
+
+// ===== 业务计数 =====
+
+var (
+	publishesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "versions_published_total",
+		Help:      "Agent versions published.",
+	})
+
+	pullsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "versions_pulled_total",
+		Help:      "Agent version downloads.",
+	})
+
+	registrationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_registrations_total",
+		Help:      "User accounts registered.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		httpRequestsInFlight,
+		httpRequestsAborted,
+		redisCommandDuration,
+		invocationDuration,
+		invocationTokens,
+		invocationErrors,
+		publishesTotal,
+		pullsTotal,
+		registrationsTotal,
+	)
+}
+
+// Handler 返回 /metrics 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// NewServer 创建独立的指标服务器，与业务端口分开，避免指标暴露到公网
+func NewServer(port int) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// Middleware 记录 HTTP 请求耗时
+// route 使用路由模板 (例如 /api/v1/agents/:namespace/:name)，未匹配的请求统一记为 unmatched，避免标签基数失控
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(
+			c.Request.Method, route, strconv.Itoa(c.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RequestAborted 记录一次因客户端断开或超时而中止的请求
+func RequestAborted(route, reason string) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestsAborted.WithLabelValues(route, reason).Inc()
+}
+
+// AbortReason 根据 context 错误返回中止原因，未中止时返回空字符串
+func AbortReason(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ReasonCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ReasonTimeout
+	}
+	return ""
+}
+
+// RegisterDB 采集数据库连接池状态 (sql.DB.Stats)
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveInvocation 记录一次智能体调用，reason 为空表示成功
+// 被取消或超时的调用单独作为 result 统计，不计入错误
+func ObserveInvocation(agent string, duration time.Duration, promptTokens, completionTokens int, reason string) {
+	result := "success"
+	switch reason {
+	case "":
+	case ReasonCancelled, ReasonTimeout:
+		result = reason
+	default:
+		result = "error"
+		invocationErrors.WithLabelValues(agent, reason).Inc()
+	}
+	invocationDuration.WithLabelValues(agent, result).Observe(duration.Seconds())
+	if promptTokens > 0 {
+		invocationTokens.WithLabelValues(agent, "prompt").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		invocationTokens.WithLabelValues(agent, "completion").Add(float64(completionTokens))
+	}
+}
+
+// VersionPublished 记录一次版本发布
+func VersionPublished() { publishesTotal.Inc() }
+
+// VersionPulled 记录一次版本下载
+func VersionPulled() { pullsTotal.Inc() }
+
+// UserRegistered 记录一次用户注册
+func UserRegistered() { registrationsTotal.Inc() }
+
+// RedisHook 记录 Redis 命令耗时，通过 redis.Client.AddHook 注册
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+// DialHook 不做处理
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 记录单条命令耗时
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd.Name(), start, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 将一次 pipeline 作为一条记录
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeRedis("pipeline", start, err)
+		return err
+	}
+}
+
+func observeRedis(command string, start time.Time, err error) {
+	result := "ok"
+	switch {
+	case err == redis.Nil:
+		result = "nil"
+	case AbortReason(err) != "":
+		result = AbortReason(err)
+	case err != nil:
+		result = "error"
+	}
+	redisCommandDuration.WithLabelValues(strings.ToLower(command), result).Observe(time.Since(start).Seconds())
+}
@@ -24,6 +24,13 @@ type Agent struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// 智能体可见性
+const (
+	VisibilityPublic   = "public"   // 公开，出现在列表和搜索中
+	VisibilityPrivate  = "private"  // 私有，仅所有者、组织成员可见
+	VisibilityUnlisted = "unlisted" // 不公开，可通过直接链接访问，但不出现在列表和搜索中
+)
+
 // AgentVersion 智能体版本
 type AgentVersion struct {
 	ID           string    `json:"id" db:"id"`
@@ -39,6 +46,11 @@ type AgentVersion struct {
 	Downloads    int64     `json:"downloads" db:"downloads"`
 	Status       string    `json:"status" db:"status"` // pending, active, deprecated
 	MinCLIVersion string   `json:"min_cli_version,omitempty" db:"min_cli_version"`
+
+	// 项目包 (tar.gz)，只发布 spec 时为空
+	PackageDigest string       `json:"package_digest,omitempty" db:"package_digest"`
+	PackageSize   int64        `json:"package_size,omitempty" db:"package_size"`
+	Files         []*AgentFile `json:"files,omitempty" db:"-"` // 包中的文件，只在获取单个版本时返回
 }
 
 // AgentFile 智能体文件
@@ -199,3 +211,43 @@ type FailureHandler struct {
 	Goto       string `yaml:"goto,omitempty" json:"goto,omitempty"`
 	MaxRetries int    `yaml:"max_retries,omitempty" json:"max_retries,omitempty"`
 }
+
+// 协作者权限，按授权范围从小到大排列
+const (
+	PermissionRead    = "read"    // 读取私有智能体
+	PermissionPublish = "publish" // 发布版本、更新元数据
+	PermissionAdmin   = "admin"   // 修改可见性、删除、管理协作者
+)
+
+// 协作邀请状态
+const (
+	CollaboratorPending  = "pending"
+	CollaboratorAccepted = "accepted"
+)
+
+// PermissionLevel 返回权限等级，未知权限返回 0
+func PermissionLevel(permission string) int {
+	switch permission {
+	case PermissionRead:
+		return 1
+	case PermissionPublish:
+		return 2
+	case PermissionAdmin:
+		return 3
+	}
+	return 0
+}
+
+// AgentCollaborator 智能体协作者
+type AgentCollaborator struct {
+	ID         string     `json:"id" db:"id"`
+	AgentID    string     `json:"agent_id" db:"agent_id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	Username   string     `json:"username"`
+	Permission string     `json:"permission" db:"permission"`
+	Status     string     `json:"status" db:"status"`
+	InvitedBy  string     `json:"invited_by" db:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	Agent      *Agent     `json:"agent,omitempty"` // 邀请列表中附带的智能体信息
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// 审计事件类型
+const (
+	AuditUserRegister      = "user.register"
+	AuditUserLogin         = "user.login"
+	AuditUserLoginFailed   = "user.login_failed"
+	AuditUserLogout        = "user.logout"
+	AuditAgentCreate       = "agent.create"
+	AuditAgentUpdate       = "agent.update"
+	AuditAgentVisibility   = "agent.visibility_change"
+	AuditAgentDelete       = "agent.delete"
+	AuditAgentTransfer     = "agent.transfer"
+	AuditAgentRename       = "agent.rename"
+	AuditVersionPublish    = "version.publish"
+	AuditVersionDeprecate  = "version.deprecate"
+	AuditAPIKeyCreate      = "api_key.create"
+	AuditAPIKeyDelete      = "api_key.delete"
+	AuditAPIKeyTier        = "api_key.tier_change"
+	AuditCollabInvite      = "collaborator.invite"
+	AuditCollabRemove      = "collaborator.remove"
+	AuditInvitationAccept  = "invitation.accept"
+	AuditInvitationDecline = "invitation.decline"
+	AuditCategoryCreate    = "category.create"
+	AuditWebhookCreate     = "webhook.create"
+	AuditWebhookDelete     = "webhook.delete"
+)
+
+// AuditEvent 审计日志记录
+type AuditEvent struct {
+	ID         string                 `json:"id" db:"id"`
+	Action     string                 `json:"action" db:"action"`
+	ActorID    string                 `json:"actor_id,omitempty" db:"actor_id"`
+	ActorName  string                 `json:"actor_name,omitempty" db:"actor_name"`
+	IP         string                 `json:"ip" db:"ip"`
+	UserAgent  string                 `json:"user_agent" db:"user_agent"`
+	TargetType string                 `json:"target_type" db:"target_type"` // user, agent, version, api_key, collaborator, category, webhook
+	TargetID   string                 `json:"target_id,omitempty" db:"target_id"`
+	TargetName string                 `json:"target_name,omitempty" db:"target_name"`
+	AgentID    string                 `json:"agent_id,omitempty" db:"agent_id"`
+	Namespace  string                 `json:"namespace,omitempty" db:"namespace"`
+	Before     map[string]interface{} `json:"before,omitempty" db:"before"` // 变更前的字段 (仅包含有差异的字段)
+	After      map[string]interface{} `json:"after,omitempty" db:"after"`   // 变更后的字段
+	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
+}
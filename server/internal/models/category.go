@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Category 智能体分类
+type Category struct {
+	ID        string    `json:"id" db:"id"`
+	ParentID  string    `json:"parent_id,omitempty" db:"parent_id"`
+	Name      string    `json:"name"` // 按请求语言本地化后的名称
+	NameZh    string    `json:"name_zh" db:"name_zh"`
+	NameEn    string    `json:"name_en" db:"name_en"`
+	Icon      string    `json:"icon,omitempty" db:"icon"`
+	SortOrder int       `json:"sort_order" db:"sort_order"`
+	Count     int64     `json:"count"` // 公开智能体数量，含子分类
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// LocalizedName 返回指定语言的名称
+func (c *Category) LocalizedName(lang string) string {
+	if lang == "en" && c.NameEn != "" {
+		return c.NameEn
+	}
+	return c.NameZh
+}
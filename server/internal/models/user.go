@@ -65,6 +65,25 @@ type OrgMember struct {
 	JoinedAt  time.Time `json:"joined_at" db:"joined_at"`
 }
 
+// 组织成员角色
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// OrgRolePermission 组织角色在组织智能体上的权限
+// owner 和 admin 可以管理智能体，普通成员只能发布版本和更新元数据
+func OrgRolePermission(role string) string {
+	switch role {
+	case OrgRoleOwner, OrgRoleAdmin:
+		return PermissionAdmin
+	case OrgRoleMember:
+		return PermissionPublish
+	}
+	return ""
+}
+
 // APIKey API密钥
 type APIKey struct {
 	ID          string    `json:"id" db:"id"`
@@ -73,12 +92,38 @@ type APIKey struct {
 	KeyPrefix   string    `json:"key_prefix" db:"key_prefix"` // 显示前几位
 	KeyHash     string    `json:"-" db:"key_hash"`
 	Scopes      []string  `json:"scopes" db:"scopes"`
+	Tier        string    `json:"tier" db:"tier"` // 配额等级，决定调用限流倍数
 	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
 	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	IsActive    bool      `json:"is_active" db:"is_active"`
 }
 
+// API Key 配额等级
+const (
+	TierFree       = "free"
+	TierPro        = "pro"
+	TierEnterprise = "enterprise"
+)
+
+// API Key 权限范围
+const (
+	ScopeReadPrivate = "read:private" // 读取有权限的私有智能体
+)
+
+// ValidScopes 可授予 API Key 的权限范围
+var ValidScopes = []string{ScopeReadPrivate}
+
+// HasScope 判断 API Key 是否拥有指定权限
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // AccessToken 访问令牌
 type AccessToken struct {
 	ID        string    `json:"id" db:"id"`
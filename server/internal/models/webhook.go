@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook 事件类型
+const (
+	EventVersionPublished  = "version.published"
+	EventVersionDeprecated = "version.deprecated"
+	EventAgentDeleted      = "agent.deleted"
+	EventAgentTransferred  = "agent.transferred"
+)
+
+// WebhookEvents 可订阅的事件
+var WebhookEvents = []string{
+	EventVersionPublished,
+	EventVersionDeprecated,
+	EventAgentDeleted,
+	EventAgentTransferred,
+}
+
+// Webhook 订阅范围
+const (
+	WebhookScopeAgent = "agent"
+	WebhookScopeOrg   = "org"
+	WebhookScopeUser  = "user"
+)
+
+// 投递状态
+const (
+	DeliveryPending = "pending"
+	DeliverySuccess = "success"
+	DeliveryFailed  = "failed"
+)
+
+// Webhook 事件订阅
+type Webhook struct {
+	ID         string    `json:"id" db:"id"`
+	Scope      string    `json:"scope" db:"scope"`   // agent, org, user
+	Target     string    `json:"target" db:"target"` // agent 为智能体 ID，org/user 为命名空间
+	TargetName string    `json:"target_name" db:"target_name"`
+	URL        string    `json:"url" db:"url"`
+	Secret     string    `json:"-" db:"secret"` // HMAC 签名密钥
+	Events     []string  `json:"events" db:"events"`
+	IsActive   bool      `json:"is_active" db:"is_active"`
+	CreatedBy  string    `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery Webhook 投递记录
+type WebhookDelivery struct {
+	ID             string          `json:"id" db:"id"`
+	WebhookID      string          `json:"webhook_id" db:"webhook_id"`
+	Event          string          `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   string          `json:"response_body,omitempty" db:"response_body"`
+	Error          string          `json:"error,omitempty" db:"error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// 热门智能体的读穿缓存
+// 智能体按 namespace/name 缓存，最新版本按 agent_id 缓存；写操作成功后删除对应的键。
+// 下载次数不触发失效，缓存期内返回的计数可能略有滞后
+const (
+	agentCacheTTL = time.Minute
+)
+
+func agentCacheKey(namespace, name string) string {
+	return "agents:" + namespace + "/" + name
+}
+
+func latestVersionCacheKey(agentID string) string {
+	return "versions:latest:" + agentID
+}
+
+// cacheGet 读取缓存并解码到 dest，未命中或 Redis 不可用时返回 false
+func (s *Storage) cacheGet(ctx context.Context, key string, dest interface{}) bool {
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// cacheSet 写入缓存，失败时忽略，下次读取回源数据库
+func (s *Storage) cacheSet(ctx context.Context, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	s.redis.Set(ctx, key, data, agentCacheTTL)
+}
+
+// invalidateAgent 清除智能体缓存
+func (s *Storage) invalidateAgent(ctx context.Context, namespace, name string) {
+	s.redis.Del(ctx, agentCacheKey(namespace, name))
+}
+
+// invalidateLatestVersion 清除智能体最新版本的缓存
+func (s *Storage) invalidateLatestVersion(ctx context.Context, agentID string) {
+	s.redis.Del(ctx, latestVersionCacheKey(agentID))
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/lib/pq"
+)
+
+// ===== API Key 操作 =====
+
+// CreateAPIKey 创建 API Key
+func (s *Storage) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, key_prefix, key_hash, scopes, tier, expires_at, created_at, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		key.ID, key.UserID, key.Name, key.KeyPrefix, key.KeyHash,
+		pq.Array(key.Scopes), key.Tier, key.ExpiresAt, key.CreatedAt, key.IsActive,
+	)
+	return err
+}
+
+// GetAPIKeyByHash 通过哈希获取 API Key
+func (s *Storage) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_prefix, key_hash, scopes, tier, expires_at, last_used_at, created_at, is_active
+		FROM api_keys
+		WHERE key_hash = $1
+	`
+	return scanAPIKey(s.db.QueryRowContext(ctx, query, hash))
+}
+
+// ListAPIKeys 列出用户的 API Keys
+func (s *Storage) ListAPIKeys(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_prefix, key_hash, scopes, tier, expires_at, last_used_at, created_at, is_active
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteAPIKey 删除用户的 API Key
+func (s *Storage) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchAPIKey 更新 API Key 最后使用时间
+func (s *Storage) TouchAPIKey(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, time.Now(), id)
+	return err
+}
+
+// UpdateAPIKeyTier 修改 API Key 的配额等级，返回修改后的记录，不存在时返回 sql.ErrNoRows
+func (s *Storage) UpdateAPIKeyTier(ctx context.Context, id, tier string) (*models.APIKey, error) {
+	query := `
+		UPDATE api_keys SET tier = $1
+		WHERE id = $2
+		RETURNING id, user_id, name, key_prefix, key_hash, scopes, tier, expires_at, last_used_at, created_at, is_active
+	`
+	return scanAPIKey(s.db.QueryRowContext(ctx, query, tier, id))
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var prefix sql.NullString
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(
+		&key.ID, &key.UserID, &key.Name, &prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&key.Tier, &expiresAt, &lastUsedAt, &key.CreatedAt, &key.IsActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.KeyPrefix = prefix.String
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return key, nil
+}
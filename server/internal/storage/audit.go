@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// ===== Audit 操作 =====
+
+// AuditQuery 审计日志查询条件
+type AuditQuery struct {
+	AgentID   string
+	Namespace string
+	ActorID   string
+	Action    string
+	Since     time.Time
+	Until     time.Time
+	PageOptions
+}
+
+// CreateAuditEvent 追加审计记录
+func (s *Storage) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	before, err := marshalJSONB(event.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalJSONB(event.After)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_log (id, action, actor_id, actor_name, ip, user_agent, target_type, target_id, target_name, agent_id, namespace, before, after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+	_, err = s.db.ExecContext(ctx, query,
+		event.ID, event.Action, nullString(event.ActorID), nullString(event.ActorName),
+		event.IP, event.UserAgent, event.TargetType, nullString(event.TargetID),
+		nullString(event.TargetName), nullString(event.AgentID), nullString(event.Namespace),
+		before, after, event.CreatedAt,
+	)
+	return err
+}
+
+// ListAuditEvents 按条件查询审计记录，按时间倒序分页
+func (s *Storage) ListAuditEvents(ctx context.Context, q AuditQuery) ([]*models.AuditEvent, error) {
+	baseQuery := `
+		SELECT id, action, actor_id, actor_name, ip, user_agent, target_type, target_id, target_name, agent_id, namespace, before, after, created_at
+		FROM audit_log
+		WHERE 1 = 1`
+	args := []interface{}{}
+	argIndex := 1
+
+	addFilter := func(column string, value interface{}) {
+		baseQuery += fmt.Sprintf(" AND %s $%d", column, argIndex)
+		args = append(args, value)
+		argIndex++
+	}
+	if q.AgentID != "" {
+		addFilter("agent_id =", q.AgentID)
+	}
+	if q.Namespace != "" {
+		// 转出命名空间的转移记录也属于该命名空间
+		baseQuery += fmt.Sprintf(" AND (namespace = $%d OR before->>'namespace' = $%d)", argIndex, argIndex)
+		args = append(args, q.Namespace)
+		argIndex++
+	}
+	if q.ActorID != "" {
+		addFilter("actor_id =", q.ActorID)
+	}
+	if q.Action != "" {
+		addFilter("action =", q.Action)
+	}
+	if !q.Since.IsZero() {
+		addFilter("created_at >=", q.Since)
+	}
+	if !q.Until.IsZero() {
+		addFilter("created_at <", q.Until)
+	}
+
+	if q.After != nil {
+		baseQuery += keysetCondition("created_at", true, argIndex)
+		args = append(args, q.After.Time(), q.After.ID)
+		argIndex += 2
+	}
+
+	baseQuery += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", argIndex)
+	args = append(args, q.Limit)
+
+	rows, err := s.db.QueryContext(ctx, baseQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		event := &models.AuditEvent{}
+		var actorID, actorName, ip, userAgent, targetID, targetName, agentID, namespace sql.NullString
+		var before, after []byte
+		err := rows.Scan(
+			&event.ID, &event.Action, &actorID, &actorName, &ip, &userAgent, &event.TargetType,
+			&targetID, &targetName, &agentID, &namespace, &before, &after, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		event.ActorID = actorID.String
+		event.ActorName = actorName.String
+		event.IP = ip.String
+		event.UserAgent = userAgent.String
+		event.TargetID = targetID.String
+		event.TargetName = targetName.String
+		event.AgentID = agentID.String
+		event.Namespace = namespace.String
+		if len(before) > 0 {
+			json.Unmarshal(before, &event.Before)
+		}
+		if len(after) > 0 {
+			json.Unmarshal(after, &event.After)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func marshalJSONB(v map[string]interface{}) (interface{}, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
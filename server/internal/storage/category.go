@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+const (
+	categoryCountsKey = "categories:counts"
+	categoryCountsTTL = 5 * time.Minute
+)
+
+// ===== Category 操作 =====
+
+// ListCategories 列出所有分类
+func (s *Storage) ListCategories(ctx context.Context) ([]*models.Category, error) {
+	query := `
+		SELECT id, parent_id, name_zh, name_en, icon, sort_order, created_at
+		FROM categories
+		ORDER BY sort_order ASC, id ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+// GetCategory 获取分类
+func (s *Storage) GetCategory(ctx context.Context, id string) (*models.Category, error) {
+	query := `
+		SELECT id, parent_id, name_zh, name_en, icon, sort_order, created_at
+		FROM categories
+		WHERE id = $1
+	`
+	return scanCategory(s.db.QueryRowContext(ctx, query, id))
+}
+
+// CreateCategory 创建分类，ID 已存在时返回 ErrDuplicate
+func (s *Storage) CreateCategory(ctx context.Context, category *models.Category) error {
+	query := `
+		INSERT INTO categories (id, parent_id, name_zh, name_en, icon, sort_order, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		category.ID, nullString(category.ParentID), category.NameZh, category.NameEn,
+		nullString(category.Icon), category.SortOrder, category.CreatedAt,
+	)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// CategoryCounts 获取各分类下的公开智能体数量
+// 结果缓存在 Redis 中，智能体增删改时失效
+func (s *Storage) CategoryCounts(ctx context.Context) (map[string]int64, error) {
+	if cached, err := s.redis.HGetAll(ctx, categoryCountsKey).Result(); err == nil && len(cached) > 0 {
+		counts := make(map[string]int64, len(cached))
+		for id, value := range cached {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				counts[id] = n
+			}
+		}
+		return counts, nil
+	}
+
+	query := `
+		SELECT category, COUNT(*)
+		FROM agents
+		WHERE visibility = 'public' AND category IS NOT NULL AND category <> ''
+		GROUP BY category
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var n int64
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(counts) > 0 {
+		values := make(map[string]interface{}, len(counts))
+		for id, n := range counts {
+			values[id] = n
+		}
+		pipe := s.redis.TxPipeline()
+		pipe.HSet(ctx, categoryCountsKey, values)
+		pipe.Expire(ctx, categoryCountsKey, categoryCountsTTL)
+		pipe.Exec(ctx)
+	}
+
+	return counts, nil
+}
+
+// invalidateCategoryCounts 清除分类计数缓存
+func (s *Storage) invalidateCategoryCounts(ctx context.Context) {
+	s.redis.Del(ctx, categoryCountsKey)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+	category := &models.Category{}
+	var parentID, icon sql.NullString
+	err := row.Scan(
+		&category.ID, &parentID, &category.NameZh, &category.NameEn,
+		&icon, &category.SortOrder, &category.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	category.ParentID = parentID.String
+	category.Icon = icon.String
+	return category, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
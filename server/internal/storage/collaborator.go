@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// ===== Collaborator 操作 =====
+
+// UpsertCollaborator 邀请协作者，已存在时只更新权限
+func (s *Storage) UpsertCollaborator(ctx context.Context, collab *models.AgentCollaborator) error {
+	query := `
+		INSERT INTO agent_collaborators (id, agent_id, user_id, permission, status, invited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (agent_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+		RETURNING id, status
+	`
+	return s.db.QueryRowContext(ctx, query,
+		collab.ID, collab.AgentID, collab.UserID, collab.Permission,
+		collab.Status, collab.InvitedBy, collab.CreatedAt,
+	).Scan(&collab.ID, &collab.Status)
+}
+
+// GetCollaborator 获取用户在智能体上的协作授权
+func (s *Storage) GetCollaborator(ctx context.Context, agentID, userID string) (*models.AgentCollaborator, error) {
+	query := `
+		SELECT c.id, c.agent_id, c.user_id, u.username, c.permission, c.status, c.invited_by, c.created_at, c.accepted_at
+		FROM agent_collaborators c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.agent_id = $1 AND c.user_id = $2
+	`
+	return scanCollaborator(s.db.QueryRowContext(ctx, query, agentID, userID))
+}
+
+// ListCollaborators 列出智能体的协作者
+func (s *Storage) ListCollaborators(ctx context.Context, agentID string) ([]*models.AgentCollaborator, error) {
+	query := `
+		SELECT c.id, c.agent_id, c.user_id, u.username, c.permission, c.status, c.invited_by, c.created_at, c.accepted_at
+		FROM agent_collaborators c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.agent_id = $1
+		ORDER BY c.created_at ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, agentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var collabs []*models.AgentCollaborator
+	for rows.Next() {
+		collab, err := scanCollaborator(rows)
+		if err != nil {
+			return nil, err
+		}
+		collabs = append(collabs, collab)
+	}
+	return collabs, rows.Err()
+}
+
+// ListInvitations 列出用户待接受的协作邀请
+func (s *Storage) ListInvitations(ctx context.Context, userID string) ([]*models.AgentCollaborator, error) {
+	query := `
+		SELECT c.id, c.agent_id, c.user_id, u.username, c.permission, c.status, c.invited_by, c.created_at, c.accepted_at,
+			a.namespace, a.name, a.description
+		FROM agent_collaborators c
+		JOIN users u ON u.id = c.user_id
+		JOIN agents a ON a.id = c.agent_id
+		WHERE c.user_id = $1 AND c.status = 'pending'
+		ORDER BY c.created_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*models.AgentCollaborator
+	for rows.Next() {
+		collab := &models.AgentCollaborator{Agent: &models.Agent{}}
+		var acceptedAt sql.NullTime
+		var description sql.NullString
+		err := rows.Scan(
+			&collab.ID, &collab.AgentID, &collab.UserID, &collab.Username, &collab.Permission,
+			&collab.Status, &collab.InvitedBy, &collab.CreatedAt, &acceptedAt,
+			&collab.Agent.Namespace, &collab.Agent.Name, &description,
+		)
+		if err != nil {
+			return nil, err
+		}
+		collab.Agent.ID = collab.AgentID
+		collab.Agent.Description = description.String
+		collab.Agent.FullName = fmt.Sprintf("%s/%s", collab.Agent.Namespace, collab.Agent.Name)
+		invitations = append(invitations, collab)
+	}
+	return invitations, rows.Err()
+}
+
+// AcceptInvitation 接受协作邀请，返回对应的智能体 ID
+func (s *Storage) AcceptInvitation(ctx context.Context, id, userID string) (string, error) {
+	query := `
+		UPDATE agent_collaborators
+		SET status = 'accepted', accepted_at = $1
+		WHERE id = $2 AND user_id = $3 AND status = 'pending'
+		RETURNING agent_id
+	`
+	var agentID string
+	err := s.db.QueryRowContext(ctx, query, time.Now(), id, userID).Scan(&agentID)
+	return agentID, err
+}
+
+// DeleteInvitation 拒绝协作邀请，返回对应的智能体 ID
+func (s *Storage) DeleteInvitation(ctx context.Context, id, userID string) (string, error) {
+	query := `
+		DELETE FROM agent_collaborators
+		WHERE id = $1 AND user_id = $2 AND status = 'pending'
+		RETURNING agent_id
+	`
+	var agentID string
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(&agentID)
+	return agentID, err
+}
+
+// DeleteCollaborator 移除协作者
+func (s *Storage) DeleteCollaborator(ctx context.Context, agentID, userID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM agent_collaborators WHERE agent_id = $1 AND user_id = $2`, agentID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func scanCollaborator(row rowScanner) (*models.AgentCollaborator, error) {
+	collab := &models.AgentCollaborator{}
+	var acceptedAt sql.NullTime
+	err := row.Scan(
+		&collab.ID, &collab.AgentID, &collab.UserID, &collab.Username, &collab.Permission,
+		&collab.Status, &collab.InvitedBy, &collab.CreatedAt, &acceptedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if acceptedAt.Valid {
+		collab.AcceptedAt = &acceptedAt.Time
+	}
+	return collab, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/agenthub/server/internal/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+// slowCommandThreshold 超过该耗时的 Redis 命令记录警告日志
+const slowCommandThreshold = 100 * time.Millisecond
+
+// redisLogHook 使用 context 中的 logger 记录 Redis 错误和慢命令，日志带有发起请求的 request_id
+type redisLogHook struct{}
+
+func (redisLogHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (redisLogHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		logRedis(ctx, cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (redisLogHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		logRedis(ctx, "pipeline", start, err)
+		return err
+	}
+}
+
+func logRedis(ctx context.Context, command string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	logger := logging.FromContext(ctx)
+	switch {
+	case err != nil && err != redis.Nil && !errors.Is(err, context.Canceled):
+		logger.Warn("redis command failed", "command", command, "duration", elapsed, "error", err)
+	case elapsed > slowCommandThreshold && command != "brpop":
+		logger.Warn("slow redis command", "command", command, "duration", elapsed)
+	}
+}
@@ -0,0 +1,342 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+)
+
+// ===== Agent 操作 =====
+
+// CreateAgent 创建智能体
+func (s *Store) CreateAgent(ctx context.Context, agent *models.Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findAgent(agent.Namespace, agent.Name) != nil {
+		return errConflict
+	}
+	a := *agent
+	a.FullName = fmt.Sprintf("%s/%s", a.Namespace, a.Name)
+	s.agents[a.ID] = &a
+	return nil
+}
+
+// GetAgent 获取智能体
+func (s *Store) GetAgent(ctx context.Context, namespace, name string) (*models.Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	agent := s.findAgent(namespace, name)
+	if agent == nil {
+		return nil, errNotFound
+	}
+	a := *agent
+	return &a, nil
+}
+
+// GetAgentByID 通过ID获取智能体
+func (s *Store) GetAgentByID(ctx context.Context, id string) (*models.Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	agent, ok := s.agents[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	a := *agent
+	return &a, nil
+}
+
+// ListAgents 列出智能体，过滤、排序和分页规则与 PostgreSQL 实现一致
+func (s *Store) ListAgents(ctx context.Context, opts storage.ListAgentsOptions) ([]*models.Agent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	search := strings.ToLower(opts.Search)
+	var matched []*models.Agent
+	for _, agent := range s.agents {
+		if !opts.IncludePrivate && agent.Visibility != models.VisibilityPublic {
+			continue
+		}
+		if opts.Category != "" && agent.Category != opts.Category {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(agent.Name), search) &&
+			!strings.Contains(strings.ToLower(agent.Description), search) {
+			continue
+		}
+		if opts.Author != "" && agent.Namespace != opts.Author {
+			continue
+		}
+		a := *agent
+		matched = append(matched, &a)
+	}
+
+	// before a 是否排在 b 之前，排序键相同时按 ID
+	sortKey := storage.AgentSort(opts.Sort)
+	before := func(a, b *models.Agent) bool {
+		switch sortKey {
+		case storage.SortDownloads:
+			if a.Downloads != b.Downloads {
+				return a.Downloads > b.Downloads
+			}
+		case storage.SortLikes:
+			if a.Likes != b.Likes {
+				return a.Likes > b.Likes
+			}
+		case storage.SortName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.ID < b.ID
+		default:
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+		}
+		return a.ID > b.ID
+	}
+	sort.Slice(matched, func(i, j int) bool { return before(matched[i], matched[j]) })
+
+	if opts.After != nil {
+		// 用游标构造排序键相同的记录，跳过排在它之前 (含) 的部分
+		pivot := &models.Agent{ID: opts.After.ID}
+		switch sortKey {
+		case storage.SortDownloads:
+			pivot.Downloads = opts.After.Int()
+		case storage.SortLikes:
+			pivot.Likes = opts.After.Int()
+		case storage.SortName:
+			pivot.Name = opts.After.Value
+		default:
+			pivot.UpdatedAt = opts.After.Time()
+		}
+		start := sort.Search(len(matched), func(i int) bool { return before(pivot, matched[i]) })
+		matched = matched[start:]
+	}
+	if opts.Limit > 0 && len(matched) > opts.Limit {
+		matched = matched[:opts.Limit]
+	}
+	return matched, nil
+}
+
+// UpdateAgent 更新智能体
+func (s *Store) UpdateAgent(ctx context.Context, agent *models.Agent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.agents[agent.ID]
+	if !ok {
+		return nil
+	}
+	existing.Description = agent.Description
+	existing.Category = agent.Category
+	existing.Tags = append([]string(nil), agent.Tags...)
+	existing.License = agent.License
+	existing.Visibility = agent.Visibility
+	existing.Homepage = agent.Homepage
+	existing.Repository = agent.Repository
+	existing.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteAgent 删除智能体，级联删除版本、重定向和协作者
+func (s *Store) DeleteAgent(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.agents, id)
+	for vid, v := range s.versions {
+		if v.AgentID == id {
+			delete(s.versions, vid)
+		}
+	}
+	for key, agentID := range s.redirects {
+		if agentID == id {
+			delete(s.redirects, key)
+		}
+	}
+	for cid, c := range s.collabs {
+		if c.AgentID == id {
+			delete(s.collabs, cid)
+		}
+	}
+	return nil
+}
+
+// IncrementDownloads 增加下载次数
+func (s *Store) IncrementDownloads(ctx context.Context, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if agent, ok := s.agents[agentID]; ok {
+		agent.Downloads++
+	}
+	return nil
+}
+
+// MoveAgent 转移或重命名智能体，并为旧名称保留重定向记录，作者更新为 agent.AuthorID
+func (s *Store) MoveAgent(ctx context.Context, agent *models.Agent, newNamespace, newName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.agents[agent.ID]
+	if !ok {
+		return errNotFound
+	}
+	if other := s.findAgent(newNamespace, newName); other != nil && other.ID != agent.ID {
+		return errConflict
+	}
+
+	now := time.Now()
+	s.redirects[existing.Namespace+"/"+existing.Name] = existing.ID
+	delete(s.redirects, newNamespace+"/"+newName)
+
+	existing.Namespace = newNamespace
+	existing.Name = newName
+	existing.FullName = fmt.Sprintf("%s/%s", newNamespace, newName)
+	existing.AuthorID = agent.AuthorID
+	existing.UpdatedAt = now
+
+	agent.Namespace = newNamespace
+	agent.Name = newName
+	agent.FullName = existing.FullName
+	agent.UpdatedAt = now
+	return nil
+}
+
+// GetAgentByRedirect 通过旧名称查找智能体
+func (s *Store) GetAgentByRedirect(ctx context.Context, namespace, name string) (*models.Agent, error) {
+	s.mu.RLock()
+	agentID, ok := s.redirects[namespace+"/"+name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, errNotFound
+	}
+	return s.GetAgentByID(ctx, agentID)
+}
+
+// DeleteAgentRedirect 删除重定向记录
+func (s *Store) DeleteAgentRedirect(ctx context.Context, namespace, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.redirects, namespace+"/"+name)
+	return nil
+}
+
+// findAgent 按名称查找智能体，调用方需持有锁
+func (s *Store) findAgent(namespace, name string) *models.Agent {
+	for _, agent := range s.agents {
+		if agent.Namespace == namespace && agent.Name == name {
+			return agent
+		}
+	}
+	return nil
+}
+
+// ===== Version 操作 =====
+
+// CreateVersion 创建版本，新版本为最新版本时将其他版本的 is_latest 设为 false
+func (s *Store) CreateVersion(ctx context.Context, version *models.AgentVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.agents[version.AgentID]; !ok {
+		return errNotFound
+	}
+	for _, v := range s.versions {
+		if v.AgentID == version.AgentID && v.Version == version.Version {
+			return errConflict
+		}
+	}
+	if version.IsLatest {
+		for _, v := range s.versions {
+			if v.AgentID == version.AgentID {
+				v.IsLatest = false
+			}
+		}
+	}
+	v := *version
+	for _, f := range v.Files {
+		c := *f
+		c.VersionID = v.ID
+		s.files[v.ID] = append(s.files[v.ID], &c)
+	}
+	v.Files = nil
+	s.versions[v.ID] = &v
+	return nil
+}
+
+// GetVersion 获取特定版本
+func (s *Store) GetVersion(ctx context.Context, agentID, version string) (*models.AgentVersion, error) {
+	return s.findVersion(func(v *models.AgentVersion) bool {
+		return v.AgentID == agentID && v.Version == version
+	})
+}
+
+// GetLatestVersion 获取最新版本
+func (s *Store) GetLatestVersion(ctx context.Context, agentID string) (*models.AgentVersion, error) {
+	return s.findVersion(func(v *models.AgentVersion) bool {
+		return v.AgentID == agentID && v.IsLatest
+	})
+}
+
+// ListVersions 列出版本，按发布时间倒序分页
+func (s *Store) ListVersions(ctx context.Context, agentID string, page storage.PageOptions) ([]*models.AgentVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var versions []*models.AgentVersion
+	for _, v := range s.versions {
+		if v.AgentID == agentID {
+			c := *v
+			versions = append(versions, &c)
+		}
+	}
+	return pageRecent(versions, func(v *models.AgentVersion) (time.Time, string) { return v.PublishedAt, v.ID }, page), nil
+}
+
+// UpdateVersionStatus 更新版本状态
+func (s *Store) UpdateVersionStatus(ctx context.Context, id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.versions[id]; ok {
+		v.Status = status
+	}
+	return nil
+}
+
+// ListFiles 列出版本包中的文件，按路径排序
+func (s *Store) ListFiles(ctx context.Context, versionID string) ([]*models.AgentFile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var files []*models.AgentFile
+	for _, f := range s.files[versionID] {
+		c := *f
+		files = append(files, &c)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (s *Store) findVersion(match func(*models.AgentVersion) bool) (*models.AgentVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.versions {
+		if match(v) {
+			c := *v
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+)
+
+func createAgent(t *testing.T, s *Store, namespace, name string, downloads int64, updated time.Time) *models.Agent {
+	t.Helper()
+	agent := &models.Agent{
+		ID:         fmt.Sprintf("agent-%s-%s", namespace, name),
+		Namespace:  namespace,
+		Name:       name,
+		Visibility: models.VisibilityPublic,
+		Downloads:  downloads,
+		UpdatedAt:  updated,
+	}
+	if err := s.CreateAgent(context.Background(), agent); err != nil {
+		t.Fatal(err)
+	}
+	return agent
+}
+
+func TestCreateAgentDuplicate(t *testing.T) {
+	s := New()
+	createAgent(t, s, "alice", "helper", 0, time.Now())
+
+	err := s.CreateAgent(context.Background(), &models.Agent{ID: "other", Namespace: "alice", Name: "helper"})
+	if !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("CreateAgent with a taken name returned %v, want ErrDuplicate", err)
+	}
+}
+
+func TestCreateVersion(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	agent := createAgent(t, s, "alice", "helper", 0, time.Now())
+
+	publish := func(id, version string, latest bool) error {
+		return s.CreateVersion(ctx, &models.AgentVersion{
+			ID:          id,
+			AgentID:     agent.ID,
+			Version:     version,
+			IsLatest:    latest,
+			PublishedAt: time.Now(),
+		})
+	}
+	latest := func() string {
+		t.Helper()
+		v, err := s.GetLatestVersion(ctx, agent.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.Version
+	}
+
+	if err := publish("v1", "1.0.0", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := publish("v2", "1.1.0", true); err != nil {
+		t.Fatal(err)
+	}
+	if got := latest(); got != "1.1.0" {
+		t.Errorf("latest version = %s, want 1.1.0", got)
+	}
+	if v, err := s.GetVersion(ctx, agent.ID, "1.0.0"); err != nil || v.IsLatest {
+		t.Errorf("previous version after publishing: %+v, %v", v, err)
+	}
+
+	// 与 PostgreSQL 一致：非最新版本不改变 is_latest
+	if err := publish("v3", "0.9.0", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := latest(); got != "1.1.0" {
+		t.Errorf("latest version after publishing a non-latest version = %s, want 1.1.0", got)
+	}
+
+	if err := publish("v4", "1.1.0", true); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("duplicate version returned %v, want ErrDuplicate", err)
+	}
+	if got := latest(); got != "1.1.0" {
+		t.Errorf("rejected duplicate changed the latest version to %s", got)
+	}
+
+	err := s.CreateVersion(ctx, &models.AgentVersion{ID: "v5", AgentID: "missing", Version: "1.0.0", IsLatest: true})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("version for a missing agent returned %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestListAgentsKeysetPagination(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	now := time.Now()
+	// 下载量和更新时间有重复，翻页依赖 ID 作为次序
+	for i := 0; i < 7; i++ {
+		createAgent(t, s, "alice", fmt.Sprintf("agent-%d", i), int64(i%3), now.Add(-time.Duration(i/2)*time.Minute))
+	}
+
+	for _, sort := range []string{storage.SortUpdated, storage.SortDownloads, storage.SortName} {
+		t.Run(sort, func(t *testing.T) {
+			all, err := s.ListAgents(ctx, storage.ListAgentsOptions{Sort: sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 7 {
+				t.Fatalf("got %d agents, want 7", len(all))
+			}
+
+			var paged []string
+			opts := storage.ListAgentsOptions{Sort: sort, PageOptions: storage.PageOptions{Limit: 3}}
+			for {
+				page, err := s.ListAgents(ctx, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, a := range page {
+					paged = append(paged, a.ID)
+				}
+				if len(page) < opts.Limit {
+					break
+				}
+				cursor := storage.AgentCursor(page[len(page)-1], sort)
+				opts.After = &cursor
+			}
+
+			if len(paged) != len(all) {
+				t.Fatalf("paging returned %d agents, want %d", len(paged), len(all))
+			}
+			for i, a := range all {
+				if paged[i] != a.ID {
+					t.Errorf("page position %d = %s, want %s", i, paged[i], a.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestListVersionsKeysetPagination(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	agent := createAgent(t, s, "alice", "helper", 0, time.Now())
+
+	// 两个版本发布时间相同，按 ID 倒序
+	base := time.Now().Truncate(time.Second)
+	published := []time.Time{base, base.Add(time.Second), base.Add(time.Second), base.Add(2 * time.Second)}
+	for i, at := range published {
+		err := s.CreateVersion(ctx, &models.AgentVersion{
+			ID:          fmt.Sprintf("v%d", i),
+			AgentID:     agent.ID,
+			Version:     fmt.Sprintf("1.0.%d", i),
+			IsLatest:    true,
+			PublishedAt: at,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	page := storage.PageOptions{Limit: 1}
+	for {
+		versions, err := s.ListVersions(ctx, agent.ID, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) == 0 {
+			break
+		}
+		last := versions[len(versions)-1]
+		got = append(got, last.ID)
+		cursor := storage.RecentCursor(last.PublishedAt, last.ID)
+		page.After = &cursor
+	}
+
+	want := []string{"v3", "v2", "v1", "v0"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged versions = %v, want %v", got, want)
+	}
+}
+
+func TestMoveAgentRedirects(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	agent := createAgent(t, s, "alice", "helper", 0, time.Now())
+	createAgent(t, s, "alice", "taken", 0, time.Now())
+
+	if err := s.MoveAgent(ctx, agent, "alice", "taken"); !errors.Is(err, storage.ErrDuplicate) {
+		t.Fatalf("move onto a taken name returned %v, want ErrDuplicate", err)
+	}
+
+	if err := s.MoveAgent(ctx, agent, "alice", "assistant"); err != nil {
+		t.Fatal(err)
+	}
+	if agent.FullName != "alice/assistant" {
+		t.Errorf("moved agent full name = %s", agent.FullName)
+	}
+	if _, err := s.GetAgent(ctx, "alice", "helper"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetAgent by the old name returned %v, want sql.ErrNoRows", err)
+	}
+	if got, err := s.GetAgentByRedirect(ctx, "alice", "helper"); err != nil || got.ID != agent.ID {
+		t.Errorf("redirect from the old name: %+v, %v", got, err)
+	}
+
+	// 多次重命名时历史名称都指向同一个智能体
+	if err := s.MoveAgent(ctx, agent, "alice", "bot"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"helper", "assistant"} {
+		if got, err := s.GetAgentByRedirect(ctx, "alice", name); err != nil || got.FullName != "alice/bot" {
+			t.Errorf("redirect from alice/%s: %+v, %v", name, got, err)
+		}
+	}
+
+	// 改回旧名称后该名称不再是重定向
+	if err := s.MoveAgent(ctx, agent, "alice", "helper"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetAgentByRedirect(ctx, "alice", "helper"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("redirect for the current name returned %v, want sql.ErrNoRows", err)
+	}
+
+	// 删除智能体时一并删除重定向
+	if err := s.DeleteAgent(ctx, agent.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetAgentByRedirect(ctx, "alice", "bot"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("redirect after delete returned %v, want sql.ErrNoRows", err)
+	}
+}
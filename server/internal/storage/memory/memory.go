@@ -0,0 +1,144 @@
+// Package memory 提供进程内存储实现，用于开发模式 (agenthub-server --dev) 和无依赖的处理器测试
+// 数据只保存在内存中，进程退出后丢失
+package memory
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+)
+
+var _ storage.Store = (*Store)(nil)
+
+// errNotFound 与 PostgreSQL 实现保持一致，未找到时返回 sql.ErrNoRows
+var errNotFound = sql.ErrNoRows
+
+// errConflict 唯一约束冲突
+var errConflict = storage.ErrDuplicate
+
+// Store 进程内存储
+type Store struct {
+	mu sync.RWMutex
+
+	agents     map[string]*models.Agent        // id -> agent
+	versions   map[string]*models.AgentVersion // id -> version
+	files      map[string][]*models.AgentFile  // version id -> files
+	redirects  map[string]string               // namespace/name -> agent id
+	users      map[string]*models.User         // id -> user
+	orgMembers map[string]map[string]string    // org -> user id -> role
+	apiKeys    map[string]*models.APIKey       // id -> key
+	categories map[string]*models.Category     // id -> category
+	collabs    map[string]*models.AgentCollaborator
+	audit      []*models.AuditEvent
+	webhooks   map[string]*models.Webhook
+	deliveries map[string]*models.WebhookDelivery
+
+	queues    map[string][]string
+	scheduled map[string]map[string]time.Time
+	notify    chan struct{}
+	hits      map[string][]time.Time // 速率限制：窗口内的请求时间
+}
+
+// New 创建进程内存储，并写入默认分类
+func New() *Store {
+	s := &Store{
+		agents:     make(map[string]*models.Agent),
+		versions:   make(map[string]*models.AgentVersion),
+		files:      make(map[string][]*models.AgentFile),
+		redirects:  make(map[string]string),
+		users:      make(map[string]*models.User),
+		orgMembers: make(map[string]map[string]string),
+		apiKeys:    make(map[string]*models.APIKey),
+		categories: make(map[string]*models.Category),
+		collabs:    make(map[string]*models.AgentCollaborator),
+		webhooks:   make(map[string]*models.Webhook),
+		deliveries: make(map[string]*models.WebhookDelivery),
+		queues:     make(map[string][]string),
+		scheduled:  make(map[string]map[string]time.Time),
+		notify:     make(chan struct{}),
+		hits:       make(map[string][]time.Time),
+	}
+	s.seedCategories()
+	return s
+}
+
+// pageRecent 按时间倒序 (时间相同时按 ID 倒序) 排列，返回游标之后的 page.Limit 条
+// 排序规则与 PostgreSQL 实现的 ORDER BY created_at DESC, id DESC 一致
+func pageRecent[T any](items []T, key func(T) (time.Time, string), page storage.PageOptions) []T {
+	before := func(a, b T) bool {
+		at, aid := key(a)
+		bt, bid := key(b)
+		return recentBefore(at, aid, bt, bid)
+	}
+	sort.Slice(items, func(i, j int) bool { return before(items[i], items[j]) })
+
+	if page.After != nil {
+		t, id := page.After.Time(), page.After.ID
+		start := sort.Search(len(items), func(i int) bool {
+			it, iid := key(items[i])
+			return recentBefore(t, id, it, iid)
+		})
+		items = items[start:]
+	}
+	if page.Limit > 0 && len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return items
+}
+
+// recentBefore (at, aid) 是否排在 (bt, bid) 之前
+func recentBefore(at time.Time, aid string, bt time.Time, bid string) bool {
+	if !at.Equal(bt) {
+		return at.After(bt)
+	}
+	return aid > bid
+}
+
+// Close 关闭存储
+func (s *Store) Close() error {
+	return nil
+}
+
+// AddOrgMember 添加组织成员，内存存储没有组织管理接口，供开发和测试时预置数据
+func (s *Store) AddOrgMember(orgName, userID, role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.orgMembers[orgName] == nil {
+		s.orgMembers[orgName] = make(map[string]string)
+	}
+	s.orgMembers[orgName][userID] = role
+}
+
+// seedCategories 写入与 migrations/002_categories.up.sql 相同的默认分类
+func (s *Store) seedCategories() {
+	defaults := []struct {
+		id, zh, en, icon string
+	}{
+		{"assistant", "通用助手", "Assistant", "bot"},
+		{"coding", "编程开发", "Coding", "code"},
+		{"writing", "写作创作", "Writing", "pen-tool"},
+		{"analysis", "数据分析", "Analysis", "bar-chart"},
+		{"creative", "创意设计", "Creative", "palette"},
+		{"education", "教育学习", "Education", "graduation-cap"},
+		{"business", "商业办公", "Business", "briefcase"},
+		{"research", "研究探索", "Research", "sparkles"},
+		{"tooling", "工具效率", "Tooling", "wrench"},
+		{"other", "其他", "Other", "more-horizontal"},
+	}
+	now := time.Now()
+	for i, c := range defaults {
+		s.categories[c.id] = &models.Category{
+			ID:        c.id,
+			NameZh:    c.zh,
+			NameEn:    c.en,
+			Icon:      c.icon,
+			SortOrder: (i + 1) * 10,
+			CreatedAt: now,
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/agenthub/server/internal/storage"
+)
+
+// ===== 队列 =====
+
+// Push 将任务追加到队列并唤醒等待中的 Pop
+func (s *Store) Push(ctx context.Context, queue, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queues[queue] = append(s.queues[queue], value)
+	s.broadcast()
+	return nil
+}
+
+// Pop 按先进先出取出任务，timeout 内无任务时返回 storage.ErrQueueEmpty
+func (s *Store) Pop(ctx context.Context, timeout time.Duration, queues ...string) (string, string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		for _, queue := range queues {
+			if items := s.queues[queue]; len(items) > 0 {
+				s.queues[queue] = items[1:]
+				s.mu.Unlock()
+				return queue, items[0], nil
+			}
+		}
+		wait := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-timer.C:
+			return "", "", storage.ErrQueueEmpty
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		}
+	}
+}
+
+// Schedule 在 at 时刻之后将任务放入队列
+func (s *Store) Schedule(ctx context.Context, queue, value string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scheduled[queue] == nil {
+		s.scheduled[queue] = make(map[string]time.Time)
+	}
+	s.scheduled[queue][value] = at
+	return nil
+}
+
+// PromoteDue 将到期的延迟任务移入队列
+func (s *Store) PromoteDue(ctx context.Context, queue string, limit int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	moved := 0
+	for value, at := range s.scheduled[queue] {
+		if moved >= limit {
+			break
+		}
+		if at.After(now) {
+			continue
+		}
+		delete(s.scheduled[queue], value)
+		s.queues[queue] = append(s.queues[queue], value)
+		moved++
+	}
+	if moved > 0 {
+		s.broadcast()
+	}
+	return moved, nil
+}
+
+// broadcast 唤醒所有等待者，调用方需持有锁
+func (s *Store) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// ===== 速率限制 =====
+
+// Allow 滑动窗口计数，与 Redis 实现语义一致
+func (s *Store) Allow(ctx context.Context, key string, limit int, window time.Duration) (*storage.RateLimitResult, error) {
+	return s.slidingWindow(key, limit, window, true), nil
+}
+
+// Peek 查询滑动窗口状态，不计数
+func (s *Store) Peek(ctx context.Context, key string, limit int, window time.Duration) (*storage.RateLimitResult, error) {
+	return s.slidingWindow(key, limit, window, false), nil
+}
+
+// Reset 清除计数
+func (s *Store) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.hits, key)
+	return nil
+}
+
+// Refund 撤销最近的一次计数
+func (s *Store) Refund(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if hits := s.hits[key]; len(hits) > 1 {
+		s.hits[key] = hits[:len(hits)-1]
+	} else {
+		delete(s.hits, key)
+	}
+	return nil
+}
+
+func (s *Store) slidingWindow(key string, limit int, window time.Duration, record bool) *storage.RateLimitResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	hits := s.hits[key]
+	for len(hits) > 0 && !hits[0].After(now.Add(-window)) {
+		hits = hits[1:]
+	}
+
+	allowed := len(hits) < limit
+	if allowed && record {
+		hits = append(hits, now)
+	}
+	if len(hits) == 0 {
+		delete(s.hits, key)
+	} else {
+		s.hits[key] = hits
+	}
+
+	resetAt := now.Add(window)
+	if len(hits) > 0 {
+		resetAt = hits[0].Add(window)
+	}
+	remaining := limit - len(hits)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &storage.RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: remaining,
+		ResetAt:   resetAt,
+	}
+}
@@ -0,0 +1,377 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+)
+
+// ===== Category 操作 =====
+
+// ListCategories 列出所有分类
+func (s *Store) ListCategories(ctx context.Context) ([]*models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := make([]*models.Category, 0, len(s.categories))
+	for _, c := range s.categories {
+		category := *c
+		categories = append(categories, &category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+// GetCategory 获取分类
+func (s *Store) GetCategory(ctx context.Context, id string) (*models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.categories[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	category := *c
+	return &category, nil
+}
+
+// CreateCategory 创建分类
+func (s *Store) CreateCategory(ctx context.Context, category *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.categories[category.ID]; ok {
+		return errConflict
+	}
+	c := *category
+	s.categories[c.ID] = &c
+	return nil
+}
+
+// CategoryCounts 统计各分类下的公开智能体数量
+func (s *Store) CategoryCounts(ctx context.Context) (map[string]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, agent := range s.agents {
+		if agent.Visibility == models.VisibilityPublic && agent.Category != "" {
+			counts[agent.Category]++
+		}
+	}
+	return counts, nil
+}
+
+// ===== Collaborator 操作 =====
+
+// UpsertCollaborator 邀请协作者，已存在时只更新权限
+func (s *Store) UpsertCollaborator(ctx context.Context, collab *models.AgentCollaborator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.collabs {
+		if c.AgentID == collab.AgentID && c.UserID == collab.UserID {
+			c.Permission = collab.Permission
+			collab.ID = c.ID
+			collab.Status = c.Status
+			return nil
+		}
+	}
+	c := *collab
+	s.collabs[c.ID] = &c
+	return nil
+}
+
+// GetCollaborator 获取用户在智能体上的协作授权
+func (s *Store) GetCollaborator(ctx context.Context, agentID, userID string) (*models.AgentCollaborator, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, c := range s.collabs {
+		if c.AgentID == agentID && c.UserID == userID {
+			return s.collaboratorCopy(c), nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// ListCollaborators 列出智能体的协作者
+func (s *Store) ListCollaborators(ctx context.Context, agentID string) ([]*models.AgentCollaborator, error) {
+	return s.listCollaborators(func(c *models.AgentCollaborator) bool {
+		return c.AgentID == agentID
+	}, false), nil
+}
+
+// ListInvitations 列出用户待接受的协作邀请
+func (s *Store) ListInvitations(ctx context.Context, userID string) ([]*models.AgentCollaborator, error) {
+	return s.listCollaborators(func(c *models.AgentCollaborator) bool {
+		return c.UserID == userID && c.Status == models.CollaboratorPending
+	}, true), nil
+}
+
+// AcceptInvitation 接受协作邀请，返回对应的智能体 ID
+func (s *Store) AcceptInvitation(ctx context.Context, id, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.collabs[id]
+	if !ok || c.UserID != userID || c.Status != models.CollaboratorPending {
+		return "", errNotFound
+	}
+	now := time.Now()
+	c.Status = models.CollaboratorAccepted
+	c.AcceptedAt = &now
+	return c.AgentID, nil
+}
+
+// DeleteInvitation 拒绝协作邀请，返回对应的智能体 ID
+func (s *Store) DeleteInvitation(ctx context.Context, id, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.collabs[id]
+	if !ok || c.UserID != userID || c.Status != models.CollaboratorPending {
+		return "", errNotFound
+	}
+	delete(s.collabs, id)
+	return c.AgentID, nil
+}
+
+// DeleteCollaborator 移除协作者
+func (s *Store) DeleteCollaborator(ctx context.Context, agentID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, c := range s.collabs {
+		if c.AgentID == agentID && c.UserID == userID {
+			delete(s.collabs, id)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (s *Store) listCollaborators(match func(*models.AgentCollaborator) bool, withAgent bool) []*models.AgentCollaborator {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var collabs []*models.AgentCollaborator
+	for _, c := range s.collabs {
+		if !match(c) {
+			continue
+		}
+		collab := s.collaboratorCopy(c)
+		if withAgent {
+			if agent, ok := s.agents[c.AgentID]; ok {
+				collab.Agent = &models.Agent{
+					ID:          agent.ID,
+					Namespace:   agent.Namespace,
+					Name:        agent.Name,
+					FullName:    fmt.Sprintf("%s/%s", agent.Namespace, agent.Name),
+					Description: agent.Description,
+				}
+			}
+		}
+		collabs = append(collabs, collab)
+	}
+	sort.Slice(collabs, func(i, j int) bool { return collabs[i].CreatedAt.Before(collabs[j].CreatedAt) })
+	return collabs
+}
+
+// collaboratorCopy 复制协作记录并补全用户名，调用方需持有锁
+func (s *Store) collaboratorCopy(c *models.AgentCollaborator) *models.AgentCollaborator {
+	collab := *c
+	if user, ok := s.users[c.UserID]; ok {
+		collab.Username = user.Username
+	}
+	return &collab
+}
+
+// ===== Audit 操作 =====
+
+// CreateAuditEvent 追加审计记录
+func (s *Store) CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := *event
+	s.audit = append(s.audit, &e)
+	return nil
+}
+
+// ListAuditEvents 按条件查询审计记录，按时间倒序分页
+func (s *Store) ListAuditEvents(ctx context.Context, q storage.AuditQuery) ([]*models.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []*models.AuditEvent
+	for _, e := range s.audit {
+		if q.AgentID != "" && e.AgentID != q.AgentID {
+			continue
+		}
+		if q.Namespace != "" && e.Namespace != q.Namespace && e.Before["namespace"] != q.Namespace {
+			continue
+		}
+		if q.ActorID != "" && e.ActorID != q.ActorID {
+			continue
+		}
+		if q.Action != "" && e.Action != q.Action {
+			continue
+		}
+		if !q.Since.IsZero() && e.CreatedAt.Before(q.Since) {
+			continue
+		}
+		if !q.Until.IsZero() && !e.CreatedAt.Before(q.Until) {
+			continue
+		}
+		event := *e
+		events = append(events, &event)
+	}
+	return pageRecent(events, func(e *models.AuditEvent) (time.Time, string) { return e.CreatedAt, e.ID }, q.PageOptions), nil
+}
+
+// ===== Webhook 操作 =====
+
+// CreateWebhook 创建 Webhook 订阅
+func (s *Store) CreateWebhook(ctx context.Context, hook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := *hook
+	h.Events = append([]string(nil), hook.Events...)
+	s.webhooks[h.ID] = &h
+	return nil
+}
+
+// GetWebhook 获取 Webhook
+func (s *Store) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hook, ok := s.webhooks[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	h := *hook
+	return &h, nil
+}
+
+// ListWebhooks 列出用户创建的 Webhook
+func (s *Store) ListWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error) {
+	return s.listWebhooks(func(h *models.Webhook) bool { return h.CreatedBy == userID }), nil
+}
+
+// MatchWebhooks 查找订阅了指定事件的 Webhook
+func (s *Store) MatchWebhooks(ctx context.Context, event, agentID, namespace string) ([]*models.Webhook, error) {
+	return s.listWebhooks(func(h *models.Webhook) bool {
+		if !h.IsActive || !contains(h.Events, event) {
+			return false
+		}
+		if h.Scope == models.WebhookScopeAgent {
+			return h.Target == agentID
+		}
+		return h.Target == namespace
+	}), nil
+}
+
+// DeleteWebhook 删除用户的 Webhook 及其投递记录
+func (s *Store) DeleteWebhook(ctx context.Context, id, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hook, ok := s.webhooks[id]
+	if !ok || hook.CreatedBy != userID {
+		return errNotFound
+	}
+	delete(s.webhooks, id)
+	for did, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (s *Store) listWebhooks(match func(*models.Webhook) bool) []*models.Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hooks []*models.Webhook
+	for _, hook := range s.webhooks {
+		if match(hook) {
+			h := *hook
+			hooks = append(hooks, &h)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].CreatedAt.After(hooks[j].CreatedAt) })
+	return hooks
+}
+
+// CreateWebhookDelivery 创建投递记录
+func (s *Store) CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := *d
+	s.deliveries[delivery.ID] = &delivery
+	return nil
+}
+
+// UpdateWebhookDelivery 更新投递结果
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return errNotFound
+	}
+	delivery := *d
+	s.deliveries[d.ID] = &delivery
+	return nil
+}
+
+// GetWebhookDelivery 获取投递记录
+func (s *Store) GetWebhookDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	delivery := *d
+	return &delivery, nil
+}
+
+// ListWebhookDeliveries 列出 Webhook 的投递记录，按时间倒序分页
+func (s *Store) ListWebhookDeliveries(ctx context.Context, webhookID string, page storage.PageOptions) ([]*models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var deliveries []*models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.WebhookID == webhookID {
+			delivery := *d
+			deliveries = append(deliveries, &delivery)
+		}
+	}
+	return pageRecent(deliveries, func(d *models.WebhookDelivery) (time.Time, string) { return d.CreatedAt, d.ID }, page), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// ===== User 操作 =====
+
+// CreateUser 创建用户，用户名和邮箱唯一
+func (s *Store) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == user.Username || u.Email == user.Email {
+			return errConflict
+		}
+	}
+	u := *user
+	s.users[u.ID] = &u
+	return nil
+}
+
+// GetUserByUsername 通过用户名获取用户
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return s.findUser(func(u *models.User) bool { return u.Username == username })
+}
+
+// GetUserByEmail 通过邮箱获取用户
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.findUser(func(u *models.User) bool { return u.Email == email })
+}
+
+// GetUserByID 通过ID获取用户
+func (s *Store) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return s.findUser(func(u *models.User) bool { return u.ID == id })
+}
+
+func (s *Store) findUser(match func(*models.User) bool) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if match(u) {
+			c := *u
+			return &c, nil
+		}
+	}
+	return &models.User{}, errNotFound
+}
+
+// ===== Organization 操作 =====
+
+// IsOrgMember 判断用户是否为组织成员
+func (s *Store) IsOrgMember(ctx context.Context, orgName, userID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.orgMembers[orgName][userID]
+	return ok, nil
+}
+
+// GetOrgRole 获取用户在组织中的角色，非成员返回 sql.ErrNoRows
+func (s *Store) GetOrgRole(ctx context.Context, orgName, userID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	role, ok := s.orgMembers[orgName][userID]
+	if !ok {
+		return "", errNotFound
+	}
+	return role, nil
+}
+
+// ===== API Key 操作 =====
+
+// CreateAPIKey 保存 API Key
+func (s *Store) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := *key
+	k.Scopes = append([]string(nil), key.Scopes...)
+	s.apiKeys[k.ID] = &k
+	return nil
+}
+
+// GetAPIKeyByHash 通过哈希查找 API Key
+func (s *Store) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == hash {
+			c := *k
+			return &c, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+// ListAPIKeys 列出用户的 API Key，按创建时间倒序
+func (s *Store) ListAPIKeys(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []*models.APIKey
+	for _, k := range s.apiKeys {
+		if k.UserID == userID {
+			c := *k
+			keys = append(keys, &c)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// DeleteAPIKey 删除用户的 API Key
+func (s *Store) DeleteAPIKey(ctx context.Context, userID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok || k.UserID != userID {
+		return errNotFound
+	}
+	delete(s.apiKeys, id)
+	return nil
+}
+
+// UpdateAPIKeyTier 修改 API Key 的配额等级
+func (s *Store) UpdateAPIKeyTier(ctx context.Context, id, tier string) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	k.Tier = tier
+	c := *k
+	return &c, nil
+}
+
+// TouchAPIKey 更新最后使用时间
+func (s *Store) TouchAPIKey(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if k, ok := s.apiKeys[id]; ok {
+		now := time.Now()
+		k.LastUsedAt = &now
+	}
+	return nil
+}
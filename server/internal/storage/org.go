@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+// ===== Organization 操作 =====
+
+// IsOrgMember 判断用户是否为组织成员
+func (s *Storage) IsOrgMember(ctx context.Context, orgName, userID string) (bool, error) {
+	query := `
+		SELECT 1
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		WHERE o.name = $1 AND m.user_id = $2
+	`
+	var one int
+	err := s.db.QueryRowContext(ctx, query, orgName, userID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetOrgRole 获取用户在组织中的角色，非成员返回 sql.ErrNoRows
+func (s *Storage) GetOrgRole(ctx context.Context, orgName, userID string) (string, error) {
+	query := `
+		SELECT m.role
+		FROM org_members m
+		JOIN organizations o ON o.id = m.org_id
+		WHERE o.name = $1 AND m.user_id = $2
+	`
+	var role string
+	err := s.db.QueryRowContext(ctx, query, orgName, userID).Scan(&role)
+	return role, err
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/google/uuid"
+)
+
+// ===== 分页 =====
+
+// ErrInvalidCursor 游标无法解析或与当前排序方式不匹配
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// 排序方式，同时作为游标的 Sort 字段，游标只能用于生成它的排序方式
+const (
+	SortUpdated   = "updated"   // 智能体按更新时间倒序 (默认)
+	SortDownloads = "downloads" // 智能体按下载量倒序
+	SortLikes     = "likes"     // 智能体按点赞数倒序
+	SortName      = "name"      // 智能体按名称升序
+	SortRecent    = "recent"    // 版本、审计记录、投递记录按时间倒序
+)
+
+// PageOptions 键集分页参数
+type PageOptions struct {
+	Limit int     // 最多返回的条数
+	After *Cursor // 从该位置之后开始读取，nil 表示第一页
+}
+
+// Cursor 键集分页位置：上一页最后一条记录的排序键和 ID
+// 翻页时从该位置之后继续读取而不是 OFFSET，列表变动时不会重复或遗漏，深翻页也不需要扫描前面的记录；
+// ID 作为排序键相同时的次序
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// AgentSort 规范化智能体的排序方式，未知的排序方式按更新时间
+func AgentSort(sort string) string {
+	switch sort {
+	case SortDownloads, SortLikes, SortName:
+		return sort
+	}
+	return SortUpdated
+}
+
+// AgentCursor 智能体在给定排序方式下的位置
+func AgentCursor(agent *models.Agent, sort string) Cursor {
+	c := Cursor{Sort: AgentSort(sort), ID: agent.ID}
+	switch c.Sort {
+	case SortDownloads:
+		c.Value = strconv.FormatInt(agent.Downloads, 10)
+	case SortLikes:
+		c.Value = strconv.FormatInt(agent.Likes, 10)
+	case SortName:
+		c.Value = agent.Name
+	default:
+		c.Value = agent.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+// RecentCursor 按时间倒序的列表中记录的位置
+func RecentCursor(t time.Time, id string) Cursor {
+	return Cursor{Sort: SortRecent, Value: t.UTC().Format(time.RFC3339Nano), ID: id}
+}
+
+// Validate 检查排序键能否按排序方式解析
+// 游标来自客户端，解析失败的值不能传给数据库，否则会变成 500
+func (c *Cursor) Validate() error {
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return ErrInvalidCursor
+	}
+	var err error
+	switch c.Sort {
+	case SortDownloads, SortLikes:
+		_, err = strconv.ParseInt(c.Value, 10, 64)
+	case SortUpdated, SortRecent:
+		_, err = time.Parse(time.RFC3339Nano, c.Value)
+	case SortName:
+	default:
+		err = ErrInvalidCursor
+	}
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Int 整数排序键，需先通过 Validate
+func (c *Cursor) Int() int64 {
+	n, _ := strconv.ParseInt(c.Value, 10, 64)
+	return n
+}
+
+// Time 时间排序键，需先通过 Validate
+func (c *Cursor) Time() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, c.Value)
+	return t
+}
+
+// arg 排序键的查询参数
+func (c *Cursor) arg() interface{} {
+	switch c.Sort {
+	case SortDownloads, SortLikes:
+		return c.Int()
+	case SortUpdated, SortRecent:
+		return c.Time()
+	}
+	return c.Value
+}
+
+// keysetCondition 生成 "位于游标之后" 的条件，column 和 id 组成行比较，与 ORDER BY column, id 的方向一致
+func keysetCondition(column string, desc bool, argIndex int) string {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	return fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", column, op, argIndex, argIndex+1)
+}
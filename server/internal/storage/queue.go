@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ===== 队列 (Redis) =====
+
+// Push 将任务追加到 Redis 列表
+func (s *Storage) Push(ctx context.Context, queue, value string) error {
+	return s.redis.LPush(ctx, queue, value).Err()
+}
+
+// Pop 阻塞取出任务，返回任务所在队列和任务内容
+func (s *Storage) Pop(ctx context.Context, timeout time.Duration, queues ...string) (string, string, error) {
+	res, err := s.redis.BRPop(ctx, timeout, queues...).Result()
+	if err == redis.Nil {
+		return "", "", ErrQueueEmpty
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return res[0], res[1], nil
+}
+
+// Schedule 将延迟任务写入有序集合，score 为到期的 unix 时间
+func (s *Storage) Schedule(ctx context.Context, queue, value string, at time.Time) error {
+	return s.redis.ZAdd(ctx, scheduledKey(queue), redis.Z{Score: float64(at.Unix()), Member: value}).Err()
+}
+
+// PromoteDue 将到期的延迟任务移入队列
+// 只有成功从有序集合移除的实例负责入队，多实例部署时不会重复投递
+func (s *Storage) PromoteDue(ctx context.Context, queue string, limit int) (int, error) {
+	key := scheduledKey(queue)
+	values, err := s.redis.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, value := range values {
+		if n, err := s.redis.ZRem(ctx, key, value).Result(); err == nil && n == 1 {
+			if err := s.redis.LPush(ctx, queue, value).Err(); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func scheduledKey(queue string) string {
+	return queue + ":scheduled"
+}
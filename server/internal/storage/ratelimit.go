@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ===== 速率限制 (Redis) =====
+
+// slidingWindowScript 滑动窗口日志，在一个脚本内完成清理、计数和写入，避免并发请求竞争
+// KEYS[1] 计数 key；ARGV: limit, window(ms), member, record(1 计数 / 0 只查询)
+// 返回 {allowed, count, reset_ms}，reset_ms 为窗口内最早一次请求过期的时刻
+// 使用 Redis 服务器时间，多个 API 副本之间不受本地时钟偏差影响
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local member = ARGV[3]
+local record = tonumber(ARGV[4])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+	allowed = 1
+	if record == 1 then
+		redis.call('ZADD', key, now, member)
+		redis.call('PEXPIRE', key, window)
+		count = count + 1
+	end
+end
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+return {allowed, count, reset}
+`)
+
+// Allow 滑动窗口计数
+func (s *Storage) Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	return s.slidingWindow(ctx, key, limit, window, true)
+}
+
+// Peek 查询滑动窗口状态，不计数
+func (s *Storage) Peek(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	return s.slidingWindow(ctx, key, limit, window, false)
+}
+
+// Reset 清除计数
+func (s *Storage) Reset(ctx context.Context, key string) error {
+	return s.redis.Del(ctx, "ratelimit:"+key).Err()
+}
+
+// Refund 撤销最近的一次计数
+// 并发时撤销的不一定是本次请求写入的成员，但窗口内的计数同样减一
+func (s *Storage) Refund(ctx context.Context, key string) error {
+	return s.redis.ZPopMax(ctx, "ratelimit:"+key).Err()
+}
+
+func (s *Storage) slidingWindow(ctx context.Context, key string, limit int, window time.Duration, record bool) (*RateLimitResult, error) {
+	flag := 0
+	if record {
+		flag = 1
+	}
+	// 同一毫秒内的多次请求需要不同的成员
+	member := fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
+
+	values, err := slidingWindowScript.Run(ctx, s.redis,
+		[]string{"ratelimit:" + key},
+		limit, window.Milliseconds(), member, flag,
+	).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	remaining := limit - int(values[1])
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: remaining,
+		ResetAt:   time.UnixMilli(values[2]),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// ===== Redirect 操作 =====
+
+// MoveAgent 转移或重命名智能体，并为旧名称保留重定向记录，作者更新为 agent.AuthorID
+// 目标名称已被占用时返回 ErrDuplicate
+func (s *Storage) MoveAgent(ctx context.Context, agent *models.Agent, newNamespace, newName string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE agents SET namespace = $1, name = $2, author_id = $3, updated_at = $4 WHERE id = $5`,
+		newNamespace, newName, agent.AuthorID, now, agent.ID,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+
+	// 旧名称指向该智能体；多次重命名时历史名称都指向同一个 agent_id
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO agent_redirects (namespace, name, agent_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (namespace, name) DO UPDATE SET agent_id = EXCLUDED.agent_id, created_at = EXCLUDED.created_at
+	`, agent.Namespace, agent.Name, agent.ID, now); err != nil {
+		return err
+	}
+
+	// 新名称已被真实智能体占用，不再需要重定向
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM agent_redirects WHERE namespace = $1 AND name = $2`, newNamespace, newName,
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.invalidateAgent(ctx, agent.Namespace, agent.Name)
+	s.invalidateAgent(ctx, newNamespace, newName)
+
+	agent.Namespace = newNamespace
+	agent.Name = newName
+	agent.FullName = fmt.Sprintf("%s/%s", newNamespace, newName)
+	agent.UpdatedAt = now
+	return nil
+}
+
+// GetAgentByRedirect 通过旧名称查找智能体
+func (s *Storage) GetAgentByRedirect(ctx context.Context, namespace, name string) (*models.Agent, error) {
+	var agentID string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT agent_id FROM agent_redirects WHERE namespace = $1 AND name = $2`, namespace, name,
+	).Scan(&agentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAgentByID(ctx, agentID)
+}
+
+// DeleteAgentRedirect 删除重定向记录
+func (s *Storage) DeleteAgentRedirect(ctx context.Context, namespace, name string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM agent_redirects WHERE namespace = $1 AND name = $2`, namespace, name)
+	return err
+}
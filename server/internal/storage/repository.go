@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+)
+
+// ErrQueueEmpty 队列在等待时间内没有可取出的任务
+var ErrQueueEmpty = errors.New("queue empty")
+
+// ErrDuplicate 违反唯一约束，例如同一智能体重复发布相同版本号
+var ErrDuplicate = errors.New("already exists")
+
+// Store 聚合 API 层依赖的全部存储能力
+// Storage (PostgreSQL + Redis) 和 memory.Store (进程内) 都实现该接口
+type Store interface {
+	AgentRepository
+	VersionRepository
+	UserRepository
+	KeyRepository
+	CategoryRepository
+	CollaboratorRepository
+	AuditRepository
+	WebhookRepository
+	RateLimiter
+	Queue
+	Close() error
+}
+
+// AgentRepository 智能体存储
+type AgentRepository interface {
+	CreateAgent(ctx context.Context, agent *models.Agent) error
+	GetAgent(ctx context.Context, namespace, name string) (*models.Agent, error)
+	GetAgentByID(ctx context.Context, id string) (*models.Agent, error)
+	ListAgents(ctx context.Context, opts ListAgentsOptions) ([]*models.Agent, error)
+	UpdateAgent(ctx context.Context, agent *models.Agent) error
+	DeleteAgent(ctx context.Context, id string) error
+	IncrementDownloads(ctx context.Context, agentID string) error
+	MoveAgent(ctx context.Context, agent *models.Agent, newNamespace, newName string) error
+	GetAgentByRedirect(ctx context.Context, namespace, name string) (*models.Agent, error)
+	DeleteAgentRedirect(ctx context.Context, namespace, name string) error
+}
+
+// VersionRepository 版本存储
+type VersionRepository interface {
+	CreateVersion(ctx context.Context, version *models.AgentVersion) error
+	GetVersion(ctx context.Context, agentID, version string) (*models.AgentVersion, error)
+	GetLatestVersion(ctx context.Context, agentID string) (*models.AgentVersion, error)
+	ListVersions(ctx context.Context, agentID string, page PageOptions) ([]*models.AgentVersion, error)
+	UpdateVersionStatus(ctx context.Context, id, status string) error
+	ListFiles(ctx context.Context, versionID string) ([]*models.AgentFile, error)
+}
+
+// UserRepository 用户与组织成员存储
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	IsOrgMember(ctx context.Context, orgName, userID string) (bool, error)
+	GetOrgRole(ctx context.Context, orgName, userID string) (string, error)
+}
+
+// KeyRepository API Key 存储
+type KeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]*models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID, id string) error
+	TouchAPIKey(ctx context.Context, id string) error
+	UpdateAPIKeyTier(ctx context.Context, id, tier string) (*models.APIKey, error)
+}
+
+// CategoryRepository 分类存储
+type CategoryRepository interface {
+	ListCategories(ctx context.Context) ([]*models.Category, error)
+	GetCategory(ctx context.Context, id string) (*models.Category, error)
+	CreateCategory(ctx context.Context, category *models.Category) error
+	CategoryCounts(ctx context.Context) (map[string]int64, error)
+}
+
+// CollaboratorRepository 协作者存储
+type CollaboratorRepository interface {
+	UpsertCollaborator(ctx context.Context, collab *models.AgentCollaborator) error
+	GetCollaborator(ctx context.Context, agentID, userID string) (*models.AgentCollaborator, error)
+	ListCollaborators(ctx context.Context, agentID string) ([]*models.AgentCollaborator, error)
+	ListInvitations(ctx context.Context, userID string) ([]*models.AgentCollaborator, error)
+	AcceptInvitation(ctx context.Context, id, userID string) (string, error)
+	DeleteInvitation(ctx context.Context, id, userID string) (string, error)
+	DeleteCollaborator(ctx context.Context, agentID, userID string) error
+}
+
+// AuditRepository 审计日志存储
+type AuditRepository interface {
+	CreateAuditEvent(ctx context.Context, event *models.AuditEvent) error
+	ListAuditEvents(ctx context.Context, q AuditQuery) ([]*models.AuditEvent, error)
+}
+
+// WebhookRepository Webhook 订阅与投递记录存储
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, hook *models.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error)
+	MatchWebhooks(ctx context.Context, event, agentID, namespace string) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id, userID string) error
+	CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error
+	UpdateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID string, page PageOptions) ([]*models.WebhookDelivery, error)
+}
+
+// RateLimitResult 速率限制检查结果
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// RateLimiter 滑动窗口速率限制计数器
+type RateLimiter interface {
+	// Allow 对 key 计一次请求，最近 window 内已有 limit 次时不允许且不计数
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error)
+	// Peek 查询 key 的下一次请求是否允许，不计数
+	Peek(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error)
+	// Reset 清除 key 的计数
+	Reset(ctx context.Context, key string) error
+	// Refund 撤销 key 最近的一次计数，用于先计数、事后确认不应计入的请求
+	Refund(ctx context.Context, key string) error
+}
+
+// Queue 后台任务队列
+type Queue interface {
+	// Push 将任务追加到队列
+	Push(ctx context.Context, queue, value string) error
+	// Pop 从任意一个队列中取出任务，timeout 内无任务时返回 ErrQueueEmpty
+	Pop(ctx context.Context, timeout time.Duration, queues ...string) (string, string, error)
+	// Schedule 在 at 时刻之后将任务放入队列
+	Schedule(ctx context.Context, queue, value string, at time.Time) error
+	// PromoteDue 将到期的延迟任务移入队列，返回移动的数量
+	PromoteDue(ctx context.Context, queue string, limit int) (int, error)
+}
@@ -3,56 +3,47 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/agenthub/server/internal/config"
+	"github.com/agenthub/server/internal/metrics"
 	"github.com/agenthub/server/internal/models"
-	_ "github.com/lib/pq"
+	"github.com/agenthub/server/internal/tracing"
+	"github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 )
 
-// Storage 存储接口
+// Storage 基于 PostgreSQL 和 Redis 的存储实现
 type Storage struct {
 	db    *sql.DB
 	redis *redis.Client
 	cfg   *config.Config
 }
 
+var _ Store = (*Storage)(nil)
+
 // New 创建存储实例
 func New(cfg *config.Config) (*Storage, error) {
-	// 连接 PostgreSQL
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.DBName,
-		cfg.Database.SSLMode,
-	)
-
-	db, err := sql.Open("postgres", dsn)
+	db, err := OpenDB(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
-
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+		return nil, err
 	}
 
 	// 连接 Redis
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	opts, err := redisOptions(&cfg.Redis)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	rdb := redis.NewClient(opts)
+	rdb.AddHook(tracing.RedisHook{})
+	rdb.AddHook(metrics.RedisHook{})
+	rdb.AddHook(redisLogHook{})
 
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
@@ -63,6 +54,48 @@ func New(cfg *config.Config) (*Storage, error) {
 	}, nil
 }
 
+// OpenDB 连接 PostgreSQL，迁移命令只需要数据库连接
+func OpenDB(cfg *config.Config) (*sql.DB, error) {
+	db, err := tracing.OpenDB("postgres", cfg.Database.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// redisOptions 优先解析 REDIS_URL，单独设置的密码和 DB 覆盖 URL 中的值
+func redisOptions(cfg *config.RedisConfig) (*redis.Options, error) {
+	if cfg.URL == "" {
+		return &redis.Options{
+			Addr:     cfg.Addr(),
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		}, nil
+	}
+
+	opts, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	if cfg.Password != "" {
+		opts.Password = cfg.Password
+	}
+	if cfg.DB != 0 {
+		opts.DB = cfg.DB
+	}
+	return opts, nil
+}
+
 // Close 关闭连接
 func (s *Storage) Close() error {
 	if s.redis != nil {
@@ -86,7 +119,7 @@ func (s *Storage) Redis() *redis.Client {
 
 // ===== Agent 操作 =====
 
-// CreateAgent 创建智能体
+// CreateAgent 创建智能体，同名智能体已存在时返回 ErrDuplicate
 func (s *Storage) CreateAgent(ctx context.Context, agent *models.Agent) error {
 	query := `
 		INSERT INTO agents (id, name, namespace, description, category, tags, license, visibility, author_id, homepage, repository, created_at, updated_at)
@@ -94,14 +127,26 @@ func (s *Storage) CreateAgent(ctx context.Context, agent *models.Agent) error {
 	`
 	_, err := s.db.ExecContext(ctx, query,
 		agent.ID, agent.Name, agent.Namespace, agent.Description, agent.Category,
-		agent.Tags, agent.License, agent.Visibility, agent.AuthorID,
+		pq.Array(agent.Tags), agent.License, agent.Visibility, agent.AuthorID,
 		agent.Homepage, agent.Repository, agent.CreatedAt, agent.UpdatedAt,
 	)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err == nil {
+		s.invalidateCategoryCounts(ctx)
+	}
 	return err
 }
 
-// GetAgent 获取智能体
+// GetAgent 获取智能体，结果缓存在 Redis 中
 func (s *Storage) GetAgent(ctx context.Context, namespace, name string) (*models.Agent, error) {
+	key := agentCacheKey(namespace, name)
+	cached := &models.Agent{}
+	if s.cacheGet(ctx, key, cached) {
+		return cached, nil
+	}
+
 	query := `
 		SELECT id, name, namespace, description, category, tags, license, visibility, downloads, likes, author_id, homepage, repository, created_at, updated_at
 		FROM agents
@@ -110,13 +155,14 @@ func (s *Storage) GetAgent(ctx context.Context, namespace, name string) (*models
 	agent := &models.Agent{}
 	err := s.db.QueryRowContext(ctx, query, namespace, name).Scan(
 		&agent.ID, &agent.Name, &agent.Namespace, &agent.Description, &agent.Category,
-		&agent.Tags, &agent.License, &agent.Visibility, &agent.Downloads, &agent.Likes,
+		pq.Array(&agent.Tags), &agent.License, &agent.Visibility, &agent.Downloads, &agent.Likes,
 		&agent.AuthorID, &agent.Homepage, &agent.Repository, &agent.CreatedAt, &agent.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 	agent.FullName = fmt.Sprintf("%s/%s", agent.Namespace, agent.Name)
+	s.cacheSet(ctx, key, agent)
 	return agent, nil
 }
 
@@ -130,7 +176,7 @@ func (s *Storage) GetAgentByID(ctx context.Context, id string) (*models.Agent, e
 	agent := &models.Agent{}
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&agent.ID, &agent.Name, &agent.Namespace, &agent.Description, &agent.Category,
-		&agent.Tags, &agent.License, &agent.Visibility, &agent.Downloads, &agent.Likes,
+		pq.Array(&agent.Tags), &agent.License, &agent.Visibility, &agent.Downloads, &agent.Likes,
 		&agent.AuthorID, &agent.Homepage, &agent.Repository, &agent.CreatedAt, &agent.UpdatedAt,
 	)
 	if err != nil {
@@ -140,10 +186,14 @@ func (s *Storage) GetAgentByID(ctx context.Context, id string) (*models.Agent, e
 	return agent, nil
 }
 
-// ListAgents 列出智能体
-func (s *Storage) ListAgents(ctx context.Context, opts ListAgentsOptions) ([]*models.Agent, int64, error) {
+// ListAgents 列出智能体，按 opts.After 游标分页
+func (s *Storage) ListAgents(ctx context.Context, opts ListAgentsOptions) ([]*models.Agent, error) {
 	// 构建查询
+	// 私有和不公开的智能体只在 IncludePrivate 时出现 (用于所有者查看自己的智能体)
 	baseQuery := `FROM agents WHERE visibility = 'public'`
+	if opts.IncludePrivate {
+		baseQuery = `FROM agents WHERE 1 = 1`
+	}
 	args := []interface{}{}
 	argIndex := 1
 
@@ -165,37 +215,37 @@ func (s *Storage) ListAgents(ctx context.Context, opts ListAgentsOptions) ([]*mo
 		argIndex++
 	}
 
-	// 获取总数
-	var total int64
-	countQuery := "SELECT COUNT(*) " + baseQuery
-	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
+	// 排序，ID 作为排序键相同时的次序
+	column, desc := "updated_at", true
+	switch AgentSort(opts.Sort) {
+	case SortDownloads:
+		column = "downloads"
+	case SortLikes:
+		column = "likes"
+	case SortName:
+		column, desc = "name", false
 	}
-
-	// 排序
-	orderBy := " ORDER BY "
-	switch opts.Sort {
-	case "downloads":
-		orderBy += "downloads DESC"
-	case "likes":
-		orderBy += "likes DESC"
-	case "name":
-		orderBy += "name ASC"
-	default:
-		orderBy += "updated_at DESC"
+	direction := " DESC"
+	if !desc {
+		direction = " ASC"
 	}
+	orderBy := " ORDER BY " + column + direction + ", id" + direction
 
-	// 分页
-	offset := (opts.Page - 1) * opts.PageSize
-	pagination := fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
-	args = append(args, opts.PageSize, offset)
+	// 键集分页
+	if opts.After != nil {
+		baseQuery += keysetCondition(column, desc, argIndex)
+		args = append(args, opts.After.arg(), opts.After.ID)
+		argIndex += 2
+	}
+	pagination := fmt.Sprintf(" LIMIT $%d", argIndex)
+	args = append(args, opts.Limit)
 
 	// 查询列表
 	listQuery := `SELECT id, name, namespace, description, category, tags, license, visibility, downloads, likes, author_id, homepage, repository, created_at, updated_at ` + baseQuery + orderBy + pagination
 
 	rows, err := s.db.QueryContext(ctx, listQuery, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -204,27 +254,28 @@ func (s *Storage) ListAgents(ctx context.Context, opts ListAgentsOptions) ([]*mo
 		agent := &models.Agent{}
 		err := rows.Scan(
 			&agent.ID, &agent.Name, &agent.Namespace, &agent.Description, &agent.Category,
-			&agent.Tags, &agent.License, &agent.Visibility, &agent.Downloads, &agent.Likes,
+			pq.Array(&agent.Tags), &agent.License, &agent.Visibility, &agent.Downloads, &agent.Likes,
 			&agent.AuthorID, &agent.Homepage, &agent.Repository, &agent.CreatedAt, &agent.UpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		agent.FullName = fmt.Sprintf("%s/%s", agent.Namespace, agent.Name)
 		agents = append(agents, agent)
 	}
 
-	return agents, total, nil
+	return agents, rows.Err()
 }
 
 // ListAgentsOptions 列表选项
 type ListAgentsOptions struct {
-	Page     int
-	PageSize int
+	PageOptions
 	Category string
 	Search   string
 	Author   string
 	Sort     string
+	// IncludePrivate 包含私有和不公开的智能体，仅在调用方有权限时设置
+	IncludePrivate bool
 }
 
 // UpdateAgent 更新智能体
@@ -235,17 +286,32 @@ func (s *Storage) UpdateAgent(ctx context.Context, agent *models.Agent) error {
 		WHERE id = $9
 	`
 	_, err := s.db.ExecContext(ctx, query,
-		agent.Description, agent.Category, agent.Tags, agent.License, agent.Visibility,
+		agent.Description, agent.Category, pq.Array(agent.Tags), agent.License, agent.Visibility,
 		agent.Homepage, agent.Repository, time.Now(), agent.ID,
 	)
+	if err == nil {
+		s.invalidateCategoryCounts(ctx)
+		s.invalidateAgent(ctx, agent.Namespace, agent.Name)
+	}
 	return err
 }
 
 // DeleteAgent 删除智能体
 func (s *Storage) DeleteAgent(ctx context.Context, id string) error {
-	query := `DELETE FROM agents WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
+	var namespace, name string
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM agents WHERE id = $1 RETURNING namespace, name`, id,
+	).Scan(&namespace, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidateCategoryCounts(ctx)
+	s.invalidateAgent(ctx, namespace, name)
+	s.invalidateLatestVersion(ctx, id)
+	return nil
 }
 
 // IncrementDownloads 增加下载次数
@@ -257,64 +323,116 @@ func (s *Storage) IncrementDownloads(ctx context.Context, agentID string) error
 
 // ===== Version 操作 =====
 
-// CreateVersion 创建版本
+// CreateVersion 在一个事务中发布版本
+// 锁定智能体行以串行化同一智能体的并发发布，版本号重复时返回 ErrDuplicate
 func (s *Storage) CreateVersion(ctx context.Context, version *models.AgentVersion) error {
-	// 先将其他版本的 is_latest 设为 false
-	_, err := s.db.ExecContext(ctx, `UPDATE agent_versions SET is_latest = false WHERE agent_id = $1`, version.AgentID)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	var agentID string
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM agents WHERE id = $1 FOR UPDATE`, version.AgentID).Scan(&agentID); err != nil {
+		return err
+	}
+
+	// 先将其他版本的 is_latest 设为 false
+	if version.IsLatest {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE agent_versions SET is_latest = false WHERE agent_id = $1 AND is_latest = true`, version.AgentID,
+		); err != nil {
+			return err
+		}
+	}
 
 	query := `
-		INSERT INTO agent_versions (id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO agent_versions (id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, status, package_digest, package_size)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
-	_, err = s.db.ExecContext(ctx, query,
+	_, err = tx.ExecContext(ctx, query,
 		version.ID, version.AgentID, version.Version, version.Digest, version.Size,
 		version.Spec, version.Changelog, version.IsLatest, version.PublishedAt, version.PublishedBy, version.Status,
+		nullString(version.PackageDigest), version.PackageSize,
 	)
-	return err
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+
+	// 包中的文件
+	for _, f := range version.Files {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO agent_files (id, version_id, path, size, digest, mime_type, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, f.ID, version.ID, f.Path, f.Size, f.Digest, f.MimeType, f.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.invalidateLatestVersion(ctx, version.AgentID)
+	return nil
 }
 
 // GetVersion 获取特定版本
 func (s *Storage) GetVersion(ctx context.Context, agentID, version string) (*models.AgentVersion, error) {
 	query := `
-		SELECT id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, downloads, status
+		SELECT id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, downloads, status, COALESCE(package_digest, ''), package_size
 		FROM agent_versions
 		WHERE agent_id = $1 AND version = $2
 	`
 	v := &models.AgentVersion{}
 	err := s.db.QueryRowContext(ctx, query, agentID, version).Scan(
 		&v.ID, &v.AgentID, &v.Version, &v.Digest, &v.Size, &v.Spec, &v.Changelog,
-		&v.IsLatest, &v.PublishedAt, &v.PublishedBy, &v.Downloads, &v.Status,
+		&v.IsLatest, &v.PublishedAt, &v.PublishedBy, &v.Downloads, &v.Status, &v.PackageDigest, &v.PackageSize,
 	)
 	return v, err
 }
 
-// GetLatestVersion 获取最新版本
+// GetLatestVersion 获取最新版本，结果缓存在 Redis 中
 func (s *Storage) GetLatestVersion(ctx context.Context, agentID string) (*models.AgentVersion, error) {
+	key := latestVersionCacheKey(agentID)
+	cached := &models.AgentVersion{}
+	if s.cacheGet(ctx, key, cached) {
+		return cached, nil
+	}
+
 	query := `
-		SELECT id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, downloads, status
+		SELECT id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, downloads, status, COALESCE(package_digest, ''), package_size
 		FROM agent_versions
 		WHERE agent_id = $1 AND is_latest = true
 	`
 	v := &models.AgentVersion{}
 	err := s.db.QueryRowContext(ctx, query, agentID).Scan(
 		&v.ID, &v.AgentID, &v.Version, &v.Digest, &v.Size, &v.Spec, &v.Changelog,
-		&v.IsLatest, &v.PublishedAt, &v.PublishedBy, &v.Downloads, &v.Status,
+		&v.IsLatest, &v.PublishedAt, &v.PublishedBy, &v.Downloads, &v.Status, &v.PackageDigest, &v.PackageSize,
 	)
+	if err == nil {
+		s.cacheSet(ctx, key, v)
+	}
 	return v, err
 }
 
-// ListVersions 列出所有版本
-func (s *Storage) ListVersions(ctx context.Context, agentID string) ([]*models.AgentVersion, error) {
+// ListVersions 列出版本，按发布时间倒序分页
+func (s *Storage) ListVersions(ctx context.Context, agentID string, page PageOptions) ([]*models.AgentVersion, error) {
 	query := `
-		SELECT id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, downloads, status
+		SELECT id, agent_id, version, digest, size, spec, changelog, is_latest, published_at, published_by, downloads, status, COALESCE(package_digest, ''), package_size
 		FROM agent_versions
-		WHERE agent_id = $1
-		ORDER BY published_at DESC
-	`
-	rows, err := s.db.QueryContext(ctx, query, agentID)
+		WHERE agent_id = $1`
+	args := []interface{}{agentID}
+	if page.After != nil {
+		query += keysetCondition("published_at", true, 2)
+		args = append(args, page.After.Time(), page.After.ID)
+	}
+	query += fmt.Sprintf(" ORDER BY published_at DESC, id DESC LIMIT $%d", len(args)+1)
+	args = append(args, page.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -325,19 +443,68 @@ func (s *Storage) ListVersions(ctx context.Context, agentID string) ([]*models.A
 		v := &models.AgentVersion{}
 		err := rows.Scan(
 			&v.ID, &v.AgentID, &v.Version, &v.Digest, &v.Size, &v.Spec, &v.Changelog,
-			&v.IsLatest, &v.PublishedAt, &v.PublishedBy, &v.Downloads, &v.Status,
+			&v.IsLatest, &v.PublishedAt, &v.PublishedBy, &v.Downloads, &v.Status, &v.PackageDigest, &v.PackageSize,
 		)
 		if err != nil {
 			return nil, err
 		}
 		versions = append(versions, v)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return versions, nil
 }
 
+// ListFiles 列出版本包中的文件，按路径排序
+func (s *Storage) ListFiles(ctx context.Context, versionID string) ([]*models.AgentFile, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, version_id, path, size, digest, mime_type, created_at
+		FROM agent_files
+		WHERE version_id = $1
+		ORDER BY path
+	`, versionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*models.AgentFile
+	for rows.Next() {
+		f := &models.AgentFile{}
+		if err := rows.Scan(&f.ID, &f.VersionID, &f.Path, &f.Size, &f.Digest, &f.MimeType, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// UpdateVersionStatus 更新版本状态 (active, deprecated)
+func (s *Storage) UpdateVersionStatus(ctx context.Context, id, status string) error {
+	var agentID string
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE agent_versions SET status = $1 WHERE id = $2 RETURNING agent_id`, status, id,
+	).Scan(&agentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidateLatestVersion(ctx, agentID)
+	return nil
+}
+
+// isUniqueViolation 判断是否为 PostgreSQL 唯一约束冲突 (23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // ===== User 操作 =====
 
-// CreateUser 创建用户
+// CreateUser 创建用户，用户名或邮箱已存在时返回 ErrDuplicate
 func (s *Storage) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
 		INSERT INTO users (id, username, email, password_hash, display_name, status, created_at, updated_at)
@@ -347,6 +514,9 @@ func (s *Storage) CreateUser(ctx context.Context, user *models.User) error {
 		user.ID, user.Username, user.Email, user.PasswordHash, user.DisplayName,
 		user.Status, user.CreatedAt, user.UpdatedAt,
 	)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
 	return err
 }
 
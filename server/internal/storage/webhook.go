@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/lib/pq"
+)
+
+// ===== Webhook 操作 =====
+
+const webhookColumns = `id, scope, target, target_name, url, secret, events, is_active, created_by, created_at`
+
+// CreateWebhook 创建 Webhook 订阅
+func (s *Storage) CreateWebhook(ctx context.Context, hook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, scope, target, target_name, url, secret, events, is_active, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		hook.ID, hook.Scope, hook.Target, hook.TargetName, hook.URL, hook.Secret,
+		pq.Array(hook.Events), hook.IsActive, hook.CreatedBy, hook.CreatedAt,
+	)
+	return err
+}
+
+// GetWebhook 获取 Webhook
+func (s *Storage) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	return scanWebhook(s.db.QueryRowContext(ctx, query, id))
+}
+
+// ListWebhooks 列出用户创建的 Webhook
+func (s *Storage) ListWebhooks(ctx context.Context, userID string) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE created_by = $1 ORDER BY created_at DESC`
+	return s.queryWebhooks(ctx, query, userID)
+}
+
+// MatchWebhooks 查找订阅了指定事件的 Webhook
+// 匹配智能体级订阅以及智能体所在命名空间的组织/用户级订阅
+func (s *Storage) MatchWebhooks(ctx context.Context, event, agentID, namespace string) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks
+		WHERE is_active = true AND $1 = ANY(events)
+		AND ((scope = 'agent' AND target = $2) OR (scope IN ('org', 'user') AND target = $3))`
+	return s.queryWebhooks(ctx, query, event, agentID, namespace)
+}
+
+// DeleteWebhook 删除用户的 Webhook
+func (s *Storage) DeleteWebhook(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND created_by = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *Storage) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]*models.Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*models.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	hook := &models.Webhook{}
+	var targetName sql.NullString
+	err := row.Scan(
+		&hook.ID, &hook.Scope, &hook.Target, &targetName, &hook.URL, &hook.Secret,
+		pq.Array(&hook.Events), &hook.IsActive, &hook.CreatedBy, &hook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	hook.TargetName = targetName.String
+	return hook, nil
+}
+
+// ===== Webhook 投递记录 =====
+
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, response_status, response_body, error, next_attempt_at, created_at, delivered_at`
+
+// CreateWebhookDelivery 创建投递记录
+func (s *Storage) CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		d.ID, d.WebhookID, d.Event, []byte(d.Payload), d.Status, d.Attempts, d.CreatedAt,
+	)
+	return err
+}
+
+// UpdateWebhookDelivery 更新投递结果
+func (s *Storage) UpdateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, response_body = $4, error = $5, next_attempt_at = $6, delivered_at = $7
+		WHERE id = $8
+	`
+	_, err := s.db.ExecContext(ctx, query,
+		d.Status, d.Attempts, sql.NullInt64{Int64: int64(d.ResponseStatus), Valid: d.ResponseStatus != 0},
+		nullString(d.ResponseBody), nullString(d.Error), d.NextAttemptAt, d.DeliveredAt, d.ID,
+	)
+	return err
+}
+
+// GetWebhookDelivery 获取投递记录
+func (s *Storage) GetWebhookDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	return scanDelivery(s.db.QueryRowContext(ctx, query, id))
+}
+
+// ListWebhookDeliveries 列出 Webhook 的投递记录，按时间倒序分页
+func (s *Storage) ListWebhookDeliveries(ctx context.Context, webhookID string, page PageOptions) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = $1`
+	args := []interface{}{webhookID}
+	if page.After != nil {
+		query += keysetCondition("created_at", true, 2)
+		args = append(args, page.After.Time(), page.After.ID)
+	}
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args)+1)
+	args = append(args, page.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	var payload []byte
+	var responseStatus sql.NullInt64
+	var responseBody, errMsg sql.NullString
+	var nextAttemptAt, deliveredAt sql.NullTime
+	err := row.Scan(
+		&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts,
+		&responseStatus, &responseBody, &errMsg, &nextAttemptAt, &d.CreatedAt, &deliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	d.ResponseStatus = int(responseStatus.Int64)
+	d.ResponseBody = responseBody.String
+	d.Error = errMsg.String
+	if nextAttemptAt.Valid {
+		d.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, nil
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenDB 打开带追踪的数据库连接，每条查询和事务生成一个 span
+func OpenDB(driver, dsn string) (*sql.DB, error) {
+	return otelsql.Open(driver, dsn,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+	)
+}
+
+// RedisHook 为每条 Redis 命令生成客户端 span，通过 redis.Client.AddHook 注册
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+// DialHook 不做处理
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 单条命令
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := strings.ToLower(cmd.Name())
+		ctx, span := Tracer().Start(ctx, "redis "+name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationName(name)),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		if err != redis.Nil {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
+
+// ProcessPipelineHook 整个 pipeline 一个 span
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := Tracer().Start(ctx, "redis pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemRedis),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		if err != redis.Nil {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
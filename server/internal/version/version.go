@@ -0,0 +1,48 @@
+// Package version 构建版本信息，通过 -ldflags 注入:
+//
+//	go build -ldflags "-X github.com/agenthub/server/internal/version.Version=v1.2.0 \
+//	  -X github.com/agenthub/server/internal/version.Commit=$(git rev-parse --short HEAD)" ./cmd/server
+package version
+
+import "runtime/debug"
+
+var (
+	// Version 发布版本号
+	Version = "dev"
+	// Commit 构建时的 git 提交
+	Commit = ""
+	// BuildTime 构建时间 (RFC 3339)
+	BuildTime = ""
+)
+
+func init() {
+	if Commit != "" {
+		return
+	}
+	// 未通过 ldflags 注入时使用 go build 记录的 VCS 信息
+	Commit = "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				Commit = setting.Value
+				if len(Commit) > 12 {
+					Commit = Commit[:12]
+				}
+			case "vcs.time":
+				if BuildTime == "" {
+					BuildTime = setting.Value
+				}
+			}
+		}
+	}
+}
+
+// Info 版本信息
+func Info() map[string]string {
+	return map[string]string{
+		"version":    Version,
+		"commit":     Commit,
+		"build_time": BuildTime,
+	}
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress 投递地址指向内网、本机或其他不允许访问的地址
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// maxRedirects 投递时最多跟随的重定向次数
+const maxRedirects = 3
+
+// forbiddenPrefixes 除 netip 自带判断外额外禁止的网段
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"),  // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF 协议分配
+	netip.MustParsePrefix("198.18.0.0/15"),  // 基准测试
+	netip.MustParsePrefix("240.0.0.0/4"),    // 保留地址和广播地址
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64，可映射到任意 IPv4 地址
+	netip.MustParsePrefix("64:ff9b:1::/48"), // 本地 NAT64
+	netip.MustParsePrefix("2001::/32"),      // Teredo
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+	netip.MustParsePrefix("fec0::/10"),      // 已废弃的站点本地地址
+	netip.MustParsePrefix("100::/64"),       // 丢弃前缀
+	netip.MustParsePrefix("2001:db8::/32"),  // 文档示例
+}
+
+// IsForbiddenIP 是否为不允许投递的地址：本机、链路本地、内网、未指定、组播及保留地址
+func IsForbiddenIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsPrivate() ||
+		addr.IsUnspecified() || addr.IsMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsLinkLocalMulticast() {
+		return true
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL 检查订阅地址：只允许 http/https，主机为 IP 时不能是内网等地址
+// 域名在投递时解析，解析结果由 dialer 再次检查，这里只拒绝 localhost 一类的名称
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.New("missing host")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && IsForbiddenIP(addr) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// newClient 投递用的 HTTP 客户端
+// 地址检查放在建立连接时，对 DNS 解析后的实际 IP 生效，避免检查后解析结果被修改 (DNS rebinding)；
+// 不使用环境变量中的代理，否则连接的是代理而不是目标地址
+func newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   controlDial,
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:       deliveryTimeout,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// controlDial 在连接前检查解析后的地址
+func controlDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if IsForbiddenIP(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// checkRedirect 限制重定向次数，重定向目标同样要通过地址检查
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if err := ValidateURL(req.URL.String()); err != nil {
+		return fmt.Errorf("redirect to %s: %w", req.URL.Redacted(), err)
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/agenthub/server/internal/access"
+	"github.com/agenthub/server/internal/logging"
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 队列名
+const (
+	eventQueueKey    = "webhooks:events"     // 待展开的事件
+	deliveryQueueKey = "webhooks:deliveries" // 待投递的投递记录 ID，重试时延迟放回
+)
+
+const (
+	// MaxAttempts 单次投递的最大尝试次数
+	MaxAttempts = 6
+	// baseBackoff 首次重试间隔，之后每次翻倍
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+
+	deliveryTimeout = 10 * time.Second
+	maxResponseBody = 2048
+	defaultWorkers  = 4
+)
+
+// SignatureHeader 请求体 HMAC-SHA256 签名头，格式为 sha256=<hex>
+const SignatureHeader = "X-AgentHub-Signature-256"
+
+// Event 入队的注册表事件
+type Event struct {
+	Event      string                 `json:"event"`
+	AgentID    string                 `json:"agent_id"`
+	Namespace  string                 `json:"namespace"`
+	Visibility string                 `json:"visibility"`
+	Payload    map[string]interface{} `json:"payload"`
+	CreatedAt  time.Time              `json:"created_at"`
+	// TraceContext 发布事件的请求链路 (W3C traceparent)，展开订阅时延续同一条链路
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// Store 分发器依赖的存储，私有智能体的事件需要按订阅者当前的权限过滤
+type Store interface {
+	storage.WebhookRepository
+	storage.Queue
+	access.Store
+}
+
+// Dispatcher Webhook 分发器
+// 事件先写入队列 (生产环境为 Redis)，由后台 worker 匹配订阅、签名投递并按指数退避重试，
+// 请求处理路径只做一次入队
+type Dispatcher struct {
+	store   Store
+	client  *http.Client
+	workers int
+}
+
+// NewDispatcher 创建分发器
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		client:  newClient(),
+		workers: defaultWorkers,
+	}
+}
+
+// Publish 发布智能体相关事件
+// 入队失败只记录日志，不影响业务请求；业务操作已完成，入队不随请求取消
+func (d *Dispatcher) Publish(ctx context.Context, event string, agent *models.Agent, data map[string]interface{}) {
+	if d == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	payload := map[string]interface{}{
+		"event":     event,
+		"timestamp": time.Now().UTC(),
+		"agent": map[string]interface{}{
+			"id":         agent.ID,
+			"namespace":  agent.Namespace,
+			"name":       agent.Name,
+			"full_name":  agent.FullName,
+			"visibility": agent.Visibility,
+		},
+	}
+	for key, value := range data {
+		payload[key] = value
+	}
+
+	raw, err := json.Marshal(&Event{
+		Event:      event,
+		AgentID:    agent.ID,
+		Namespace:  agent.Namespace,
+		Visibility: agent.Visibility,
+		Payload:    payload,
+		CreatedAt:  time.Now(),
+
+		TraceContext: tracing.InjectMap(ctx),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to encode webhook event", "event", event, "error", err)
+		return
+	}
+	if err := d.store.Push(ctx, eventQueueKey, string(raw)); err != nil {
+		logging.FromContext(ctx).Error("failed to enqueue webhook event", "event", event, "error", err)
+	}
+}
+
+// Redeliver 以相同负载重新投递一条历史记录，返回新的投递记录
+func (d *Dispatcher) Redeliver(ctx context.Context, original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.New().String(),
+		WebhookID: original.WebhookID,
+		Event:     original.Event,
+		Payload:   original.Payload,
+		Status:    models.DeliveryPending,
+		CreatedAt: time.Now(),
+	}
+	if err := d.store.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	if err := d.store.Push(ctx, deliveryQueueKey, delivery.ID); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Run 启动后台 worker，阻塞直到 ctx 取消
+func (d *Dispatcher) Run(ctx context.Context) {
+	ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("component", "webhook"))
+
+	var wg sync.WaitGroup
+	wg.Add(d.workers + 1)
+	go func() {
+		defer wg.Done()
+		d.scheduleRetries(ctx)
+	}()
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			defer wg.Done()
+			d.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work 从队列中取出事件或投递任务并处理
+func (d *Dispatcher) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		queue, value, err := d.store.Pop(ctx, 5*time.Second, deliveryQueueKey, eventQueueKey)
+		if err == storage.ErrQueueEmpty {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logging.FromContext(ctx).Error("webhook queue error", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		switch queue {
+		case eventQueueKey:
+			d.fanOut(ctx, value)
+		case deliveryQueueKey:
+			d.deliver(ctx, value)
+		}
+	}
+}
+
+// scheduleRetries 将到期的重试任务移回投递队列
+func (d *Dispatcher) scheduleRetries(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := d.store.PromoteDue(ctx, deliveryQueueKey, 100); err != nil {
+			logging.FromContext(ctx).Error("failed to promote webhook retries", "error", err)
+		}
+	}
+}
+
+// fanOut 为匹配的订阅创建投递记录
+func (d *Dispatcher) fanOut(ctx context.Context, raw string) {
+	var event Event
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		logging.FromContext(ctx).Error("invalid webhook event", "error", err)
+		return
+	}
+
+	ctx, span := tracing.Start(tracing.ExtractMap(ctx, event.TraceContext), "webhook.fan_out",
+		attribute.String("webhook.event", event.Event),
+	)
+	defer span.End()
+
+	hooks, err := d.store.MatchWebhooks(ctx, event.Event, event.AgentID, event.Namespace)
+	if err != nil {
+		tracing.RecordError(span, err)
+		logging.FromContext(ctx).Error("failed to match webhooks", "event", event.Event, "error", err)
+		return
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return
+	}
+
+	// 按事件发生时智能体所在的命名空间判断，转移后的事件按新的命名空间判断
+	agent := &models.Agent{ID: event.AgentID, Namespace: event.Namespace, Visibility: event.Visibility}
+	for _, hook := range hooks {
+		// 私有智能体的事件只投递给当前仍可读取该智能体的订阅者 (所有者、组织成员、已接受的协作者)，
+		// 与 API 的权限规则一致；订阅者失去权限后不再收到事件
+		if !access.CanRead(ctx, d.store, access.Subject{UserID: hook.CreatedBy}, agent) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			ID:        uuid.New().String(),
+			WebhookID: hook.ID,
+			Event:     event.Event,
+			Payload:   payload,
+			Status:    models.DeliveryPending,
+			CreatedAt: time.Now(),
+		}
+		if err := d.store.CreateWebhookDelivery(ctx, delivery); err != nil {
+			logging.FromContext(ctx).Error("failed to create webhook delivery", "webhook_id", hook.ID, "error", err)
+			continue
+		}
+		d.store.Push(ctx, deliveryQueueKey, delivery.ID)
+	}
+}
+
+// deliver 执行一次投递尝试，失败时安排重试
+func (d *Dispatcher) deliver(ctx context.Context, id string) {
+	delivery, err := d.store.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Warn("webhook delivery not found", "delivery_id", id, "error", err)
+		return
+	}
+	if delivery.Status != models.DeliveryPending {
+		return
+	}
+
+	hook, err := d.store.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil || !hook.IsActive {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = "webhook not found or inactive"
+		delivery.NextAttemptAt = nil
+		d.store.UpdateWebhookDelivery(ctx, delivery)
+		return
+	}
+
+	delivery.Attempts++
+	ctx, span := tracing.Start(ctx, "webhook.deliver",
+		attribute.String("webhook.id", hook.ID),
+		attribute.String("webhook.delivery_id", delivery.ID),
+		attribute.String("webhook.event", delivery.Event),
+		attribute.Int("webhook.attempt", delivery.Attempts),
+	)
+	defer span.End()
+
+	status, body, err := d.send(ctx, hook, delivery)
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	tracing.RecordError(span, err)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.Error = ""
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case err == nil && status >= 200 && status < 300:
+		now := time.Now()
+		delivery.Status = models.DeliverySuccess
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= MaxAttempts || errors.Is(err, ErrForbiddenAddress):
+		// 地址不允许访问时重试也不会成功
+		delivery.Status = models.DeliveryFailed
+	default:
+		next := time.Now().Add(Backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		d.store.Schedule(ctx, deliveryQueueKey, delivery.ID, next)
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	} else if delivery.Status != models.DeliverySuccess {
+		delivery.Error = fmt.Sprintf("unexpected status %d", status)
+	}
+
+	if err := d.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		logging.FromContext(ctx).Error("failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+	logging.FromContext(ctx).Debug("webhook delivery attempted",
+		"delivery_id", delivery.ID,
+		"webhook_id", hook.ID,
+		"attempt", delivery.Attempts,
+		"status", delivery.Status,
+		"response_status", status,
+	)
+}
+
+// send 发送签名请求，返回响应状态码和截断后的响应体
+func (d *Dispatcher) send(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AgentHub-Webhook/1.0")
+	req.Header.Set("X-AgentHub-Event", delivery.Event)
+	req.Header.Set("X-AgentHub-Delivery", delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, delivery.Payload))
+	tracing.InjectHTTP(ctx, req.Header)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return resp.StatusCode, string(body), nil
+}
+
+// Sign 计算请求体签名，接收方用相同密钥计算后做常量时间比较
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff 第 attempt 次失败后的重试间隔，指数增长并带 ±20% 抖动
+func Backoff(attempt int) time.Duration {
+	delay := baseBackoff << uint(attempt-1)
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5*2+1)) - delay/5
+	return delay + jitter
+}
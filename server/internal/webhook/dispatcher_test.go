@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/agenthub/server/internal/models"
+	"github.com/agenthub/server/internal/storage"
+	"github.com/agenthub/server/internal/storage/memory"
+	"github.com/google/uuid"
+)
+
+func TestFanOutPrivateAgentFollowsReadAccess(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	d := NewDispatcher(store)
+
+	users := make(map[string]string)
+	for _, name := range []string{"owner", "member", "collab", "pending", "former", "stranger"} {
+		user := &models.User{ID: uuid.New().String(), Username: name, Email: name + "@example.com", Status: "active", CreatedAt: time.Now()}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		users[name] = user.ID
+	}
+	store.AddOrgMember("acme", users["owner"], models.OrgRoleOwner)
+	store.AddOrgMember("acme", users["member"], models.OrgRoleMember)
+
+	agent := &models.Agent{
+		ID:         uuid.New().String(),
+		Namespace:  "acme",
+		Name:       "bot",
+		Visibility: models.VisibilityPrivate,
+		AuthorID:   users["former"], // 作者本身不带权限，例如智能体已转移到组织
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := store.CreateAgent(ctx, agent); err != nil {
+		t.Fatal(err)
+	}
+	for name, status := range map[string]string{"collab": models.CollaboratorAccepted, "pending": models.CollaboratorPending} {
+		if err := store.UpsertCollaborator(ctx, &models.AgentCollaborator{
+			ID:         uuid.New().String(),
+			AgentID:    agent.ID,
+			UserID:     users[name],
+			Username:   name,
+			Permission: models.PermissionRead,
+			Status:     status,
+			CreatedAt:  time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hooks := make(map[string]string)
+	for name, userID := range users {
+		hook := &models.Webhook{
+			ID:        uuid.New().String(),
+			Scope:     models.WebhookScopeAgent,
+			Target:    agent.ID,
+			URL:       "https://hooks.example.com/" + name,
+			Events:    []string{models.EventVersionPublished},
+			IsActive:  true,
+			CreatedBy: userID,
+			CreatedAt: time.Now(),
+		}
+		if err := store.CreateWebhook(ctx, hook); err != nil {
+			t.Fatal(err)
+		}
+		hooks[name] = hook.ID
+	}
+
+	raw, err := json.Marshal(&Event{
+		Event:      models.EventVersionPublished,
+		AgentID:    agent.ID,
+		Namespace:  agent.Namespace,
+		Visibility: agent.Visibility,
+		Payload:    map[string]interface{}{"event": models.EventVersionPublished},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.fanOut(ctx, string(raw))
+
+	want := map[string]bool{"owner": true, "member": true, "collab": true}
+	for name, hookID := range hooks {
+		deliveries, err := store.ListWebhookDeliveries(ctx, hookID, storage.PageOptions{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(deliveries) == 1; got != want[name] {
+			t.Errorf("%s: delivered = %v, want %v", name, got, want[name])
+		}
+	}
+}
@@ -0,0 +1,20 @@
+-- 回滚 AgentHub 初始化脚本
+-- 扩展可能被其他库对象使用，不在此删除
+
+DROP TRIGGER IF EXISTS organizations_updated_at ON organizations;
+DROP TRIGGER IF EXISTS agents_updated_at ON agents;
+DROP TRIGGER IF EXISTS users_updated_at ON users;
+DROP FUNCTION IF EXISTS update_updated_at();
+
+DROP TABLE IF EXISTS invocation_logs;
+DROP TABLE IF EXISTS agent_stars;
+DROP TABLE IF EXISTS agent_likes;
+DROP TABLE IF EXISTS user_follows;
+DROP TABLE IF EXISTS access_tokens;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS agent_files;
+DROP TABLE IF EXISTS agent_versions;
+DROP TABLE IF EXISTS agents;
+DROP TABLE IF EXISTS org_members;
+DROP TABLE IF EXISTS organizations;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,28 @@
+-- 分类注册表
+
+-- 分类表 (支持一级子分类)
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(32) PRIMARY KEY,
+    parent_id VARCHAR(32) REFERENCES categories(id) ON DELETE CASCADE,
+    name_zh VARCHAR(64) NOT NULL,
+    name_en VARCHAR(64) NOT NULL,
+    icon VARCHAR(64),
+    sort_order INT DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_categories_parent_id ON categories(parent_id);
+
+-- 内置分类
+INSERT INTO categories (id, name_zh, name_en, icon, sort_order) VALUES
+    ('assistant', '通用助手', 'Assistant', 'bot', 10),
+    ('coding', '编程开发', 'Coding', 'code', 20),
+    ('writing', '写作创作', 'Writing', 'pen-tool', 30),
+    ('analysis', '数据分析', 'Analysis', 'bar-chart', 40),
+    ('creative', '创意设计', 'Creative', 'palette', 50),
+    ('education', '教育学习', 'Education', 'graduation-cap', 60),
+    ('business', '商业办公', 'Business', 'briefcase', 70),
+    ('research', '研究探索', 'Research', 'sparkles', 80),
+    ('tooling', '工具效率', 'Tooling', 'wrench', 90),
+    ('other', '其他', 'Other', 'more-horizontal', 100)
+ON CONFLICT (id) DO NOTHING;
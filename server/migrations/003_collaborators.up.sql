@@ -0,0 +1,17 @@
+-- 智能体协作者
+
+-- 协作者授权表 (邀请接受后生效)
+CREATE TABLE IF NOT EXISTS agent_collaborators (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    agent_id UUID REFERENCES agents(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    permission VARCHAR(20) NOT NULL DEFAULT 'read',  -- read, publish, admin
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',   -- pending, accepted
+    invited_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(agent_id, user_id)
+);
+
+CREATE INDEX idx_collaborators_agent_id ON agent_collaborators(agent_id);
+CREATE INDEX idx_collaborators_user_id ON agent_collaborators(user_id);
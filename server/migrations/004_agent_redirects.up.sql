@@ -0,0 +1,11 @@
+-- 智能体重定向 (转移和重命名后保留旧名称)
+
+CREATE TABLE IF NOT EXISTS agent_redirects (
+    namespace VARCHAR(32) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    agent_id UUID REFERENCES agents(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (namespace, name)
+);
+
+CREATE INDEX idx_redirects_agent_id ON agent_redirects(agent_id);
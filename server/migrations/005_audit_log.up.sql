@@ -0,0 +1,34 @@
+-- 审计日志 (只追加)
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    action VARCHAR(64) NOT NULL,
+    actor_id UUID,
+    actor_name VARCHAR(64),
+    ip VARCHAR(64),
+    user_agent VARCHAR(500),
+    target_type VARCHAR(32) NOT NULL,
+    target_id VARCHAR(64),
+    target_name VARCHAR(255),
+    agent_id UUID,       -- 不设外键，智能体删除后仍保留记录
+    namespace VARCHAR(32),
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_agent_id ON audit_log(agent_id, created_at DESC);
+CREATE INDEX idx_audit_namespace ON audit_log(namespace, created_at DESC);
+CREATE INDEX idx_audit_actor_id ON audit_log(actor_id, created_at DESC);
+CREATE INDEX idx_audit_created_at ON audit_log(created_at DESC);
+
+-- 禁止修改和删除审计记录
+CREATE OR REPLACE FUNCTION audit_log_immutable()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
@@ -0,0 +1,34 @@
+-- Webhook 订阅与投递记录
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    scope VARCHAR(16) NOT NULL,        -- agent, org, user
+    target VARCHAR(64) NOT NULL,       -- agent 为智能体 ID，org/user 为命名空间
+    target_name VARCHAR(255),          -- 创建时的显示名称
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    events TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhooks_target ON webhooks(scope, target);
+CREATE INDEX idx_webhooks_created_by ON webhooks(created_by);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    webhook_id UUID REFERENCES webhooks(id) ON DELETE CASCADE,
+    event VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',  -- pending, success, failed
+    attempts INT DEFAULT 0,
+    response_status INT,
+    response_body TEXT,
+    error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_versions_one_latest;
@@ -0,0 +1,14 @@
+-- 每个智能体最多只有一个 latest 版本
+
+-- 修复并发发布留下的多个 latest，只保留最近发布的版本
+UPDATE agent_versions v
+SET is_latest = false
+WHERE is_latest = true
+  AND EXISTS (
+    SELECT 1 FROM agent_versions newer
+    WHERE newer.agent_id = v.agent_id
+      AND newer.is_latest = true
+      AND (newer.published_at, newer.id) > (v.published_at, v.id)
+  );
+
+CREATE UNIQUE INDEX idx_versions_one_latest ON agent_versions(agent_id) WHERE is_latest;
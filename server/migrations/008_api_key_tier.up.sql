@@ -0,0 +1,2 @@
+-- API Key 配额等级，决定 /invoke 等接口的限流倍数
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tier VARCHAR(20) NOT NULL DEFAULT 'free';
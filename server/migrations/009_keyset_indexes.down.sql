@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_deliveries_webhook_id;
+CREATE INDEX idx_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
+
+DROP INDEX IF EXISTS idx_audit_created_at;
+CREATE INDEX idx_audit_created_at ON audit_log(created_at DESC);
+
+DROP INDEX IF EXISTS idx_versions_agent_published;
+
+DROP INDEX IF EXISTS idx_agents_name;
+DROP INDEX IF EXISTS idx_agents_updated_at;
+DROP INDEX IF EXISTS idx_agents_likes;
+DROP INDEX IF EXISTS idx_agents_downloads;
+CREATE INDEX idx_agents_downloads ON agents(downloads DESC);
+CREATE INDEX idx_agents_likes ON agents(likes DESC);
@@ -0,0 +1,15 @@
+-- 键集分页按 (排序键, id) 比较和排序，索引需包含 id
+DROP INDEX IF EXISTS idx_agents_downloads;
+DROP INDEX IF EXISTS idx_agents_likes;
+CREATE INDEX idx_agents_downloads ON agents(downloads DESC, id DESC);
+CREATE INDEX idx_agents_likes ON agents(likes DESC, id DESC);
+CREATE INDEX idx_agents_updated_at ON agents(updated_at DESC, id DESC);
+CREATE INDEX idx_agents_name ON agents(name, id);
+
+CREATE INDEX idx_versions_agent_published ON agent_versions(agent_id, published_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_audit_created_at;
+CREATE INDEX idx_audit_created_at ON audit_log(created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_deliveries_webhook_id;
+CREATE INDEX idx_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+-- 补齐的摘要与内容一致，回滚时保留
+SELECT 1;
@@ -0,0 +1,4 @@
+-- 早期发布的版本未计算摘要，按 spec 内容补齐 (与发布时的 SHA-256 十六进制一致)
+UPDATE agent_versions
+SET digest = encode(sha256(convert_to(spec, 'UTF8')), 'hex')
+WHERE (digest IS NULL OR digest = '') AND spec IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE agent_versions DROP COLUMN IF EXISTS package_size;
+ALTER TABLE agent_versions DROP COLUMN IF EXISTS package_digest;
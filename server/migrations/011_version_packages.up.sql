@@ -0,0 +1,3 @@
+-- 版本可以附带项目包 (tar.gz)，包中的文件记录在 agent_files
+ALTER TABLE agent_versions ADD COLUMN IF NOT EXISTS package_digest VARCHAR(64);
+ALTER TABLE agent_versions ADD COLUMN IF NOT EXISTS package_size BIGINT NOT NULL DEFAULT 0;
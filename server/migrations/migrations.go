@@ -0,0 +1,10 @@
+// Package migrations 内嵌数据库迁移脚本
+// 文件命名为 NNN_name.up.sql / NNN_name.down.sql，版本号递增且不可修改已发布的脚本
+package migrations
+
+import "embed"
+
+// FS 全部迁移脚本
+//
+//go:embed *.sql
+var FS embed.FS